@@ -84,7 +84,10 @@ show_help() {
     echo "  migrate-db DB  - 迁移指定数据库"
     echo "  migrate-reset-db DB - 重置指定数据库"
     echo "  build-sqlexec  - 构建SQL执行工具"
-    echo "  seed           - 填充测试数据"
+    echo "  seed [DB]      - 填充测试数据"
+    echo "  anonymize DB   - 生成脱敏的数据库副本"
+    echo "  fsck [DB]      - 检查数据一致性（REPAIR=1 时执行安全修复）"
+    echo "  rekey [DB]     - 使用当前密钥重新加密敏感字段"
     echo "  info           - 查看项目信息"
     echo "  dev            - 启动开发模式（热重载）"
     echo "  profile        - 性能分析"
@@ -99,6 +102,7 @@ show_help() {
     echo "  PORT           - 服务端口 (默认: 8080)"
     echo "  DB             - 数据库名称 (用于数据库操作)"
     echo "  PKG            - 包名称 (用于测试指定包)"
+    echo "  REPAIR         - 设为 1 时 fsck 执行安全修复"
     echo ""
 }
 
@@ -337,8 +341,51 @@ createdb() {
 
 # 填充测试数据
 seed() {
+    local db=${1:-$DB}
     log_info "填充测试数据..."
-    log_warning "请根据实际需求运行数据填充脚本"
+    if [ -n "$db" ]; then
+        go run cmd/seed/main.go -db "${db}"
+    else
+        go run cmd/seed/main.go
+    fi
+}
+
+# 生成脱敏的分公司数据库副本
+anonymize() {
+    local db=${1:-$DB}
+    if [ -z "$db" ]; then
+        log_error "请指定数据库名称"
+        echo "用法: $0 anonymize <数据库名>"
+        echo "示例: HRMS_ENV=prod $0 anonymize hrms_C001"
+        exit 1
+    fi
+    log_info "生成脱敏数据库副本: ${db}"
+    go run cmd/anonymize/main.go -db "${db}"
+}
+
+# 检查分公司数据库的数据一致性
+fsck() {
+    local db=${1:-$DB}
+    local args=""
+    if [ -n "$db" ]; then
+        args="-db ${db}"
+    fi
+    if [ "${REPAIR}" = "1" ]; then
+        args="${args} -repair"
+    fi
+    log_info "检查数据一致性..."
+    go run ./cmd/fsck ${args}
+}
+
+# 使用当前密钥重新加密敏感字段，轮换密钥或首次启用加密时执行
+rekey() {
+    local db=${1:-$DB}
+    local args=""
+    if [ -n "$db" ]; then
+        args="-db ${db}"
+    fi
+    log_info "重新加密敏感字段..."
+    go run ./cmd/rekey ${args}
 }
 
 # 查看项目信息
@@ -496,7 +543,16 @@ main() {
             createdb "$2"
             ;;
         "seed")
-            seed
+            seed "$2"
+            ;;
+        "anonymize")
+            anonymize "$2"
+            ;;
+        "fsck")
+            fsck "$2"
+            ;;
+        "rekey")
+            rekey "$2"
             ;;
         "info")
             info
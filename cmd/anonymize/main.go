@@ -0,0 +1,636 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"hrms/model"
+	"hrms/service"
+	"log"
+	mrand "math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/viper"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+	_ "modernc.org/sqlite"
+)
+
+// 配置结构体
+type Config struct {
+	Gin struct {
+		Port int64 `json:"port"`
+	} `json:"gin"`
+	Db struct {
+		Type     string `json:"type"` // 数据库类型: mysql, sqlite
+		User     string `json:"user"`
+		Password string `json:"password"`
+		Host     string `json:"host"`
+		Port     int64  `json:"port"`
+		DbName   string `json:"dbName"`
+		Path     string `json:"path"` // SQLite 数据库文件路径
+	} `json:"db"`
+	Crypto model.CryptoConfig `json:"crypto"`
+}
+
+// 初始化配置
+func InitConfig() (*Config, error) {
+	config := &Config{}
+	vip := viper.New()
+	vip.AddConfigPath("./config")
+	vip.SetConfigType("yaml")
+
+	// 环境判断
+	env := os.Getenv("HRMS_ENV")
+	if env == "" {
+		env = "dev"
+	}
+
+	switch env {
+	case "dev":
+		vip.SetConfigName("config-dev")
+	case "test":
+		vip.SetConfigName("config-test")
+	case "prod":
+		vip.SetConfigName("config-prod")
+	case "self":
+		vip.SetConfigName("config-self")
+	default:
+		vip.SetConfigName("config-dev")
+	}
+
+	log.Printf("当前环境: %s", env)
+
+	if err := vip.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("读取配置文件失败: %v", err)
+	}
+
+	if err := vip.Unmarshal(config); err != nil {
+		return nil, fmt.Errorf("解析配置文件失败: %v", err)
+	}
+
+	return config, nil
+}
+
+// 连接源数据库
+func InitDB(config *Config, dbName string) (*gorm.DB, error) {
+	dbType := strings.ToLower(config.Db.Type)
+	if dbType == "" {
+		dbType = "mysql" // 默认使用 MySQL
+	}
+
+	var db *gorm.DB
+	var err error
+
+	switch dbType {
+	case "sqlite":
+		// SQLite 连接
+		var dbPath string
+		if config.Db.Path != "" {
+			// 使用配置的路径，支持相对路径和绝对路径
+			if filepath.IsAbs(config.Db.Path) {
+				dbPath = filepath.Join(config.Db.Path, dbName+".db")
+			} else {
+				dbPath = filepath.Join(".", config.Db.Path, dbName+".db")
+			}
+		} else {
+			// 默认路径：./data/数据库名.db
+			dbPath = filepath.Join(".", "data", dbName+".db")
+		}
+
+		// 源数据库必须已存在，避免误建空库
+		if _, err := os.Stat(dbPath); err != nil {
+			return nil, fmt.Errorf("源数据库文件不存在: %s", dbPath)
+		}
+
+		db, err = openSQLite(dbPath)
+		if err != nil {
+			return nil, fmt.Errorf("SQLite连接失败: %v", err)
+		}
+		log.Printf("SQLite数据库连接成功，路径: %v", dbPath)
+
+	default:
+		// MySQL 连接（默认）
+		dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+			config.Db.User,
+			config.Db.Password,
+			config.Db.Host,
+			config.Db.Port,
+			dbName,
+		)
+
+		db, err = gorm.Open(mysql.Open(dsn), &gorm.Config{
+			NamingStrategy: schema.NamingStrategy{
+				SingularTable: true, // 全局禁止表名复数
+			},
+			Logger: logger.Default.LogMode(logger.Warn),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("MySQL连接失败: %v", err)
+		}
+		log.Printf("MySQL数据库连接成功")
+	}
+
+	return db, nil
+}
+
+func openSQLite(dbPath string) (*gorm.DB, error) {
+	return gorm.Open(sqlite.Dialector{
+		DriverName: "sqlite",
+		DSN:        dbPath + "?_pragma=foreign_keys(1)",
+	}, &gorm.Config{
+		NamingStrategy: schema.NamingStrategy{
+			SingularTable: true, // 全局禁止表名复数
+		},
+		Logger: logger.Default.LogMode(logger.Warn),
+	})
+}
+
+// 需要复制的业务表，顺序与 migrate 工具保持一致
+func getModels() []interface{} {
+	return []interface{}{
+		&model.Authority{},
+		&model.AuthorityDetail{},
+		&model.Department{},
+		&model.Rank{},
+		&model.Staff{},
+		&model.AttendanceRecord{},
+		&model.Notification{},
+		&model.BranchCompany{},
+		&model.Salary{},
+		&model.SalaryRecord{},
+		&model.Recruitment{},
+		&model.Candidate{},
+		&model.Example{},
+		&model.ExampleScore{},
+		&model.StaffStatusLog{},
+		&model.StaffHistory{},
+		&model.DepRestructure{},
+		&model.IdSequence{},
+		&model.SensitiveRevealLog{},
+		&model.AccessLog{},
+		&model.StaffDocument{},
+		&model.StaffContract{},
+		&model.StaffProbation{},
+		&model.StaffContact{},
+		&model.StaffFamily{},
+		&model.StaffEducation{},
+		&model.StaffEmployment{},
+		&model.RankChange{},
+		&model.StaffChangeRequest{},
+		&model.MilestoneOptOut{},
+	}
+}
+
+var (
+	surnames   = []string{"王", "李", "张", "刘", "陈", "杨", "黄", "赵", "吴", "周", "徐", "孙", "马", "朱", "胡", "郭", "何", "林", "罗", "高", "郑", "梁", "谢", "宋", "唐"}
+	givenNames = []string{"伟", "芳", "娜", "敏", "静", "磊", "强", "军", "洋", "勇", "艳", "杰", "涛", "明", "超", "秀英", "霞", "平", "刚", "桂英", "博", "晨", "子涵", "浩然", "欣怡", "宇轩", "思远", "佳琪", "俊杰", "雨桐"}
+)
+
+// 脱敏器，同一原始值在任意表中都会得到相同的替换值
+type masker struct {
+	key []byte
+	// 员工新身份证号，用于重置登录密码
+	identities map[string]string
+	// 原姓名对应的工号，重名时为空
+	staffIds map[string]string
+	// 变更记录中同一次变更的上级工号修改前后的值
+	historyLeaders map[string][2]string
+}
+
+// 以 HMAC 派生随机数生成器，保证同一密钥下替换结果稳定
+func (m *masker) rnd(kind, value string) *mrand.Rand {
+	mac := hmac.New(sha256.New, m.key)
+	mac.Write([]byte(kind + ":" + value))
+	sum := mac.Sum(nil)
+	return mrand.New(mrand.NewSource(int64(binary.BigEndian.Uint64(sum[:8]))))
+}
+
+// 按员工工号生成姓名，工号为空时按原姓名生成
+func (m *masker) staffName(staffId, name string) string {
+	if name == "" {
+		return ""
+	}
+	r := m.rnd("name", staffId)
+	if staffId == "" {
+		r = m.rnd("name", name)
+	}
+	return surnames[r.Intn(len(surnames))] + givenNames[r.Intn(len(givenNames))]
+}
+
+// 将字符串中的数字替换为随机数字，保留前 keep 位及非数字字符
+func (m *masker) digits(kind, value string, keep int) string {
+	r := m.rnd(kind, value)
+	out := []byte(value)
+	for i := keep; i < len(out); i++ {
+		if out[i] >= '0' && out[i] <= '9' {
+			out[i] = byte('0' + r.Intn(10))
+		}
+	}
+	return string(out)
+}
+
+// 18位身份证号保留出生日期及性别位的奇偶，替换地区码及顺序码并重新计算校验位
+func (m *masker) identityNum(value string) string {
+	if len(value) != 18 {
+		return m.digits("identity", value, 0)
+	}
+	r := m.rnd("identity", value)
+	seq := r.Intn(1000)
+	if (seq%2 == 0) != (int(value[16]-'0')%2 == 0) {
+		seq = (seq + 1) % 1000
+	}
+	province := service.IdentityProvinces[r.Intn(len(service.IdentityProvinces))]
+	prefix := fmt.Sprintf("%v%02d%02d%v%03d", province, 1+r.Intn(20), 1+r.Intn(30), value[6:14], seq)
+	return prefix + string(service.IdentityCheckCode(prefix))
+}
+
+// 手机号保留号段前三位
+func (m *masker) phone(value int64) int64 {
+	if value <= 0 {
+		return value
+	}
+	str := fmt.Sprintf("%v", value)
+	var masked int64
+	fmt.Sscanf(m.digits("phone", str, 3), "%d", &masked)
+	return masked
+}
+
+func (m *masker) email(kind, owner, value string) string {
+	if value == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, m.key)
+	mac.Write([]byte(kind + ":" + owner))
+	return fmt.Sprintf("%v_%v@example.com", kind, hex.EncodeToString(mac.Sum(nil))[:8])
+}
+
+func (m *masker) text(value, placeholder string) string {
+	if value == "" {
+		return ""
+	}
+	return placeholder
+}
+
+func (m *masker) staff(s *model.Staff) {
+	// root/admin 等内置账号不做处理
+	if s.StaffId == "root" || s.StaffId == "admin" {
+		return
+	}
+	if staffId, ok := m.staffIds[s.StaffName]; ok && staffId != s.StaffId {
+		m.staffIds[s.StaffName] = ""
+	} else {
+		m.staffIds[s.StaffName] = s.StaffId
+	}
+	s.StaffName = m.staffName(s.StaffId, s.StaffName)
+	s.LeaderName = m.staffName(s.LeaderStaffId, s.LeaderName)
+	s.IdentityNum = m.identityNum(s.IdentityNum)
+	s.CardNum = m.digits("card", s.CardNum, 6)
+	s.Phone = m.phone(s.Phone)
+	s.Email = m.email("staff", s.StaffId, s.Email)
+	s.Address = m.text(s.Address, "已脱敏")
+	m.identities[s.StaffId] = s.IdentityNum
+}
+
+// 登录密码重置为脱敏后身份证号后六位，与新建员工规则一致；内置账号密码重置为工号
+func (m *masker) authority(a *model.Authority) {
+	if ident, ok := m.identities[a.StaffId]; ok && len(ident) >= 6 {
+		a.UserPassword = service.MD5(ident[len(ident)-6:])
+		return
+	}
+	a.UserPassword = service.MD5(a.StaffId)
+}
+
+// 读取变更记录中上级工号的修改，用于按上级工号脱敏上级姓名
+func (m *masker) loadHistoryLeaders(src *gorm.DB) error {
+	var histories []model.StaffHistory
+	if err := src.Unscoped().Where("field = ?", "leader_staff_id").Find(&histories).Error; err != nil {
+		return err
+	}
+	for _, h := range histories {
+		m.historyLeaders[h.ChangeId+":"+h.StaffId] = [2]string{h.OldValue, h.NewValue}
+	}
+	return nil
+}
+
+// 上级姓名对应的工号，优先取同一次变更中的上级工号，其次按原姓名查找
+func (m *masker) leaderStaffId(h *model.StaffHistory, side int, name string) string {
+	if leaders, ok := m.historyLeaders[h.ChangeId+":"+h.StaffId]; ok && leaders[side] != "" {
+		return leaders[side]
+	}
+	return m.staffIds[name]
+}
+
+// 员工信息变更记录中的敏感字段按员工表相同规则脱敏
+func (m *masker) staffHistory(h *model.StaffHistory) {
+	mask := func(value string, side int) string {
+		if value == "" {
+			return ""
+		}
+		switch h.Field {
+		case "staff_name":
+			return m.staffName(h.StaffId, value)
+		case "leader_name":
+			return m.staffName(m.leaderStaffId(h, side, value), value)
+		case "identity_num":
+			return m.identityNum(value)
+		case "card_num":
+			return m.digits("card", value, 6)
+		case "phone":
+			var phone int64
+			fmt.Sscanf(value, "%d", &phone)
+			return fmt.Sprintf("%v", m.phone(phone))
+		case "email":
+			return m.email("staff", h.StaffId, value)
+		}
+		return value
+	}
+	h.OldValue = mask(h.OldValue, 0)
+	h.NewValue = mask(h.NewValue, 1)
+}
+
+func (m *masker) candidate(c *model.Candidate) {
+	c.Name = m.staffName("", c.Name)
+	c.Email = m.email("candidate", c.CandidateId, c.Email)
+	c.Describe = m.text(c.Describe, "候选人描述已脱敏")
+	c.Evaluation = m.text(c.Evaluation, "面试评价已脱敏")
+}
+
+// 复制单张表，逐批读取源数据（含软删除记录）脱敏后写入目标库
+func copyTable[T any](src, dst *gorm.DB, mask func(*T)) (int, error) {
+	var rows []T
+	count := 0
+	err := src.Unscoped().FindInBatches(&rows, 500, func(tx *gorm.DB, batch int) error {
+		for i := range rows {
+			if mask != nil {
+				mask(&rows[i])
+			}
+		}
+		count += len(rows)
+		return dst.Create(&rows).Error
+	}).Error
+	return count, err
+}
+
+// 生成脱敏副本，员工表需先于其他表处理以得到工号与新身份证号的对应关系
+func anonymize(src, dst *gorm.DB, m *masker) error {
+	for _, table := range getModels() {
+		if err := dst.AutoMigrate(table); err != nil {
+			return fmt.Errorf("迁移模型失败: %v", err)
+		}
+	}
+	staffName := func(staffId, name *string) {
+		*name = m.staffName(*staffId, *name)
+	}
+	steps := []struct {
+		name string
+		fn   func() (int, error)
+	}{
+		{"staff", func() (int, error) { return copyTable(src, dst, m.staff) }},
+		{"authority", func() (int, error) { return copyTable(src, dst, m.authority) }},
+		{"authority_detail", func() (int, error) { return copyTable[model.AuthorityDetail](src, dst, nil) }},
+		{"department", func() (int, error) { return copyTable[model.Department](src, dst, nil) }},
+		{"rank", func() (int, error) { return copyTable[model.Rank](src, dst, nil) }},
+		{"branch_company", func() (int, error) { return copyTable[model.BranchCompany](src, dst, nil) }},
+		{"notification", func() (int, error) {
+			return copyTable(src, dst, func(r *model.Notification) {
+				// 定时任务生成的到期提醒及纪念日通知中包含员工姓名
+				if r.SourceKey != nil {
+					r.NoticeTitle = r.Type
+					r.NoticeContent = "已脱敏"
+				}
+			})
+		}},
+		{"recruitment", func() (int, error) { return copyTable[model.Recruitment](src, dst, nil) }},
+		{"example", func() (int, error) { return copyTable[model.Example](src, dst, nil) }},
+		{"attendance_record", func() (int, error) {
+			return copyTable(src, dst, func(r *model.AttendanceRecord) { staffName(&r.StaffId, &r.StaffName) })
+		}},
+		{"salary", func() (int, error) {
+			return copyTable(src, dst, func(r *model.Salary) { staffName(&r.StaffId, &r.StaffName) })
+		}},
+		{"salary_record", func() (int, error) {
+			return copyTable(src, dst, func(r *model.SalaryRecord) { staffName(&r.StaffId, &r.StaffName) })
+		}},
+		{"example_score", func() (int, error) {
+			return copyTable(src, dst, func(r *model.ExampleScore) { staffName(&r.StaffId, &r.StaffName) })
+		}},
+		{"candidate", func() (int, error) { return copyTable(src, dst, m.candidate) }},
+		{"staff_status_log", func() (int, error) {
+			return copyTable(src, dst, func(r *model.StaffStatusLog) {
+				staffName(&r.StaffId, &r.StaffName)
+				r.Reason = "已脱敏"
+			})
+		}},
+		{"staff_history", func() (int, error) {
+			if err := m.loadHistoryLeaders(src); err != nil {
+				return 0, err
+			}
+			return copyTable(src, dst, m.staffHistory)
+		}},
+		{"dep_restructure", func() (int, error) {
+			return copyTable(src, dst, func(r *model.DepRestructure) { r.Reason = "已脱敏" })
+		}},
+		{"id_sequence", func() (int, error) { return copyTable[model.IdSequence](src, dst, nil) }},
+		{"sensitive_reveal_log", func() (int, error) {
+			return copyTable(src, dst, func(r *model.SensitiveRevealLog) {
+				r.ClientIp = ""
+				// 导出记录的筛选条件中可能包含姓名
+				if r.RecordType == model.RevealRecordStaffExport {
+					r.RecordId = "已脱敏"
+				}
+			})
+		}},
+		{"staff_document", func() (int, error) {
+			// 副本不含文件内容，文件名中可能包含姓名
+			return copyTable(src, dst, func(r *model.StaffDocument) {
+				r.FileName = r.DocumentId + filepath.Ext(r.FileName)
+				r.Remark = ""
+			})
+		}},
+		{"staff_contract", func() (int, error) {
+			return copyTable(src, dst, func(r *model.StaffContract) {
+				staffName(&r.StaffId, &r.StaffName)
+				r.Remark = ""
+			})
+		}},
+		{"staff_probation", func() (int, error) {
+			return copyTable(src, dst, func(r *model.StaffProbation) {
+				staffName(&r.StaffId, &r.StaffName)
+				r.EvalComment = ""
+				r.DecisionRemark = ""
+			})
+		}},
+		{"staff_contact", func() (int, error) {
+			return copyTable(src, dst, func(r *model.StaffContact) {
+				r.Name = m.staffName("", r.Name)
+				r.Phone = m.digits("phone", r.Phone, 3)
+				r.Address = m.text(r.Address, "已脱敏")
+			})
+		}},
+		{"staff_family", func() (int, error) {
+			return copyTable(src, dst, func(r *model.StaffFamily) {
+				r.Name = m.staffName("", r.Name)
+				r.IdentityNum = m.identityNum(r.IdentityNum)
+				r.Phone = m.digits("phone", r.Phone, 3)
+				r.Remark = ""
+			})
+		}},
+		{"staff_education", func() (int, error) { return copyTable[model.StaffEducation](src, dst, nil) }},
+		{"staff_employment", func() (int, error) {
+			return copyTable(src, dst, func(r *model.StaffEmployment) { r.LeaveReason = "" })
+		}},
+		{"rank_change", func() (int, error) {
+			return copyTable(src, dst, func(r *model.RankChange) {
+				staffName(&r.StaffId, &r.StaffName)
+				r.Reason = ""
+			})
+		}},
+		{"staff_change_request", func() (int, error) {
+			return copyTable(src, dst, func(r *model.StaffChangeRequest) {
+				staffName(&r.StaffId, &r.StaffName)
+				r.Phone = m.phone(r.Phone)
+				r.Email = m.email("staff", r.StaffId, r.Email)
+				r.CardNum = m.digits("card", r.CardNum, 6)
+				r.Address = m.text(r.Address, "已脱敏")
+				r.Contacts = m.text(r.Contacts, "[]")
+				r.Reason = ""
+			})
+		}},
+		{"milestone_opt_out", func() (int, error) { return copyTable[model.MilestoneOptOut](src, dst, nil) }},
+		{"access_log", func() (int, error) {
+			return copyTable(src, dst, func(r *model.AccessLog) {
+				r.ClientIp = ""
+				// 按姓名查询等路径中可能包含姓名
+				r.Path = "已脱敏"
+			})
+		}},
+	}
+	for _, step := range steps {
+		count, err := step.fn()
+		if err != nil {
+			return fmt.Errorf("复制表 %v 失败: %v", step.name, err)
+		}
+		log.Printf("复制表 %v 完成，共 %d 行", step.name, count)
+	}
+	return nil
+}
+
+func main() {
+	var (
+		dbName string
+		out    string
+		key    string
+		force  bool
+		help   bool
+	)
+
+	flag.StringVar(&dbName, "db", "", "源数据库名称（必需）")
+	flag.StringVar(&out, "out", "", "脱敏副本输出路径（SQLite 文件，默认 ./data/{数据库名}_anon.db）")
+	flag.StringVar(&key, "key", "", "脱敏密钥，相同密钥生成相同替换值（默认随机生成）")
+	flag.BoolVar(&force, "force", false, "强制覆盖已存在的输出文件")
+	flag.BoolVar(&help, "h", false, "显示帮助信息")
+	flag.BoolVar(&help, "help", false, "显示帮助信息")
+
+	flag.Parse()
+
+	if help {
+		fmt.Println("分公司数据库脱敏复制工具")
+		fmt.Println("将分公司数据库复制为新的 SQLite 文件，并对身份证号、银行卡号、手机号、邮箱、姓名及候选人信息进行脱敏")
+		fmt.Println()
+		fmt.Println("用法:")
+		fmt.Println("  anonymize [选项]")
+		fmt.Println()
+		fmt.Println("选项:")
+		fmt.Println("  -h, --help     显示帮助信息")
+		fmt.Println("  -db string     源数据库名称（必需）")
+		fmt.Println("  -out string    脱敏副本输出路径（默认 ./data/{数据库名}_anon.db）")
+		fmt.Println("  -key string    脱敏密钥，相同密钥生成相同替换值（默认随机生成）")
+		fmt.Println("  -force         强制覆盖已存在的输出文件")
+		fmt.Println()
+		fmt.Println("示例:")
+		fmt.Println("  HRMS_ENV=prod anonymize -db hrms_C001 -out ./data/hrms_C001_debug.db")
+		fmt.Println()
+		fmt.Println("注意事项:")
+		fmt.Println("  - 同一员工工号在所有表中替换为相同的姓名，关联查询及薪资计算不受影响")
+		fmt.Println("  - 身份证号保留出生日期及性别位，并重新计算校验位")
+		fmt.Println("  - 员工登录密码重置为脱敏后身份证号后六位，root/admin 等内置账号密码重置为工号")
+		return
+	}
+
+	// 检查必需参数
+	if dbName == "" {
+		log.Fatal("错误: 必须指定源数据库名称，使用 -db 参数")
+	}
+	if out == "" {
+		out = filepath.Join(".", "data", dbName+"_anon.db")
+	}
+	if _, err := os.Stat(out); err == nil {
+		if !force {
+			log.Fatalf("错误: 输出文件已存在: %s，使用 -force 覆盖", out)
+		}
+		if err := os.Remove(out); err != nil {
+			log.Fatalf("删除已存在的输出文件失败: %v", err)
+		}
+	}
+	if key == "" {
+		buf := make([]byte, 32)
+		if _, err := rand.Read(buf); err != nil {
+			log.Fatalf("生成脱敏密钥失败: %v", err)
+		}
+		key = hex.EncodeToString(buf)
+	}
+
+	// 初始化配置
+	config, err := InitConfig()
+	if err != nil {
+		log.Fatalf("配置初始化失败: %v", err)
+	}
+	// 按配置的密钥解密源数据，脱敏副本不设置当前密钥，以明文写入
+	// 副本在其他环境使用前需执行 rekey，按该环境的配置加密并重新计算盲索引
+	if err := model.InitFieldCrypto(model.CryptoConfig{
+		Keys:          config.Crypto.Keys,
+		BlindIndexKey: config.Crypto.BlindIndexKey,
+	}); err != nil {
+		log.Fatalf("加载加密密钥失败: %v", err)
+	}
+
+	src, err := InitDB(config, dbName)
+	if err != nil {
+		log.Fatalf("数据库连接失败: %v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(out), 0755); err != nil {
+		log.Fatalf("创建输出目录失败: %v", err)
+	}
+	dst, err := openSQLite(out)
+	if err != nil {
+		log.Fatalf("创建脱敏副本失败: %v", err)
+	}
+
+	m := &masker{
+		key:            []byte(key),
+		identities:     make(map[string]string),
+		staffIds:       make(map[string]string),
+		historyLeaders: make(map[string][2]string),
+	}
+	if err := anonymize(src, dst, m); err != nil {
+		// 失败时删除不完整的副本，避免误用
+		if sqlDB, err := dst.DB(); err == nil {
+			sqlDB.Close()
+		}
+		os.Remove(out)
+		log.Fatalf("生成脱敏副本失败: %v", err)
+	}
+
+	log.Printf("脱敏副本生成成功: %s", out)
+}
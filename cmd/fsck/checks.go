@@ -0,0 +1,251 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 内置账号，不参与员工相关检查
+const builtinStaffCond = "staff_id NOT IN ('root', 'admin')"
+
+// 身份证号比较键，优先使用盲索引
+const identityKey = "COALESCE(NULLIF(s.identity_hash, ''), s.identity_num)"
+
+// 一条违规记录
+type Violation struct {
+	Check string `gorm:"-" json:"check"`
+	// 违规行的主键 id
+	ID uint `gorm:"column:id" json:"id"`
+	// 违规行的业务标识，如 staff_id、salary_record_id
+	Key    string `gorm:"column:row_key" json:"key"`
+	Detail string `gorm:"column:detail" json:"detail"`
+}
+
+// 一项一致性检查
+type Check struct {
+	Name string
+	Desc string
+	// 查询违规记录，每行需返回 id、row_key、detail 三列
+	SQL string
+	// 安全修复，在事务中执行，返回影响行数；为空表示只报告不修复
+	Repair func(tx *gorm.DB, violations []Violation) (int64, error)
+	// 修复方式说明
+	RepairDesc string
+}
+
+// 检查目录，按顺序执行
+var checks = []*Check{
+	{
+		Name: "staff_dep_invalid",
+		Desc: "员工部门编号为 -1、为空或对应部门不存在",
+		SQL: `SELECT s.id, s.staff_id AS row_key, CONCAT('dep_id=', COALESCE(s.dep_id, 'NULL')) AS detail FROM staff s
+			WHERE s.deleted_at IS NULL AND s.` + builtinStaffCond + `
+			AND (s.dep_id IS NULL OR s.dep_id IN ('', '-1')
+				OR NOT EXISTS (SELECT 1 FROM department d WHERE d.dep_id = s.dep_id AND d.deleted_at IS NULL))`,
+	},
+	{
+		Name: "staff_rank_invalid",
+		Desc: "员工职级编号为 -1、为空或对应职级不存在",
+		SQL: "SELECT s.id, s.staff_id AS row_key, CONCAT('rank_id=', COALESCE(s.rank_id, 'NULL')) AS detail FROM staff s " +
+			"WHERE s.deleted_at IS NULL AND s." + builtinStaffCond + " " +
+			"AND (s.rank_id IS NULL OR s.rank_id IN ('', '-1') " +
+			"OR NOT EXISTS (SELECT 1 FROM `rank` r WHERE r.rank_id = s.rank_id AND r.deleted_at IS NULL))",
+	},
+	{
+		Name: "dep_parent_invalid",
+		Desc: "部门的上级部门不存在或为本部门",
+		SQL: `SELECT d.id, d.dep_id AS row_key, CONCAT('parent_dep_id=', d.parent_dep_id) AS detail FROM department d
+			WHERE d.deleted_at IS NULL AND d.parent_dep_id IS NOT NULL AND d.parent_dep_id <> ''
+			AND (d.parent_dep_id = d.dep_id
+				OR NOT EXISTS (SELECT 1 FROM department p WHERE p.dep_id = d.parent_dep_id AND p.deleted_at IS NULL))`,
+	},
+	{
+		Name: "dep_head_missing",
+		Desc: "部门负责人工号不存在",
+		SQL: `SELECT d.id, d.dep_id AS row_key, CONCAT('head_staff_id=', d.head_staff_id) AS detail FROM department d
+			WHERE d.deleted_at IS NULL AND d.head_staff_id IS NOT NULL AND d.head_staff_id <> ''
+			AND NOT EXISTS (SELECT 1 FROM staff s WHERE s.staff_id = d.head_staff_id AND s.deleted_at IS NULL)`,
+	},
+	{
+		Name: "staff_id_duplicate",
+		Desc: "多名在职员工使用同一工号",
+		SQL: `SELECT s.id, s.staff_id AS row_key, CONCAT('staff_name=', COALESCE(s.staff_name, '')) AS detail FROM staff s
+			WHERE s.deleted_at IS NULL AND s.staff_id IN (
+				SELECT staff_id FROM staff WHERE deleted_at IS NULL GROUP BY staff_id HAVING COUNT(*) > 1)`,
+	},
+	{
+		Name: "business_id_duplicate",
+		Desc: "业务ID重复（含已删除记录），迁移时无法创建唯一索引",
+		SQL:  businessIdDuplicateSQL(),
+	},
+	{
+		Name: "identity_num_duplicate",
+		Desc: "多名在职员工使用同一身份证号",
+		// 身份证号加密存储，按盲索引比较，尚未生成盲索引的历史明文数据按原值比较
+		SQL: `SELECT s.id, s.staff_id AS row_key, CONCAT('staff_name=', COALESCE(s.staff_name, '')) AS detail FROM staff s
+			WHERE s.deleted_at IS NULL AND s.` + builtinStaffCond + ` AND ` + identityKey + ` IN (
+				SELECT ` + identityKey + ` FROM staff s WHERE s.deleted_at IS NULL AND s.` + builtinStaffCond + `
+				AND s.identity_num IS NOT NULL AND s.identity_num NOT IN ('', '-1')
+				GROUP BY ` + identityKey + ` HAVING COUNT(*) > 1)`,
+	},
+	{
+		Name: "staff_leader_missing",
+		Desc: "员工的上级工号不存在或为本人",
+		SQL: `SELECT s.id, s.staff_id AS row_key, CONCAT('leader_staff_id=', s.leader_staff_id) AS detail FROM staff s
+			WHERE s.deleted_at IS NULL AND s.leader_staff_id IS NOT NULL AND s.leader_staff_id NOT IN ('', '-1')
+			AND (s.leader_staff_id = s.staff_id
+				OR NOT EXISTS (SELECT 1 FROM staff l WHERE l.staff_id = s.leader_staff_id AND l.deleted_at IS NULL))`,
+	},
+	{
+		Name: "staff_leader_name_mismatch",
+		Desc: "员工记录中的上级姓名与上级员工姓名不一致",
+		SQL: `SELECT s.id, s.staff_id AS row_key,
+				CONCAT('leader_name=', COALESCE(s.leader_name, ''), ', expected=', l.staff_name) AS detail
+			FROM staff s JOIN staff l ON l.staff_id = s.leader_staff_id AND l.deleted_at IS NULL
+			WHERE s.deleted_at IS NULL AND COALESCE(s.leader_name, '') <> l.staff_name`,
+		RepairDesc: "按上级员工姓名更新 leader_name",
+		Repair: func(tx *gorm.DB, violations []Violation) (int64, error) {
+			// MySQL 不允许在 UPDATE 的子查询中引用被更新的表，逐行更新
+			var affected int64
+			for _, v := range violations {
+				var leaderName string
+				if err := tx.Raw(`SELECT l.staff_name FROM staff s JOIN staff l ON l.staff_id = s.leader_staff_id
+					AND l.deleted_at IS NULL WHERE s.id = ? LIMIT 1`, v.ID).Scan(&leaderName).Error; err != nil {
+					return affected, err
+				}
+				result := tx.Exec("UPDATE staff SET leader_name = ?, updated_at = ? WHERE id = ?", leaderName, time.Now(), v.ID)
+				if result.Error != nil {
+					return affected, result.Error
+				}
+				affected += result.RowsAffected
+			}
+			return affected, nil
+		},
+	},
+	{
+		Name: "authority_orphan",
+		Desc: "账号对应的员工不存在或已删除",
+		SQL: `SELECT a.id, a.staff_id AS row_key, CONCAT('authority_id=', COALESCE(a.authority_id, '')) AS detail FROM authority a
+			WHERE a.deleted_at IS NULL AND a.` + builtinStaffCond + `
+			AND NOT EXISTS (SELECT 1 FROM staff s WHERE s.staff_id = a.staff_id AND s.deleted_at IS NULL)`,
+		RepairDesc: "删除账号（软删除，与删除员工时的处理一致）",
+		Repair:     softDelete("authority"),
+	},
+	{
+		Name: "authority_duplicate",
+		Desc: "同一员工存在多个账号",
+		SQL: `SELECT a.id, a.staff_id AS row_key, CONCAT('user_type=', COALESCE(a.user_type, '')) AS detail FROM authority a
+			WHERE a.deleted_at IS NULL AND a.staff_id IN (
+				SELECT staff_id FROM authority WHERE deleted_at IS NULL GROUP BY staff_id HAVING COUNT(*) > 1)`,
+	},
+	{
+		Name: "staff_without_authority",
+		Desc: "在职员工没有登录账号",
+		SQL: `SELECT s.id, s.staff_id AS row_key, CONCAT('staff_name=', COALESCE(s.staff_name, '')) AS detail FROM staff s
+			WHERE s.deleted_at IS NULL
+			AND NOT EXISTS (SELECT 1 FROM authority a WHERE a.staff_id = s.staff_id AND a.deleted_at IS NULL)`,
+	},
+	{
+		Name: "salary_orphan",
+		Desc: "薪资套账对应的员工不存在或已删除",
+		SQL: `SELECT sa.id, sa.staff_id AS row_key, CONCAT('salary_id=', COALESCE(sa.salary_id, '')) AS detail FROM salary sa
+			WHERE sa.deleted_at IS NULL
+			AND NOT EXISTS (SELECT 1 FROM staff s WHERE s.staff_id = sa.staff_id AND s.deleted_at IS NULL)`,
+		RepairDesc: "删除薪资套账（软删除）",
+		Repair:     softDelete("salary"),
+	},
+	{
+		Name: "salary_duplicate",
+		Desc: "同一员工存在多个薪资套账",
+		SQL: `SELECT sa.id, sa.staff_id AS row_key, CONCAT('salary_id=', COALESCE(sa.salary_id, '')) AS detail FROM salary sa
+			WHERE sa.deleted_at IS NULL AND sa.staff_id IN (
+				SELECT staff_id FROM salary WHERE deleted_at IS NULL GROUP BY staff_id HAVING COUNT(*) > 1)`,
+	},
+	{
+		Name: "salary_record_without_attendance",
+		Desc: "薪资记录没有对应月份的考勤记录",
+		SQL: `SELECT r.id, r.salary_record_id AS row_key,
+				CONCAT('staff_id=', COALESCE(r.staff_id, ''), ', salary_date=', COALESCE(r.salary_date, '')) AS detail
+			FROM salary_record r
+			WHERE r.deleted_at IS NULL
+			AND NOT EXISTS (SELECT 1 FROM attendance_record t
+				WHERE t.staff_id = r.staff_id AND t.date = r.salary_date AND t.deleted_at IS NULL)`,
+	},
+	{
+		Name: "attendance_duplicate",
+		Desc: "同一员工同一月份存在多条考勤记录",
+		SQL: `SELECT t.id, t.attendance_id AS row_key,
+				CONCAT('staff_id=', COALESCE(t.staff_id, ''), ', date=', COALESCE(t.date, '')) AS detail
+			FROM attendance_record t
+			WHERE t.deleted_at IS NULL AND EXISTS (SELECT 1 FROM attendance_record o
+				WHERE o.staff_id = t.staff_id AND o.date = t.date AND o.deleted_at IS NULL AND o.id <> t.id)`,
+	},
+}
+
+// 带唯一索引的业务ID列，表名及列名
+var businessIdColumns = [][2]string{
+	{"authority", "authority_id"},
+	{"attendance_record", "attendance_id"},
+	{"branch_company", "branch_id"},
+	{"candidate", "candidate_id"},
+	{"dep_restructure", "restructure_id"},
+	{"department", "dep_id"},
+	{"example", "example_id"},
+	{"notification", "notice_id"},
+	{"rank", "rank_id"},
+	{"recruitment", "recruitment_id"},
+	{"salary", "salary_id"},
+	{"salary_record", "salary_record_id"},
+	{"staff", "staff_id"},
+	{"staff_status_log", "log_id"},
+}
+
+func businessIdDuplicateSQL() string {
+	var parts []string
+	for _, c := range businessIdColumns {
+		parts = append(parts, fmt.Sprintf("SELECT t.id, t.%[2]s AS row_key, 'table=%[1]s' AS detail FROM `%[1]s` t "+
+			"WHERE t.%[2]s IN (SELECT %[2]s FROM `%[1]s` GROUP BY %[2]s HAVING COUNT(*) > 1)", c[0], c[1]))
+	}
+	return strings.Join(parts, " UNION ALL ")
+}
+
+func violationIDs(violations []Violation) []uint {
+	ids := make([]uint, 0, len(violations))
+	for _, v := range violations {
+		ids = append(ids, v.ID)
+	}
+	return ids
+}
+
+// 按主键软删除违规行
+func softDelete(table string) func(tx *gorm.DB, violations []Violation) (int64, error) {
+	return func(tx *gorm.DB, violations []Violation) (int64, error) {
+		result := tx.Exec(fmt.Sprintf("UPDATE `%s` SET deleted_at = ? WHERE id IN ? AND deleted_at IS NULL", table),
+			time.Now(), violationIDs(violations))
+		return result.RowsAffected, result.Error
+	}
+}
+
+// 执行检查，返回违规记录
+func (c *Check) Run(db *gorm.DB) ([]Violation, error) {
+	var violations []Violation
+	if err := db.Raw(c.SQL).Scan(&violations).Error; err != nil {
+		return nil, fmt.Errorf("检查 %s 执行失败: %v", c.Name, err)
+	}
+	for i := range violations {
+		violations[i].Check = c.Name
+	}
+	return violations, nil
+}
+
+func findCheck(name string) *Check {
+	for _, c := range checks {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
@@ -0,0 +1,361 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/viper"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+	_ "modernc.org/sqlite"
+)
+
+// 配置结构体
+type Config struct {
+	Gin struct {
+		Port int64 `json:"port"`
+	} `json:"gin"`
+	Db struct {
+		Type     string `json:"type"` // 数据库类型: mysql, sqlite
+		User     string `json:"user"`
+		Password string `json:"password"`
+		Host     string `json:"host"`
+		Port     int64  `json:"port"`
+		DbName   string `json:"dbName"`
+		Path     string `json:"path"` // SQLite 数据库文件路径
+	} `json:"db"`
+}
+
+// 初始化配置
+func InitConfig() (*Config, error) {
+	config := &Config{}
+	vip := viper.New()
+	vip.AddConfigPath("./config")
+	vip.SetConfigType("yaml")
+
+	// 环境判断
+	env := os.Getenv("HRMS_ENV")
+	if env == "" {
+		env = "dev"
+	}
+
+	switch env {
+	case "dev":
+		vip.SetConfigName("config-dev")
+	case "test":
+		vip.SetConfigName("config-test")
+	case "prod":
+		vip.SetConfigName("config-prod")
+	case "self":
+		vip.SetConfigName("config-self")
+	default:
+		vip.SetConfigName("config-dev")
+	}
+
+	log.Printf("当前环境: %s", env)
+
+	if err := vip.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("读取配置文件失败: %v", err)
+	}
+
+	if err := vip.Unmarshal(config); err != nil {
+		return nil, fmt.Errorf("解析配置文件失败: %v", err)
+	}
+
+	return config, nil
+}
+
+// 连接数据库
+func InitDB(config *Config, dbName string) (*gorm.DB, error) {
+	dbType := strings.ToLower(config.Db.Type)
+	if dbType == "" {
+		dbType = "mysql" // 默认使用 MySQL
+	}
+
+	var db *gorm.DB
+	var err error
+
+	switch dbType {
+	case "sqlite":
+		// SQLite 连接
+		var dbPath string
+		if config.Db.Path != "" {
+			// 使用配置的路径，支持相对路径和绝对路径
+			if filepath.IsAbs(config.Db.Path) {
+				dbPath = filepath.Join(config.Db.Path, dbName+".db")
+			} else {
+				dbPath = filepath.Join(".", config.Db.Path, dbName+".db")
+			}
+		} else {
+			// 默认路径：./data/数据库名.db
+			dbPath = filepath.Join(".", "data", dbName+".db")
+		}
+
+		// 数据库必须已存在，避免误建空库
+		if _, err := os.Stat(dbPath); err != nil {
+			return nil, fmt.Errorf("数据库文件不存在: %s", dbPath)
+		}
+
+		db, err = openSQLite(dbPath)
+		if err != nil {
+			return nil, fmt.Errorf("SQLite连接失败: %v", err)
+		}
+		log.Printf("SQLite数据库连接成功，路径: %v", dbPath)
+
+	default:
+		// MySQL 连接（默认）
+		dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+			config.Db.User,
+			config.Db.Password,
+			config.Db.Host,
+			config.Db.Port,
+			dbName,
+		)
+
+		db, err = gorm.Open(mysql.Open(dsn), &gorm.Config{
+			NamingStrategy: schema.NamingStrategy{
+				SingularTable: true, // 全局禁止表名复数
+			},
+			Logger: logger.Default.LogMode(logger.Warn),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("MySQL连接失败: %v", err)
+		}
+		log.Printf("MySQL数据库连接成功")
+	}
+
+	return db, nil
+}
+
+func openSQLite(dbPath string) (*gorm.DB, error) {
+	return gorm.Open(sqlite.Dialector{
+		DriverName: "sqlite",
+		DSN:        dbPath + "?_pragma=foreign_keys(1)",
+	}, &gorm.Config{
+		NamingStrategy: schema.NamingStrategy{
+			SingularTable: true, // 全局禁止表名复数
+		},
+		Logger: logger.Default.LogMode(logger.Warn),
+	})
+}
+
+// 单个数据库的检查结果
+type Report struct {
+	DbName     string           `json:"db"`
+	Violations []Violation      `json:"violations"`
+	Repaired   map[string]int64 `json:"repaired,omitempty"`
+	Error      string           `json:"error,omitempty"`
+}
+
+// 对单个数据库执行检查，repair 为 true 时在一个事务中执行安全修复
+func fsck(db *gorm.DB, selected []*Check, repair bool) (*Report, error) {
+	report := &Report{Violations: []Violation{}}
+	found := make(map[*Check][]Violation)
+	for _, c := range selected {
+		violations, err := c.Run(db)
+		if err != nil {
+			return report, err
+		}
+		found[c] = violations
+		report.Violations = append(report.Violations, violations...)
+	}
+	if !repair {
+		return report, nil
+	}
+
+	report.Repaired = make(map[string]int64)
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for _, c := range selected {
+			if c.Repair == nil || len(found[c]) == 0 {
+				continue
+			}
+			affected, err := c.Repair(tx, found[c])
+			if err != nil {
+				return fmt.Errorf("修复 %s 失败: %v", c.Name, err)
+			}
+			report.Repaired[c.Name] = affected
+		}
+		return nil
+	})
+	if err != nil {
+		report.Repaired = nil
+	}
+	return report, err
+}
+
+func printReport(report *Report, selected []*Check) {
+	fmt.Printf("\n########## %s ##########\n", report.DbName)
+	if report.Error != "" {
+		fmt.Printf("检查失败: %s\n", report.Error)
+		return
+	}
+	byCheck := make(map[string][]Violation)
+	for _, v := range report.Violations {
+		byCheck[v.Check] = append(byCheck[v.Check], v)
+	}
+	for _, c := range selected {
+		violations := byCheck[c.Name]
+		if len(violations) == 0 {
+			fmt.Printf("[通过] %-34s %s\n", c.Name, c.Desc)
+			continue
+		}
+		fmt.Printf("[异常] %-34s %s，共 %d 条\n", c.Name, c.Desc, len(violations))
+		for _, v := range violations {
+			fmt.Printf("         id=%-8d key=%-20s %s\n", v.ID, v.Key, v.Detail)
+		}
+		if affected, ok := report.Repaired[c.Name]; ok {
+			fmt.Printf("         已修复 %d 行: %s\n", affected, c.RepairDesc)
+		} else if c.Repair != nil {
+			fmt.Printf("         可使用 -repair 修复: %s\n", c.RepairDesc)
+		}
+	}
+}
+
+// 修复后仍然存在的违规数量
+func remaining(report *Report) int {
+	count := 0
+	for _, v := range report.Violations {
+		if _, ok := report.Repaired[v.Check]; !ok {
+			count++
+		}
+	}
+	return count
+}
+
+func main() {
+	var (
+		dbNames   string
+		checkList string
+		format    string
+		repair    bool
+		list      bool
+		help      bool
+	)
+
+	flag.StringVar(&dbNames, "db", "", "指定数据库名称，多个用逗号分隔（默认使用配置文件中的所有数据库）")
+	flag.StringVar(&checkList, "check", "", "只执行指定的检查项，多个用逗号分隔（默认执行全部）")
+	flag.StringVar(&format, "format", "text", "输出格式 (text/json)")
+	flag.BoolVar(&repair, "repair", false, "对支持的检查项执行安全修复")
+	flag.BoolVar(&list, "list", false, "列出所有检查项")
+	flag.BoolVar(&help, "h", false, "显示帮助信息")
+	flag.BoolVar(&help, "help", false, "显示帮助信息")
+
+	flag.Parse()
+
+	if help {
+		fmt.Println("数据一致性检查工具")
+		fmt.Println("按检查目录逐个分公司数据库检查数据一致性，报告违规行的 id，可选执行安全修复")
+		fmt.Println()
+		fmt.Println("用法:")
+		fmt.Println("  fsck [选项]")
+		fmt.Println()
+		fmt.Println("选项:")
+		flag.PrintDefaults()
+		fmt.Println()
+		fmt.Println("示例:")
+		fmt.Println("  fsck                                          # 检查配置中的所有数据库")
+		fmt.Println("  fsck -db hrms_C001 -check authority_orphan    # 只执行指定检查项")
+		fmt.Println("  fsck -db hrms_C001 -repair                    # 检查并执行安全修复")
+		fmt.Println("  fsck -list                                    # 列出所有检查项")
+		fmt.Println()
+		fmt.Println("注意事项:")
+		fmt.Println("  - 修复只针对可安全自动处理的检查项，每个数据库的修复在一个事务中执行")
+		fmt.Println("  - 存在未修复的违规或检查失败时以非零状态退出")
+		return
+	}
+
+	if list {
+		for _, c := range checks {
+			repairDesc := "仅报告"
+			if c.Repair != nil {
+				repairDesc = "修复: " + c.RepairDesc
+			}
+			fmt.Printf("%-34s %s（%s）\n", c.Name, c.Desc, repairDesc)
+		}
+		return
+	}
+
+	if format != "text" && format != "json" {
+		log.Fatalf("错误: 不支持的输出格式: %s", format)
+	}
+
+	selected := checks
+	if checkList != "" {
+		selected = nil
+		for _, name := range strings.Split(checkList, ",") {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+			c := findCheck(name)
+			if c == nil {
+				log.Fatalf("错误: 未知的检查项: %s，使用 -list 查看所有检查项", name)
+			}
+			selected = append(selected, c)
+		}
+	}
+
+	// 初始化配置
+	config, err := InitConfig()
+	if err != nil {
+		log.Fatalf("配置初始化失败: %v", err)
+	}
+
+	// 获取要检查的数据库列表
+	var targetDBs []string
+	if dbNames != "" {
+		targetDBs = strings.Split(dbNames, ",")
+	} else {
+		targetDBs = strings.Split(config.Db.DbName, ",")
+	}
+
+	var reports []*Report
+	failCount := 0
+	for _, dbName := range targetDBs {
+		dbName = strings.TrimSpace(dbName)
+		if dbName == "" {
+			continue
+		}
+
+		report := &Report{DbName: dbName, Violations: []Violation{}}
+		db, err := InitDB(config, dbName)
+		if err == nil {
+			report, err = fsck(db, selected, repair)
+			report.DbName = dbName
+			if sqlDB, e := db.DB(); e == nil {
+				sqlDB.Close()
+			}
+		}
+		if err != nil {
+			report.Error = err.Error()
+			failCount++
+		} else if remaining(report) > 0 {
+			failCount++
+		}
+		reports = append(reports, report)
+		if format == "text" {
+			printReport(report, selected)
+		}
+	}
+
+	if format == "json" {
+		data, err := json.MarshalIndent(reports, "", "  ")
+		if err != nil {
+			log.Fatalf("输出结果失败: %v", err)
+		}
+		fmt.Println(string(data))
+	}
+
+	if failCount > 0 {
+		os.Exit(1)
+	}
+	log.Println("检查完成，未发现异常")
+}
@@ -163,6 +163,22 @@ func getModels() []interface{} {
 		&model.Candidate{},
 		&model.Example{},
 		&model.ExampleScore{},
+		&model.StaffStatusLog{},
+		&model.StaffHistory{},
+		&model.DepRestructure{},
+		&model.IdSequence{},
+		&model.SensitiveRevealLog{},
+		&model.AccessLog{},
+		&model.StaffDocument{},
+		&model.StaffContract{},
+		&model.StaffProbation{},
+		&model.StaffContact{},
+		&model.StaffFamily{},
+		&model.StaffEducation{},
+		&model.StaffEmployment{},
+		&model.RankChange{},
+		&model.StaffChangeRequest{},
+		&model.MilestoneOptOut{},
 	}
 }
 
@@ -172,11 +188,41 @@ func migrateDB(db *gorm.DB, dbName string) error {
 
 	models := getModels()
 
+	// 身份证号盲索引改为唯一索引，删除原普通索引，空字符串改为 NULL 后才能创建
+	if db.Migrator().HasColumn(&model.Staff{}, "identity_hash") {
+		if db.Migrator().HasIndex(&model.Staff{}, "idx_staff_identity_hash") {
+			if err := db.Migrator().DropIndex(&model.Staff{}, "idx_staff_identity_hash"); err != nil {
+				return fmt.Errorf("删除身份证号盲索引失败: %v", err)
+			}
+		}
+		if err := db.Exec("UPDATE staff SET identity_hash = NULL WHERE identity_hash = ''").Error; err != nil {
+			return fmt.Errorf("清理身份证号盲索引失败: %v", err)
+		}
+	}
+
+	// 通知去重标识改为唯一索引，手工发布的通知改为 NULL
+	if db.Migrator().HasColumn(&model.Notification{}, "source_key") {
+		if db.Migrator().HasIndex(&model.Notification{}, "idx_notification_source_key") {
+			if err := db.Migrator().DropIndex(&model.Notification{}, "idx_notification_source_key"); err != nil {
+				return fmt.Errorf("删除通知去重索引失败: %v", err)
+			}
+		}
+		if err := db.Exec("UPDATE notification SET source_key = NULL WHERE source_key = ''").Error; err != nil {
+			return fmt.Errorf("清理通知去重标识失败: %v", err)
+		}
+	}
+
+	// 单个模型迁移失败时继续迁移其余模型，避免后续新增的表无法创建
+	var failed []string
 	for _, model := range models {
 		if err := db.AutoMigrate(model); err != nil {
-			return fmt.Errorf("迁移模型失败: %v", err)
+			log.Printf("迁移模型 %T 失败: %v", model, err)
+			failed = append(failed, fmt.Sprintf("%T", model))
 		}
 	}
+	if len(failed) > 0 {
+		return fmt.Errorf("迁移模型失败: %s", strings.Join(failed, ", "))
+	}
 
 	log.Printf("数据库迁移成功: %s", dbName)
 	return nil
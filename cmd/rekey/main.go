@@ -0,0 +1,304 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"hrms/model"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/viper"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+	_ "modernc.org/sqlite"
+)
+
+// 配置结构体
+type Config struct {
+	Gin struct {
+		Port int64 `json:"port"`
+	} `json:"gin"`
+	Db struct {
+		Type     string `json:"type"` // 数据库类型: mysql, sqlite
+		User     string `json:"user"`
+		Password string `json:"password"`
+		Host     string `json:"host"`
+		Port     int64  `json:"port"`
+		DbName   string `json:"dbName"`
+		Path     string `json:"path"` // SQLite 数据库文件路径
+	} `json:"db"`
+	Crypto model.CryptoConfig `json:"crypto"`
+}
+
+// 初始化配置
+func InitConfig() (*Config, error) {
+	config := &Config{}
+	vip := viper.New()
+	vip.AddConfigPath("./config")
+	vip.SetConfigType("yaml")
+
+	// 环境判断
+	env := os.Getenv("HRMS_ENV")
+	if env == "" {
+		env = "dev"
+	}
+
+	switch env {
+	case "dev":
+		vip.SetConfigName("config-dev")
+	case "test":
+		vip.SetConfigName("config-test")
+	case "prod":
+		vip.SetConfigName("config-prod")
+	case "self":
+		vip.SetConfigName("config-self")
+	default:
+		vip.SetConfigName("config-dev")
+	}
+
+	log.Printf("当前环境: %s", env)
+
+	if err := vip.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("读取配置文件失败: %v", err)
+	}
+
+	if err := vip.Unmarshal(config); err != nil {
+		return nil, fmt.Errorf("解析配置文件失败: %v", err)
+	}
+
+	return config, nil
+}
+
+// 连接数据库
+func InitDB(config *Config, dbName string) (*gorm.DB, error) {
+	dbType := strings.ToLower(config.Db.Type)
+	if dbType == "" {
+		dbType = "mysql" // 默认使用 MySQL
+	}
+
+	var db *gorm.DB
+	var err error
+
+	switch dbType {
+	case "sqlite":
+		// SQLite 连接
+		var dbPath string
+		if config.Db.Path != "" {
+			// 使用配置的路径，支持相对路径和绝对路径
+			if filepath.IsAbs(config.Db.Path) {
+				dbPath = filepath.Join(config.Db.Path, dbName+".db")
+			} else {
+				dbPath = filepath.Join(".", config.Db.Path, dbName+".db")
+			}
+		} else {
+			// 默认路径：./data/数据库名.db
+			dbPath = filepath.Join(".", "data", dbName+".db")
+		}
+
+		// 数据库必须已存在，避免误建空库
+		if _, err := os.Stat(dbPath); err != nil {
+			return nil, fmt.Errorf("数据库文件不存在: %s", dbPath)
+		}
+
+		db, err = openSQLite(dbPath)
+		if err != nil {
+			return nil, fmt.Errorf("SQLite连接失败: %v", err)
+		}
+		log.Printf("SQLite数据库连接成功，路径: %v", dbPath)
+
+	default:
+		// MySQL 连接（默认）
+		dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+			config.Db.User,
+			config.Db.Password,
+			config.Db.Host,
+			config.Db.Port,
+			dbName,
+		)
+
+		db, err = gorm.Open(mysql.Open(dsn), &gorm.Config{
+			NamingStrategy: schema.NamingStrategy{
+				SingularTable: true, // 全局禁止表名复数
+			},
+			Logger: logger.Default.LogMode(logger.Warn),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("MySQL连接失败: %v", err)
+		}
+		log.Printf("MySQL数据库连接成功")
+	}
+
+	return db, nil
+}
+
+func openSQLite(dbPath string) (*gorm.DB, error) {
+	return gorm.Open(sqlite.Dialector{
+		DriverName: "sqlite",
+		DSN:        dbPath + "?_pragma=foreign_keys(1)",
+	}, &gorm.Config{
+		NamingStrategy: schema.NamingStrategy{
+			SingularTable: true, // 全局禁止表名复数
+		},
+		Logger: logger.Default.LogMode(logger.Warn),
+	})
+}
+
+// 需要重新加密的表
+var tables = []struct {
+	name string
+	run  func(db *gorm.DB, batchSize int) (int64, error)
+}{
+	{"staff", func(db *gorm.DB, batchSize int) (int64, error) {
+		return rekeyRows(db, batchSize, []string{"identity_num", "card_num", "phone", "address", "identity_hash"}, func(s *model.Staff) {
+			// 按当前盲索引密钥重新计算
+			s.IdentityHash = nil
+			if s.IdentityNum != "" {
+				hash := model.BlindIndex(s.IdentityNum)
+				s.IdentityHash = &hash
+			}
+		})
+	}},
+	{"candidate", func(db *gorm.DB, batchSize int) (int64, error) {
+		return rekeyRows[model.Candidate](db, batchSize, []string{"email"}, nil)
+	}},
+	{"staff_history", func(db *gorm.DB, batchSize int) (int64, error) {
+		return rekeyRows[model.StaffHistory](db, batchSize, []string{"old_value", "new_value"}, nil)
+	}},
+	{"staff_contact", func(db *gorm.DB, batchSize int) (int64, error) {
+		return rekeyRows[model.StaffContact](db, batchSize, []string{"phone", "address"}, nil)
+	}},
+	{"staff_family", func(db *gorm.DB, batchSize int) (int64, error) {
+		return rekeyRows[model.StaffFamily](db, batchSize, []string{"identity_num", "phone"}, nil)
+	}},
+	{"staff_change_request", func(db *gorm.DB, batchSize int) (int64, error) {
+		return rekeyRows[model.StaffChangeRequest](db, batchSize, []string{"phone", "card_num", "address", "contacts"}, nil)
+	}},
+}
+
+// 读取时按密文中的密钥编号解密，写回时使用当前密钥加密，每批在一个事务中提交
+func rekeyRows[T any](db *gorm.DB, batchSize int, columns []string, prepare func(*T)) (int64, error) {
+	var rows []T
+	var count int64
+	err := db.Unscoped().FindInBatches(&rows, batchSize, func(_ *gorm.DB, batch int) error {
+		return db.Transaction(func(tx *gorm.DB) error {
+			for i := range rows {
+				if prepare != nil {
+					prepare(&rows[i])
+				}
+				// UpdateColumns 不更新 updated_at，重新加密不视为业务修改
+				if err := tx.Unscoped().Model(&rows[i]).Select(columns).UpdateColumns(&rows[i]).Error; err != nil {
+					return err
+				}
+			}
+			count += int64(len(rows))
+			return nil
+		})
+	}).Error
+	return count, err
+}
+
+func main() {
+	var (
+		dbNames   string
+		batchSize int
+		genKey    bool
+		help      bool
+	)
+
+	flag.StringVar(&dbNames, "db", "", "指定数据库名称，多个用逗号分隔（默认使用配置文件中的所有数据库）")
+	flag.IntVar(&batchSize, "batch", 500, "每批处理的行数")
+	flag.BoolVar(&genKey, "genkey", false, "生成一个新的随机密钥并输出")
+	flag.BoolVar(&help, "h", false, "显示帮助信息")
+	flag.BoolVar(&help, "help", false, "显示帮助信息")
+
+	flag.Parse()
+
+	if help {
+		fmt.Println("敏感字段重新加密工具")
+		fmt.Println("使用配置中的当前密钥重新加密员工身份证号、银行卡号、手机号、住址，候选人邮箱，员工变更记录、自助修改申请及紧急联系人、家庭成员的敏感字段，并重新计算身份证号盲索引")
+		fmt.Println()
+		fmt.Println("用法:")
+		fmt.Println("  rekey [选项]")
+		fmt.Println()
+		fmt.Println("选项:")
+		flag.PrintDefaults()
+		fmt.Println()
+		fmt.Println("示例:")
+		fmt.Println("  rekey -genkey                # 生成新密钥，写入配置文件 crypto.keys")
+		fmt.Println("  rekey                        # 重新加密配置中的所有数据库")
+		fmt.Println("  rekey -db hrms_C001          # 只处理指定数据库")
+		fmt.Println()
+		fmt.Println("注意事项:")
+		fmt.Println("  - 轮换密钥时先将新密钥加入 crypto.keys 并设为 activeKeyId，执行完成后再移除旧密钥")
+		fmt.Println("  - 未配置 activeKeyId 时数据将被解密为明文")
+		fmt.Println("  - 可重复执行，中断后重新执行即可")
+		return
+	}
+
+	if genKey {
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			log.Fatalf("生成密钥失败: %v", err)
+		}
+		fmt.Println(base64.StdEncoding.EncodeToString(key))
+		return
+	}
+
+	// 初始化配置
+	config, err := InitConfig()
+	if err != nil {
+		log.Fatalf("配置初始化失败: %v", err)
+	}
+	if err := model.InitFieldCrypto(config.Crypto); err != nil {
+		log.Fatalf("加载加密密钥失败: %v", err)
+	}
+	if config.Crypto.ActiveKeyId == "" {
+		log.Printf("警告: 未配置 activeKeyId，数据将以明文写回")
+	}
+
+	// 获取要处理的数据库列表
+	var targetDBs []string
+	if dbNames != "" {
+		targetDBs = strings.Split(dbNames, ",")
+	} else {
+		targetDBs = strings.Split(config.Db.DbName, ",")
+	}
+
+	failCount := 0
+	for _, dbName := range targetDBs {
+		dbName = strings.TrimSpace(dbName)
+		if dbName == "" {
+			continue
+		}
+		db, err := InitDB(config, dbName)
+		if err != nil {
+			log.Printf("数据库 %v 连接失败: %v", dbName, err)
+			failCount++
+			continue
+		}
+		for _, table := range tables {
+			count, err := table.run(db, batchSize)
+			if err != nil {
+				log.Printf("数据库 %v 表 %v 重新加密失败: %v", dbName, table.name, err)
+				failCount++
+				continue
+			}
+			log.Printf("数据库 %v 表 %v 重新加密完成，共 %d 行", dbName, table.name, count)
+		}
+		if sqlDB, e := db.DB(); e == nil {
+			sqlDB.Close()
+		}
+	}
+
+	if failCount > 0 {
+		os.Exit(1)
+	}
+	log.Println("重新加密完成")
+}
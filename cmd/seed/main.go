@@ -0,0 +1,799 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"hrms/model"
+	"hrms/service"
+	"log"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+	_ "modernc.org/sqlite"
+)
+
+// 配置结构体
+type Config struct {
+	Gin struct {
+		Port int64 `json:"port"`
+	} `json:"gin"`
+	Db struct {
+		Type     string `json:"type"` // 数据库类型: mysql, sqlite
+		User     string `json:"user"`
+		Password string `json:"password"`
+		Host     string `json:"host"`
+		Port     int64  `json:"port"`
+		DbName   string `json:"dbName"`
+		Path     string `json:"path"` // SQLite 数据库文件路径
+	} `json:"db"`
+	Crypto model.CryptoConfig `json:"crypto"`
+}
+
+// 数据填充规模
+type SeedOptions struct {
+	Seed         int64
+	Deps         int
+	Ranks        int
+	Staffs       int
+	Months       int
+	StartMonth   string
+	Notices      int
+	Recruitments int
+	Candidates   int
+	Examples     int
+	Clean        bool
+}
+
+// 初始化配置
+func InitConfig() (*Config, error) {
+	config := &Config{}
+	vip := viper.New()
+	vip.AddConfigPath("./config")
+	vip.SetConfigType("yaml")
+
+	// 环境判断
+	env := os.Getenv("HRMS_ENV")
+	if env == "" {
+		env = "dev"
+	}
+
+	switch env {
+	case "dev":
+		vip.SetConfigName("config-dev")
+	case "test":
+		vip.SetConfigName("config-test")
+	case "prod":
+		vip.SetConfigName("config-prod")
+	case "self":
+		vip.SetConfigName("config-self")
+	default:
+		vip.SetConfigName("config-dev")
+	}
+
+	log.Printf("当前环境: %s", env)
+
+	if err := vip.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("读取配置文件失败: %v", err)
+	}
+
+	if err := vip.Unmarshal(config); err != nil {
+		return nil, fmt.Errorf("解析配置文件失败: %v", err)
+	}
+
+	return config, nil
+}
+
+// 连接数据库
+func InitDB(config *Config, dbName string) (*gorm.DB, error) {
+	dbType := strings.ToLower(config.Db.Type)
+	if dbType == "" {
+		dbType = "mysql" // 默认使用 MySQL
+	}
+
+	var db *gorm.DB
+	var err error
+
+	switch dbType {
+	case "sqlite":
+		// SQLite 连接
+		var dbPath string
+		if config.Db.Path != "" {
+			// 使用配置的路径，支持相对路径和绝对路径
+			if filepath.IsAbs(config.Db.Path) {
+				dbPath = filepath.Join(config.Db.Path, dbName+".db")
+			} else {
+				dbPath = filepath.Join(".", config.Db.Path, dbName+".db")
+			}
+		} else {
+			// 默认路径：./data/数据库名.db
+			dbPath = filepath.Join(".", "data", dbName+".db")
+		}
+
+		// 确保目录存在
+		dir := filepath.Dir(dbPath)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("创建SQLite数据库目录失败: %v", err)
+		}
+
+		db, err = gorm.Open(sqlite.Dialector{
+			DriverName: "sqlite",
+			DSN:        dbPath + "?_pragma=foreign_keys(1)",
+		}, &gorm.Config{
+			NamingStrategy: schema.NamingStrategy{
+				SingularTable: true, // 全局禁止表名复数
+			},
+			Logger: logger.Default.LogMode(logger.Warn),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("SQLite连接失败: %v", err)
+		}
+		log.Printf("SQLite数据库连接成功，路径: %v", dbPath)
+
+	default:
+		// MySQL 连接（默认）
+		dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+			config.Db.User,
+			config.Db.Password,
+			config.Db.Host,
+			config.Db.Port,
+			dbName,
+		)
+
+		db, err = gorm.Open(mysql.Open(dsn), &gorm.Config{
+			NamingStrategy: schema.NamingStrategy{
+				SingularTable: true, // 全局禁止表名复数
+			},
+			Logger: logger.Default.LogMode(logger.Warn),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("MySQL连接失败: %v", err)
+		}
+		log.Printf("MySQL数据库连接成功")
+	}
+
+	return db, nil
+}
+
+// 需要填充的业务表，顺序与 migrate 工具保持一致
+func getModels() []interface{} {
+	return []interface{}{
+		&model.Authority{},
+		&model.AuthorityDetail{},
+		&model.Department{},
+		&model.Rank{},
+		&model.Staff{},
+		&model.AttendanceRecord{},
+		&model.Notification{},
+		&model.BranchCompany{},
+		&model.Salary{},
+		&model.SalaryRecord{},
+		&model.Recruitment{},
+		&model.Candidate{},
+		&model.Example{},
+		&model.ExampleScore{},
+		&model.StaffStatusLog{},
+		&model.StaffHistory{},
+		&model.DepRestructure{},
+		&model.IdSequence{},
+		&model.SensitiveRevealLog{},
+		&model.AccessLog{},
+		&model.StaffDocument{},
+		&model.StaffContract{},
+		&model.StaffProbation{},
+		&model.StaffContact{},
+		&model.StaffFamily{},
+		&model.StaffEducation{},
+		&model.StaffEmployment{},
+		&model.RankChange{},
+		&model.StaffChangeRequest{},
+		&model.MilestoneOptOut{},
+	}
+}
+
+// 清理已有业务数据，保留 root/admin 账号、权限配置及分公司信息
+func cleanDB(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		tables := []interface{}{
+			&model.MilestoneOptOut{},
+			&model.StaffChangeRequest{},
+			&model.RankChange{},
+			&model.StaffEmployment{},
+			&model.StaffEducation{},
+			&model.StaffFamily{},
+			&model.StaffContact{},
+			&model.StaffProbation{},
+			&model.StaffContract{},
+			&model.StaffDocument{},
+			&model.SensitiveRevealLog{},
+			&model.IdSequence{},
+			&model.DepRestructure{},
+			&model.StaffHistory{},
+			&model.StaffStatusLog{},
+			&model.ExampleScore{},
+			&model.Example{},
+			&model.Candidate{},
+			&model.Recruitment{},
+			&model.SalaryRecord{},
+			&model.Salary{},
+			&model.Notification{},
+			&model.AttendanceRecord{},
+			&model.Rank{},
+			&model.Department{},
+		}
+		for _, table := range tables {
+			if err := tx.Session(&gorm.Session{AllowGlobalUpdate: true}).Unscoped().Delete(table).Error; err != nil {
+				return err
+			}
+		}
+		// 访问日志的删除钩子禁止删除，清理时跳过钩子
+		if err := tx.Session(&gorm.Session{AllowGlobalUpdate: true, SkipHooks: true}).Unscoped().Delete(&model.AccessLog{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("staff_id not in ?", []string{"root", "admin"}).Delete(&model.Staff{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("staff_id not in ?", []string{"root", "admin"}).Delete(&model.Authority{}).Error
+	})
+}
+
+// 基础数据字典
+var (
+	surnames    = []string{"王", "李", "张", "刘", "陈", "杨", "黄", "赵", "吴", "周", "徐", "孙", "马", "朱", "胡", "郭", "何", "林", "罗", "高", "郑", "梁", "谢", "宋", "唐"}
+	givenNames  = []string{"伟", "芳", "娜", "敏", "静", "磊", "强", "军", "洋", "勇", "艳", "杰", "涛", "明", "超", "秀英", "霞", "平", "刚", "桂英", "博", "晨", "子涵", "浩然", "欣怡", "宇轩", "思远", "佳琪", "俊杰", "雨桐"}
+	depNames    = []string{"研发部", "产品部", "市场部", "销售部", "人力资源部", "财务部", "行政部", "运营部", "客服部", "法务部", "采购部", "测试部"}
+	rankNames   = []string{"实习生", "助理", "专员", "高级专员", "主管", "经理", "高级经理", "总监", "副总裁", "总裁"}
+	nations     = []string{"汉族", "汉族", "汉族", "汉族", "壮族", "回族", "满族", "苗族", "土家族"}
+	schools     = []string{"清华大学", "北京大学", "中山大学", "华南理工大学", "浙江大学", "复旦大学", "武汉大学", "四川大学", "南京大学", "暨南大学"}
+	majors      = []string{"计算机科学与技术", "软件工程", "市场营销", "会计学", "人力资源管理", "法学", "工商管理", "电子信息工程", "机械工程", "汉语言文学"}
+	eduLevels   = []string{"大专", "本科", "本科", "本科", "硕士", "博士"}
+	regionCodes = []string{"110101", "310104", "440103", "440106", "440304", "330106", "320102", "420106", "510104", "460106"}
+	jobNames    = []string{"Java开发工程师", "Go开发工程师", "前端工程师", "测试工程师", "产品经理", "销售代表", "财务专员", "HRBP", "运维工程师", "数据分析师"}
+	jobTypes    = []string{"全职", "全职", "全职", "兼职", "实习"}
+	locations   = []string{"广州", "深圳", "北京", "上海", "杭州"}
+	experiences = []string{"应届生", "1-3年", "3-5年", "5-10年"}
+	noticeTypes = []string{"普通通知", "普通通知", "普通通知", "紧急通知"}
+	noticeTitle = []string{"关于调整上下班时间的通知", "季度全员大会安排", "年度体检通知", "办公区消防演练", "节假日放假安排", "新版报销制度发布", "年度绩效考核启动", "团建活动报名"}
+)
+
+type seeder struct {
+	db   *gorm.DB
+	rnd  *rand.Rand
+	opts *SeedOptions
+	used map[string]bool
+
+	deps   []model.Department
+	ranks  []model.Rank
+	staffs []model.Staff
+}
+
+func (s *seeder) pick(list []string) string {
+	return list[s.rnd.Intn(len(list))]
+}
+
+// 在 [min, max] 区间内取随机数
+func (s *seeder) between(min, max int64) int64 {
+	return min + s.rnd.Int63n(max-min+1)
+}
+
+// 生成不重复的业务ID，前缀与 service.RandomID 保持一致，使用随机数以保证同一种子生成的数据可复现
+func (s *seeder) randomID(pre string) string {
+	for {
+		id := fmt.Sprintf("%v_%v", pre, s.rnd.Uint32())
+		if !s.used[id] {
+			s.used[id] = true
+			return id
+		}
+	}
+}
+
+// 员工工号 H 加5位数字可用的工号数，即可生成的员工数上限
+const staffIdSpace = 100000
+
+// 生成不重复的员工工号，格式与默认工号格式 H{seq:5} 保持一致
+func (s *seeder) randomStaffId() string {
+	for {
+		id := fmt.Sprintf("H%05d", s.rnd.Intn(staffIdSpace))
+		if !s.used[id] {
+			s.used[id] = true
+			return id
+		}
+	}
+}
+
+func (s *seeder) randomName() string {
+	return s.pick(surnames) + s.pick(givenNames)
+}
+
+// 生成校验位合法的18位身份证号，顺序码奇数为男性、偶数为女性
+func (s *seeder) randomIdentityNum(birthday time.Time, sex int64) string {
+	for {
+		seq := s.rnd.Intn(1000)
+		if int64(seq%2) == sex%2 {
+			prefix := fmt.Sprintf("%v%v%03d", s.pick(regionCodes), birthday.Format("20060102"), seq)
+			if s.used[prefix] {
+				continue
+			}
+			s.used[prefix] = true
+			return prefix + string(service.IdentityCheckCode(prefix))
+		}
+	}
+}
+
+func (s *seeder) randomCardNum() string {
+	var b strings.Builder
+	b.WriteString("6222")
+	for i := 0; i < 15; i++ {
+		b.WriteByte(byte('0' + s.rnd.Intn(10)))
+	}
+	return b.String()
+}
+
+func (s *seeder) randomPhone() int64 {
+	prefixes := []int64{130, 135, 138, 150, 155, 158, 176, 186, 188, 199}
+	return prefixes[s.rnd.Intn(len(prefixes))]*100000000 + s.rnd.Int63n(100000000)
+}
+
+func (s *seeder) seedDepartments() error {
+	for i := 0; i < s.opts.Deps; i++ {
+		name := depNames[i%len(depNames)]
+		if i >= len(depNames) {
+			name = fmt.Sprintf("%v%v", name, i/len(depNames)+1)
+		}
+		dep := model.Department{
+			DepId:       s.randomID("dep"),
+			DepName:     name,
+			DepDescribe: fmt.Sprintf("负责公司%v相关工作", strings.TrimSuffix(name, "部")),
+			CostCenter:  fmt.Sprintf("CC%03d", i+1),
+		}
+		// 超出部门名称列表的部门作为同名部门的下级部门
+		if i >= len(depNames) {
+			dep.ParentDepId = s.deps[i%len(depNames)].DepId
+		}
+		s.deps = append(s.deps, dep)
+	}
+	return s.db.CreateInBatches(&s.deps, 100).Error
+}
+
+func (s *seeder) seedRanks() error {
+	for i := 0; i < s.opts.Ranks; i++ {
+		name := rankNames[i%len(rankNames)]
+		if i >= len(rankNames) {
+			name = fmt.Sprintf("%v%v", name, i/len(rankNames)+1)
+		}
+		s.ranks = append(s.ranks, model.Rank{
+			RankId:   s.randomID("rank"),
+			RankName: name,
+			Level:    int64(i + 1),
+		})
+	}
+	return s.db.CreateInBatches(&s.ranks, 100).Error
+}
+
+// 生成员工及登录信息，上级关系为树状：
+// 第一位员工为总负责人，各部门首位员工为部门负责人并汇报给总负责人，其余员工汇报给本部门已有员工
+func (s *seeder) seedStaffs(start time.Time) error {
+	var logins []model.Authority
+	depMembers := make(map[string][]int)
+	for i := 0; i < s.opts.Staffs; i++ {
+		dep := s.deps[i%len(s.deps)]
+		sex := s.between(1, 2)
+		birthday := time.Date(int(s.between(1970, 2000)), time.Month(s.between(1, 12)), int(s.between(1, 28)), 0, 0, 0, 0, time.Local)
+		// 职级越靠后越高，负责人取较高职级
+		rankIndex := s.rnd.Intn((len(s.ranks) + 1) / 2)
+		var leader *model.Staff
+		switch {
+		case i == 0:
+			rankIndex = len(s.ranks) - 1
+		case len(depMembers[dep.DepId]) == 0:
+			leader = &s.staffs[0]
+			rankIndex = len(s.ranks)/2 + s.rnd.Intn((len(s.ranks)+1)/2)
+		default:
+			members := depMembers[dep.DepId]
+			leader = &s.staffs[members[s.rnd.Intn(len(members))]]
+		}
+		staff := model.Staff{
+			StaffId:     s.randomStaffId(),
+			StaffName:   s.randomName(),
+			Birthday:    birthday,
+			IdentityNum: s.randomIdentityNum(birthday, sex),
+			Sex:         sex,
+			Nation:      s.pick(nations),
+			School:      s.pick(schools),
+			Major:       s.pick(majors),
+			EduLevel:    s.pick(eduLevels),
+			BaseSalary:  s.between(4, 12)*1000 + int64(rankIndex)*2000,
+			CardNum:     s.randomCardNum(),
+			RankId:      s.ranks[rankIndex].RankId,
+			DepId:       dep.DepId,
+			Phone:       s.randomPhone(),
+			EntryDate:   start.AddDate(0, 0, -int(s.between(30, 3650))),
+		}
+		staff.Email = fmt.Sprintf("%v@hrms.com", strings.ToLower(staff.StaffId))
+		// 入职不满三个月的员工处于试用期
+		staff.Status, staff.StatusDate = model.StaffStatusActive, staff.EntryDate
+		if staff.EntryDate.After(start.AddDate(0, -3, 0)) {
+			staff.Status = model.StaffStatusProbation
+		}
+		if leader != nil {
+			staff.LeaderStaffId = leader.StaffId
+			staff.LeaderName = leader.StaffName
+		}
+		depMembers[dep.DepId] = append(depMembers[dep.DepId], i)
+		s.staffs = append(s.staffs, staff)
+		// 创建登陆信息，密码为身份证后六位
+		identLen := len(staff.IdentityNum)
+		logins = append(logins, model.Authority{
+			AuthorityId:  s.randomID("auth"),
+			StaffId:      staff.StaffId,
+			UserPassword: service.MD5(staff.IdentityNum[identLen-6 : identLen]),
+			UserType:     "normal",
+		})
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.CreateInBatches(&s.staffs, 100).Error; err != nil {
+			return err
+		}
+		// 部门的第一名员工为部门负责人
+		for i := range s.deps {
+			members := depMembers[s.deps[i].DepId]
+			if len(members) == 0 {
+				continue
+			}
+			s.deps[i].HeadStaffId = s.staffs[members[0]].StaffId
+			if err := tx.Model(&model.Department{}).Where("dep_id = ?", s.deps[i].DepId).
+				Update("head_staff_id", s.deps[i].HeadStaffId).Error; err != nil {
+				return err
+			}
+		}
+		return tx.CreateInBatches(&logins, 100).Error
+	})
+}
+
+// 生成工资套账、每月考勤记录及按考勤计算出的薪资记录，最后一个月的薪资为未发放状态
+func (s *seeder) seedPayroll(start time.Time) error {
+	var salaries []model.Salary
+	var attends []model.AttendanceRecord
+	var records []model.SalaryRecord
+	for _, staff := range s.staffs {
+		salary := model.Salary{
+			SalaryId:   s.randomID("salary"),
+			StaffId:    staff.StaffId,
+			StaffName:  staff.StaffName,
+			Base:       staff.BaseSalary,
+			Subsidy:    s.between(0, 10) * 100,
+			Bonus:      s.between(0, 30) * 100,
+			Commission: s.between(0, 20) * 100,
+			Other:      s.between(0, 5) * 100,
+			Fund:       1,
+		}
+		if s.rnd.Intn(10) == 0 {
+			salary.Fund = 2
+		}
+		salaries = append(salaries, salary)
+		for m := 0; m < s.opts.Months; m++ {
+			attend := model.AttendanceRecord{
+				AttendanceId: s.randomID("attendance_record"),
+				StaffId:      staff.StaffId,
+				StaffName:    staff.StaffName,
+				Date:         start.AddDate(0, m, 0).Format("2006-01"),
+				LeaveDays:    s.between(0, 3),
+				OvertimeDays: s.between(0, 4),
+				Approve:      1,
+			}
+			attend.WorkDays = s.between(20, 22) - attend.LeaveDays
+			attends = append(attends, attend)
+			record := service.ComputeSalaryRecord(&attend, &salary)
+			record.SalaryRecordId = s.randomID("salary_record")
+			if m < s.opts.Months-1 {
+				record.IsPay = 2
+			}
+			records = append(records, record)
+		}
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.CreateInBatches(&salaries, 100).Error; err != nil {
+			return err
+		}
+		if len(attends) == 0 {
+			return nil
+		}
+		if err := tx.CreateInBatches(&attends, 100).Error; err != nil {
+			return err
+		}
+		return tx.CreateInBatches(&records, 100).Error
+	})
+}
+
+func (s *seeder) seedNotifications(start time.Time) error {
+	if s.opts.Notices == 0 {
+		return nil
+	}
+	var notices []model.Notification
+	for i := 0; i < s.opts.Notices; i++ {
+		title := s.pick(noticeTitle)
+		notices = append(notices, model.Notification{
+			NoticeId:      s.randomID("notice"),
+			NoticeTitle:   title,
+			NoticeContent: fmt.Sprintf("<p>%v，请各位同事留意并相互转告。</p>", title),
+			Type:          s.pick(noticeTypes),
+			Date:          start.AddDate(0, 0, int(s.between(0, int64(s.opts.Months)*30))),
+		})
+	}
+	return s.db.CreateInBatches(&notices, 100).Error
+}
+
+// 生成招聘信息及候选人，候选人状态 0待处理 1已拒绝 2已录用
+func (s *seeder) seedRecruitment() error {
+	if s.opts.Recruitments == 0 {
+		return nil
+	}
+	var recruitments []model.Recruitment
+	for i := 0; i < s.opts.Recruitments; i++ {
+		job := jobNames[i%len(jobNames)]
+		low := s.between(6, 20)
+		recruitments = append(recruitments, model.Recruitment{
+			RecruitmentId: s.randomID("recruitment"),
+			JobName:       job,
+			JobType:       s.pick(jobTypes),
+			BaseLocation:  s.pick(locations),
+			BaseSalary:    fmt.Sprintf("%vk-%vk", low, low+s.between(2, 10)),
+			EduLevel:      s.pick(eduLevels),
+			Experience:    s.pick(experiences),
+			Describe:      fmt.Sprintf("负责%v相关工作，有团队合作精神", job),
+			Email:         "hr@hrms.com",
+		})
+	}
+	if err := s.db.CreateInBatches(&recruitments, 100).Error; err != nil {
+		return err
+	}
+	if s.opts.Candidates == 0 || len(s.staffs) == 0 {
+		return nil
+	}
+	var candidates []model.Candidate
+	for i := 0; i < s.opts.Candidates; i++ {
+		recruitment := recruitments[s.rnd.Intn(len(recruitments))]
+		interviewer := s.staffs[s.rnd.Intn(len(s.staffs))]
+		candidate := model.Candidate{
+			CandidateId: s.randomID("candidate"),
+			StaffId:     interviewer.StaffId,
+			Name:        s.randomName(),
+			JobName:     recruitment.JobName,
+			EduLevel:    s.pick(eduLevels),
+			Major:       s.pick(majors),
+			Experience:  s.pick(experiences),
+			Describe:    fmt.Sprintf("应聘%v，毕业于%v", recruitment.JobName, s.pick(schools)),
+			Email:       fmt.Sprintf("candidate%03d@example.com", i+1),
+			Status:      s.between(0, 2),
+		}
+		if candidate.Status != 0 {
+			candidate.Evaluation = "面试表现良好，沟通能力较强"
+		}
+		candidates = append(candidates, candidate)
+	}
+	return s.db.CreateInBatches(&candidates, 100).Error
+}
+
+// 生成考试及员工作答成绩，成绩按 service 中的判卷规则计算
+func (s *seeder) seedExamples(start time.Time) error {
+	if s.opts.Examples == 0 {
+		return nil
+	}
+	var examples []model.Example
+	var scores []model.ExampleScore
+	for i := 0; i < s.opts.Examples; i++ {
+		var items []*model.ExampleItem
+		for n := 1; n <= 5; n++ {
+			options := []string{"A.选项一", "B.选项二", "C.选项三", "D.选项四"}
+			items = append(items, &model.ExampleItem{
+				Num:   n,
+				Title: fmt.Sprintf("第%v题：公司制度相关问题%v", n, n),
+				Items: options,
+				Ans:   options[s.rnd.Intn(len(options))],
+			})
+		}
+		contentBytes, _ := json.Marshal(&items)
+		example := model.Example{
+			ExampleId: s.randomID("example"),
+			Name:      fmt.Sprintf("%v员工制度考试", start.AddDate(0, i, 0).Format("2006年01月")),
+			Describe:  "员工制度及规范考核",
+			Date:      start.AddDate(0, i, 0).Format("2006-01-02 15:04:05"),
+			Limit:     30,
+			Content:   string(contentBytes),
+		}
+		examples = append(examples, example)
+		for _, staff := range s.staffs {
+			if s.rnd.Intn(2) == 0 {
+				continue
+			}
+			commit := make(map[string]string)
+			right := 0
+			for _, item := range items {
+				ans := item.Items[s.rnd.Intn(len(item.Items))]
+				if s.rnd.Intn(3) != 0 {
+					ans = item.Ans
+				}
+				if ans == item.Ans {
+					right++
+				}
+				commit[fmt.Sprintf("%v", item.Num)] = ans
+			}
+			commitBytes, _ := json.Marshal(&commit)
+			scores = append(scores, model.ExampleScore{
+				ExampleId: example.ExampleId,
+				StaffId:   staff.StaffId,
+				StaffName: staff.StaffName,
+				Name:      example.Name,
+				Date:      example.Date,
+				Content:   example.Content,
+				Commit:    string(commitBytes),
+				Score:     int64(float32(right) / float32(len(items)) * 100),
+			})
+		}
+	}
+	if err := s.db.CreateInBatches(&examples, 100).Error; err != nil {
+		return err
+	}
+	if len(scores) == 0 {
+		return nil
+	}
+	return s.db.CreateInBatches(&scores, 100).Error
+}
+
+// 填充单个分公司数据库
+func seedDB(db *gorm.DB, dbName string, opts *SeedOptions) error {
+	log.Printf("开始填充数据库: %s", dbName)
+
+	start, err := time.ParseInLocation("2006-01", opts.StartMonth, time.Local)
+	if err != nil {
+		return fmt.Errorf("起始月份格式错误，应为 YYYY-MM: %v", err)
+	}
+	for _, m := range getModels() {
+		if err := db.AutoMigrate(m); err != nil {
+			return fmt.Errorf("迁移模型失败: %v", err)
+		}
+	}
+	if opts.Clean {
+		if err := cleanDB(db); err != nil {
+			return fmt.Errorf("清理已有数据失败: %v", err)
+		}
+		log.Printf("已清理数据库 %s 的已有业务数据", dbName)
+	}
+
+	s := &seeder{
+		db:   db,
+		rnd:  rand.New(rand.NewSource(opts.Seed)),
+		opts: opts,
+		used: make(map[string]bool),
+	}
+	steps := []struct {
+		name string
+		fn   func() error
+	}{
+		{"部门", s.seedDepartments},
+		{"职级", s.seedRanks},
+		{"员工", func() error { return s.seedStaffs(start) }},
+		{"薪资及考勤", func() error { return s.seedPayroll(start) }},
+		{"通知", func() error { return s.seedNotifications(start) }},
+		{"招聘及候选人", s.seedRecruitment},
+		{"考试及成绩", func() error { return s.seedExamples(start) }},
+	}
+	for _, step := range steps {
+		if err := step.fn(); err != nil {
+			return fmt.Errorf("填充%v数据失败: %v", step.name, err)
+		}
+		log.Printf("填充%v数据完成", step.name)
+	}
+
+	log.Printf("数据库填充成功: %s，部门%v个，职级%v个，员工%v名，考勤及薪资%v个月",
+		dbName, len(s.deps), len(s.ranks), len(s.staffs), opts.Months)
+	return nil
+}
+
+func main() {
+	var (
+		dbNames string
+		help    bool
+		opts    SeedOptions
+	)
+
+	flag.StringVar(&dbNames, "db", "", "指定数据库名称，多个用逗号分隔（默认使用配置文件中的所有数据库）")
+	flag.Int64Var(&opts.Seed, "seed", 1, "随机种子，相同种子生成相同数据")
+	flag.IntVar(&opts.Deps, "deps", 6, "部门数量")
+	flag.IntVar(&opts.Ranks, "ranks", 6, "职级数量")
+	flag.IntVar(&opts.Staffs, "staff", 50, "员工数量，不超过 100000")
+	flag.IntVar(&opts.Months, "months", 6, "考勤及薪资月数")
+	flag.StringVar(&opts.StartMonth, "start", "2021-01", "考勤及薪资起始月份，格式 YYYY-MM")
+	flag.IntVar(&opts.Notices, "notices", 10, "通知数量")
+	flag.IntVar(&opts.Recruitments, "recruitments", 5, "招聘信息数量")
+	flag.IntVar(&opts.Candidates, "candidates", 20, "候选人数量")
+	flag.IntVar(&opts.Examples, "examples", 3, "考试数量")
+	flag.BoolVar(&opts.Clean, "clean", false, "填充前清理已有业务数据（保留 root/admin 账号及权限配置）")
+	flag.BoolVar(&help, "h", false, "显示帮助信息")
+	flag.BoolVar(&help, "help", false, "显示帮助信息")
+
+	flag.Parse()
+
+	if help {
+		fmt.Println("测试数据填充工具")
+		fmt.Println("按随机种子生成部门、职级、员工、薪资套账、考勤、薪资记录、通知、招聘、候选人及考试数据")
+		fmt.Println()
+		fmt.Println("用法:")
+		fmt.Println("  seed [选项]")
+		fmt.Println()
+		fmt.Println("选项:")
+		flag.PrintDefaults()
+		fmt.Println()
+		fmt.Println("示例:")
+		fmt.Println("  seed -db hrms_C001                         # 使用默认规模填充")
+		fmt.Println("  seed -db hrms_C001 -clean -staff 200       # 清理后填充200名员工")
+		fmt.Println("  seed -db hrms_C001,hrms_C002 -seed 42      # 使用指定随机种子填充多个数据库")
+		fmt.Println()
+		fmt.Println("注意事项:")
+		fmt.Println("  - 相同的随机种子及参数会生成相同的数据")
+		fmt.Println("  - 员工登录密码为身份证号后六位")
+		return
+	}
+
+	if opts.Deps <= 0 || opts.Ranks <= 0 || opts.Staffs <= 0 {
+		log.Fatal("错误: 部门、职级及员工数量必须大于0")
+	}
+	if opts.Staffs > staffIdSpace {
+		log.Fatalf("错误: 员工数量不能超过 %d，工号格式为 H 加5位数字", staffIdSpace)
+	}
+	if opts.Months < 0 || opts.Notices < 0 || opts.Recruitments < 0 || opts.Candidates < 0 || opts.Examples < 0 {
+		log.Fatal("错误: 数量参数不能为负数")
+	}
+
+	// 初始化配置
+	config, err := InitConfig()
+	if err != nil {
+		log.Fatalf("配置初始化失败: %v", err)
+	}
+	// 身份证号等敏感字段按配置加密写入
+	if err := model.InitFieldCrypto(config.Crypto); err != nil {
+		log.Fatalf("加载加密密钥失败: %v", err)
+	}
+
+	// 获取要填充的数据库列表
+	var targetDBs []string
+	if dbNames != "" {
+		targetDBs = strings.Split(dbNames, ",")
+	} else {
+		targetDBs = strings.Split(config.Db.DbName, ",")
+	}
+
+	failCount := 0
+	for _, dbName := range targetDBs {
+		dbName = strings.TrimSpace(dbName)
+		if dbName == "" {
+			continue
+		}
+
+		db, err := InitDB(config, dbName)
+		if err != nil {
+			log.Printf("连接数据库 %s 失败: %v", dbName, err)
+			failCount++
+			continue
+		}
+
+		if err := seedDB(db, dbName, &opts); err != nil {
+			log.Printf("填充数据库 %s 失败: %v", dbName, err)
+			failCount++
+		}
+	}
+
+	if failCount > 0 {
+		os.Exit(1)
+	}
+	log.Println("操作完成")
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 多分公司执行选项
+type BranchOptions struct {
+	Parallel    int
+	StopOnError bool
+	// 每个分公司在一个事务中执行全部语句，任一语句失败则回滚
+	Transaction bool
+	Format      string
+	ReadOnly    bool
+}
+
+// 单个分公司的执行结果
+type BranchResult struct {
+	DbName   string
+	Executed int
+	Skipped  bool
+	Err      error
+	Output   string
+	Duration time.Duration
+}
+
+// 读取待执行的语句列表
+func loadStatements(sqlStr, filename string) ([]string, error) {
+	var reader *statementReader
+	if filename != "" {
+		file, err := os.Open(filename)
+		if err != nil {
+			return nil, fmt.Errorf("打开文件失败: %v", err)
+		}
+		defer file.Close()
+		reader = newStatementReader(file)
+	} else {
+		reader = newStatementReader(strings.NewReader(sqlStr))
+	}
+	var stmts []string
+	for {
+		stmt, _, err := reader.Next()
+		if err == io.EOF {
+			return stmts, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		stmts = append(stmts, stmt)
+	}
+}
+
+// 依次执行语句，返回成功执行的条数
+func (e *Executor) ExecuteAll(stmts []string) (int, error) {
+	for i, stmt := range stmts {
+		fmt.Fprintf(e.out, "\n=== 执行第 %d 条 SQL ===\n", i+1)
+		if err := e.Execute(stmt); err != nil {
+			return i, err
+		}
+	}
+	return len(stmts), nil
+}
+
+// 在单个分公司数据库上执行全部语句
+func runBranch(config *Config, dbName string, stmts []string, opts *BranchOptions) *BranchResult {
+	begin := time.Now()
+	result := &BranchResult{DbName: dbName}
+	var out bytes.Buffer
+	defer func() {
+		result.Output = out.String()
+		result.Duration = time.Since(begin)
+	}()
+
+	db, err := InitDB(config, dbName)
+	if err != nil {
+		result.Err = fmt.Errorf("数据库连接失败: %v", err)
+		return result
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		defer sqlDB.Close()
+	}
+
+	exec := NewExecutor(db, opts.Format)
+	exec.readOnly = opts.ReadOnly
+	exec.quiet = true
+	exec.out = &out
+	defer exec.Close()
+
+	result.Executed, result.Err = runStatements(exec, stmts, opts)
+	return result
+}
+
+// 按选项执行全部语句，返回成功执行的条数
+func runStatements(exec *Executor, stmts []string, opts *BranchOptions) (int, error) {
+	// 只读模式下每条查询都在回滚的事务中执行，无需整体事务
+	if opts.Transaction && !opts.ReadOnly {
+		if err := exec.Begin(); err != nil {
+			return 0, err
+		}
+	}
+	executed, err := exec.ExecuteAll(stmts)
+	if !exec.InTransaction() {
+		return executed, err
+	}
+	if err != nil {
+		exec.Rollback()
+		return executed, err
+	}
+	if opts.Transaction {
+		return executed, exec.Commit()
+	}
+	// 脚本中开启的事务未提交时回滚，视为执行失败
+	exec.Rollback()
+	return executed, errors.New("脚本中开启的事务未提交，已回滚")
+}
+
+// 事务控制语句
+func isTransactionControl(stmt string) bool {
+	switch strings.ToUpper(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(stmt), ";"))) {
+	case "BEGIN", "START TRANSACTION", "BEGIN TRANSACTION", "COMMIT", "ROLLBACK":
+		return true
+	}
+	return false
+}
+
+// 执行前检查语句，-tx 时由工具管理每个分公司的事务，脚本中不能再包含事务控制语句
+func checkBranchStatements(stmts []string, opts *BranchOptions) error {
+	if !opts.Transaction {
+		return nil
+	}
+	for i, stmt := range stmts {
+		if isTransactionControl(stmt) {
+			return fmt.Errorf("第 %d 条语句 %v: 使用 -tx 时脚本中不能包含事务控制语句", i+1, strings.TrimSpace(stmt))
+		}
+	}
+	return nil
+}
+
+// 在多个分公司数据库上执行语句，parallel 大于1时并发执行
+func runBranches(config *Config, dbNames []string, stmts []string, opts *BranchOptions) []*BranchResult {
+	results := make([]*BranchResult, len(dbNames))
+	parallel := opts.Parallel
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	var (
+		wg      sync.WaitGroup
+		printMu sync.Mutex
+		stopped int32
+	)
+	sem := make(chan struct{}, parallel)
+	for i, dbName := range dbNames {
+		sem <- struct{}{}
+		// 遇错即停时不再启动新的分公司
+		if atomic.LoadInt32(&stopped) == 1 {
+			<-sem
+			results[i] = &BranchResult{DbName: dbName, Skipped: true}
+			continue
+		}
+		wg.Add(1)
+		go func(i int, dbName string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			result := runBranch(config, dbName, stmts, opts)
+			results[i] = result
+			if result.Err != nil && opts.StopOnError {
+				atomic.StoreInt32(&stopped, 1)
+			}
+			printMu.Lock()
+			printBranchResult(result)
+			printMu.Unlock()
+		}(i, dbName)
+	}
+	wg.Wait()
+	return results
+}
+
+func printBranchResult(result *BranchResult) {
+	fmt.Printf("\n########## %s ##########\n", result.DbName)
+	fmt.Print(result.Output)
+	if result.Err != nil {
+		fmt.Printf("\n[%s] 执行失败: %v\n", result.DbName, result.Err)
+	} else {
+		fmt.Printf("\n[%s] 执行成功，共 %d 条语句，耗时 %v\n", result.DbName, result.Executed, result.Duration.Round(time.Millisecond))
+	}
+}
+
+// 输出汇总信息，返回失败的分公司数量
+func printSummary(results []*BranchResult, total int) int {
+	summary := &QueryResult{Columns: []string{"数据库", "状态", "已执行", "耗时", "错误信息"}}
+	failed := 0
+	for _, result := range results {
+		status, errMsg := "成功", ""
+		switch {
+		case result.Skipped:
+			status = "跳过"
+		case result.Err != nil:
+			status, errMsg = "失败", result.Err.Error()
+			failed++
+		}
+		summary.Rows = append(summary.Rows, []interface{}{
+			result.DbName,
+			status,
+			fmt.Sprintf("%d/%d", result.Executed, total),
+			result.Duration.Round(time.Millisecond).String(),
+			errMsg,
+		})
+	}
+	fmt.Printf("\n=== 执行汇总 ===\n")
+	writeTable(os.Stdout, summary)
+	return failed
+}
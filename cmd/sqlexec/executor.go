@@ -0,0 +1,322 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// 只读模式下拒绝执行写操作
+var ErrReadOnly = errors.New("只读模式下禁止执行写操作")
+
+// 用户取消了危险语句的执行
+var ErrCanceled = errors.New("已取消执行")
+
+var whereRegexp = regexp.MustCompile(`(?i)\bWHERE\b`)
+
+// SQL 执行器，维护当前事务、输出格式及安全模式
+type Executor struct {
+	db       *gorm.DB
+	tx       *gorm.DB
+	format   string
+	readOnly bool
+	// 生产环境下无 WHERE 条件的 DELETE/UPDATE 需要确认
+	confirm bool
+	// 交互模式下不打印执行日志
+	quiet bool
+	in    *bufio.Reader
+	out   io.Writer
+}
+
+func NewExecutor(db *gorm.DB, format string) *Executor {
+	return &Executor{
+		db:     db,
+		format: format,
+		in:     bufio.NewReader(os.Stdin),
+		out:    os.Stdout,
+	}
+}
+
+// 当前用于执行语句的连接，事务中使用事务连接
+func (e *Executor) conn() *gorm.DB {
+	if e.tx != nil {
+		return e.tx
+	}
+	return e.db
+}
+
+func (e *Executor) InTransaction() bool {
+	return e.tx != nil
+}
+
+// 只读模式下禁止开启事务，避免查询语句借用事务连接提交写操作
+func (e *Executor) Begin() error {
+	if e.readOnly {
+		return ErrReadOnly
+	}
+	if e.tx != nil {
+		return fmt.Errorf("已处于事务中，请先提交或回滚")
+	}
+	tx := e.db.Begin()
+	if tx.Error != nil {
+		return fmt.Errorf("开启事务失败: %v", tx.Error)
+	}
+	e.tx = tx
+	fmt.Fprintln(e.out, "事务已开启")
+	return nil
+}
+
+func (e *Executor) Commit() error {
+	if e.readOnly {
+		return ErrReadOnly
+	}
+	if e.tx == nil {
+		return fmt.Errorf("当前没有进行中的事务")
+	}
+	err := e.tx.Commit().Error
+	e.tx = nil
+	if err != nil {
+		return fmt.Errorf("提交事务失败: %v", err)
+	}
+	fmt.Fprintln(e.out, "事务已提交")
+	return nil
+}
+
+func (e *Executor) Rollback() error {
+	if e.tx == nil {
+		return fmt.Errorf("当前没有进行中的事务")
+	}
+	err := e.tx.Rollback().Error
+	e.tx = nil
+	if err != nil {
+		return fmt.Errorf("回滚事务失败: %v", err)
+	}
+	fmt.Fprintln(e.out, "事务已回滚")
+	return nil
+}
+
+// 判断是否为查询语句
+func isQuery(upperSQL string) bool {
+	for _, prefix := range []string{"SELECT", "SHOW", "DESCRIBE", "DESC", "EXPLAIN", "WITH", "PRAGMA"} {
+		if strings.HasPrefix(upperSQL, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// 去除语句中的字符串、带引号的标识符及注释，避免其中的关键字被误判
+func stripLiterals(sqlStr string) string {
+	var b strings.Builder
+	for i := 0; i < len(sqlStr); i++ {
+		ch := sqlStr[i]
+		switch {
+		case ch == '\'' || ch == '"' || ch == '`':
+			// 引号内的内容替换为空格，连续两个引号或反斜杠转义的引号视为内容
+			for i++; i < len(sqlStr); i++ {
+				if sqlStr[i] == '\\' && ch != '`' {
+					i++
+				} else if sqlStr[i] == ch {
+					if i+1 < len(sqlStr) && sqlStr[i+1] == ch {
+						i++
+						continue
+					}
+					break
+				}
+			}
+			b.WriteByte(' ')
+		case ch == '-' && strings.HasPrefix(sqlStr[i:], "--"), ch == '#':
+			for i < len(sqlStr) && sqlStr[i] != '\n' {
+				i++
+			}
+			b.WriteByte(' ')
+		case ch == '/' && strings.HasPrefix(sqlStr[i:], "/*"):
+			end := strings.Index(sqlStr[i+2:], "*/")
+			if end < 0 {
+				i = len(sqlStr)
+			} else {
+				i += end + 3
+			}
+			b.WriteByte(' ')
+		default:
+			b.WriteByte(ch)
+		}
+	}
+	return b.String()
+}
+
+// 判断是否为不带 WHERE 条件的 DELETE/UPDATE 语句，字符串及注释中的 WHERE 不计入
+func isUnboundedWrite(upperSQL string) bool {
+	if !strings.HasPrefix(upperSQL, "DELETE") && !strings.HasPrefix(upperSQL, "UPDATE") {
+		return false
+	}
+	return !whereRegexp.MatchString(stripLiterals(upperSQL))
+}
+
+// 向用户确认危险语句，输入 yes 才继续执行
+func (e *Executor) confirmDangerous(sqlStr string) error {
+	fmt.Fprintf(e.out, "警告: 该语句没有 WHERE 条件，将影响整张表:\n  %s\n确认执行请输入 yes: ", sqlStr)
+	answer, err := e.in.ReadString('\n')
+	if err != nil && answer == "" {
+		return ErrCanceled
+	}
+	if strings.TrimSpace(strings.ToLower(answer)) != "yes" {
+		return ErrCanceled
+	}
+	return nil
+}
+
+// 执行 SQL 语句
+func (e *Executor) Execute(sqlStr string) error {
+	sqlStr = strings.TrimSpace(sqlStr)
+	if sqlStr == "" {
+		return nil
+	}
+
+	// 移除末尾的分号
+	sqlStr = strings.TrimSpace(strings.TrimSuffix(sqlStr, ";"))
+
+	if !e.quiet {
+		log.Printf("执行 SQL: %s", sqlStr)
+	}
+
+	upperSQL := strings.ToUpper(sqlStr)
+	// 事务控制语句需要绑定到同一连接上执行
+	switch upperSQL {
+	case "BEGIN", "START TRANSACTION", "BEGIN TRANSACTION":
+		return e.Begin()
+	case "COMMIT":
+		return e.Commit()
+	case "ROLLBACK":
+		return e.Rollback()
+	}
+
+	if isQuery(upperSQL) {
+		// 查询语句，返回结果；只读模式下始终在单独回滚的事务中执行，防止带副作用的查询生效
+		conn := e.conn()
+		if e.readOnly {
+			conn = e.db.Begin()
+			if conn.Error != nil {
+				return fmt.Errorf("开启事务失败: %v", conn.Error)
+			}
+			defer conn.Rollback()
+		}
+		rows, err := conn.Raw(sqlStr).Rows()
+		if err != nil {
+			return fmt.Errorf("查询执行失败: %v", err)
+		}
+		defer rows.Close()
+
+		result, err := scanRows(rows)
+		if err != nil {
+			return err
+		}
+		if err := writeResult(e.out, e.format, result); err != nil {
+			return err
+		}
+		if e.format == FormatTable {
+			fmt.Fprintf(e.out, "\n查询完成，共 %d 行记录\n", len(result.Rows))
+		}
+		return nil
+	}
+
+	// 非查询语句（INSERT, UPDATE, DELETE 等）
+	if e.readOnly {
+		return ErrReadOnly
+	}
+	if e.confirm && isUnboundedWrite(upperSQL) {
+		if err := e.confirmDangerous(sqlStr); err != nil {
+			return err
+		}
+	}
+	result := e.conn().Exec(sqlStr)
+	if result.Error != nil {
+		return fmt.Errorf("SQL 执行失败: %v", result.Error)
+	}
+
+	fmt.Fprintf(e.out, "SQL 执行成功，影响行数: %d\n", result.RowsAffected)
+	return nil
+}
+
+// 按行读取 SQL，以分号结尾的行作为一条语句的结束
+type statementReader struct {
+	scanner *bufio.Scanner
+	lineNum int
+}
+
+func newStatementReader(r io.Reader) *statementReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	return &statementReader{scanner: scanner}
+}
+
+// 读取下一条语句，返回语句及其结束行号，读取完毕返回 io.EOF
+func (s *statementReader) Next() (string, int, error) {
+	var sqlBuilder strings.Builder
+	for s.scanner.Scan() {
+		s.lineNum++
+		line := strings.TrimSpace(s.scanner.Text())
+
+		// 跳过空行和注释
+		if line == "" || strings.HasPrefix(line, "--") || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		sqlBuilder.WriteString(line)
+		sqlBuilder.WriteString(" ")
+
+		// 如果行以分号结尾，返回语句
+		if strings.HasSuffix(line, ";") {
+			return strings.TrimSpace(sqlBuilder.String()), s.lineNum, nil
+		}
+	}
+	if err := s.scanner.Err(); err != nil {
+		return "", s.lineNum, fmt.Errorf("读取文件失败: %v", err)
+	}
+	// 处理最后一条没有分号的 SQL
+	if sqlBuilder.Len() > 0 {
+		return strings.TrimSpace(sqlBuilder.String()), s.lineNum, nil
+	}
+	return "", s.lineNum, io.EOF
+}
+
+// 从文件读取 SQL 语句
+func (e *Executor) ExecuteFile(filename string) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return fmt.Errorf("打开文件失败: %v", err)
+	}
+	defer file.Close()
+
+	reader := newStatementReader(file)
+	for {
+		sqlStr, lineNum, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(e.out, "\n=== 执行第 %d 行附近的 SQL ===\n", lineNum)
+		if err := e.Execute(sqlStr); err != nil {
+			log.Printf("第 %d 行 SQL 执行失败: %v", lineNum, err)
+			return err
+		}
+	}
+}
+
+// 结束执行，未提交的事务将被回滚
+func (e *Executor) Close() {
+	if e.tx != nil {
+		log.Printf("存在未提交的事务，已自动回滚")
+		e.tx.Rollback()
+		e.tx = nil
+	}
+}
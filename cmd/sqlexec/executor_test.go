@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestIsQuery(t *testing.T) {
+	cases := []struct {
+		sql  string
+		want bool
+	}{
+		{"SELECT * FROM STAFF", true},
+		{"SHOW TABLES", true},
+		{"DESC STAFF", true},
+		{"EXPLAIN SELECT 1", true},
+		{"WITH T AS (SELECT 1) SELECT * FROM T", true},
+		{"PRAGMA TABLE_INFO(STAFF)", true},
+		{"INSERT INTO STAFF VALUES (1)", false},
+		{"UPDATE STAFF SET NAME = 'A'", false},
+		{"DELETE FROM STAFF", false},
+		{"DROP TABLE STAFF", false},
+	}
+	for _, tc := range cases {
+		if got := isQuery(tc.sql); got != tc.want {
+			t.Errorf("isQuery(%q) = %v, want %v", tc.sql, got, tc.want)
+		}
+	}
+}
+
+func TestIsUnboundedWrite(t *testing.T) {
+	cases := []struct {
+		sql  string
+		want bool
+	}{
+		{"DELETE FROM STAFF", true},
+		{"UPDATE STAFF SET NAME = 'A'", true},
+		{"DELETE FROM STAFF WHERE ID = 1", false},
+		{"UPDATE STAFF SET NAME = 'A' WHERE ID = 1", false},
+		{"UPDATE STAFF SET NAME = 'NOWHERE'", true},
+		// 字符串及注释中的 WHERE 不算条件
+		{"UPDATE STAFF SET REMARK='WHERE'", true},
+		{"UPDATE STAFF SET REMARK=\"A WHERE B\"", true},
+		{"UPDATE STAFF SET REMARK='IT''S WHERE'", true},
+		{"UPDATE STAFF SET REMARK='A\\' WHERE'", true},
+		{"UPDATE STAFF SET REMARK='A' -- WHERE ID = 1", true},
+		{"UPDATE STAFF SET REMARK='A' # WHERE ID = 1", true},
+		{"DELETE FROM STAFF /* WHERE ID = 1 */", true},
+		{"DELETE FROM `WHERE`", true},
+		{"UPDATE STAFF SET REMARK='WHERE' WHERE ID = 1", false},
+		{"DELETE FROM STAFF /* ALL */ WHERE ID = 1", false},
+		{"UPDATE STAFF SET REMARK='IT''S' WHERE ID = 1", false},
+		{"SELECT * FROM STAFF", false},
+		{"INSERT INTO STAFF VALUES (1)", false},
+	}
+	for _, tc := range cases {
+		if got := isUnboundedWrite(tc.sql); got != tc.want {
+			t.Errorf("isUnboundedWrite(%q) = %v, want %v", tc.sql, got, tc.want)
+		}
+	}
+}
+
+func newTestExecutor(t *testing.T) *Executor {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Dialector{
+		DriverName: "sqlite",
+		DSN:        filepath.Join(t.TempDir(), "test.db"),
+	}, &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("打开数据库失败: %v", err)
+	}
+	if err := db.Exec("CREATE TABLE staff (id INTEGER PRIMARY KEY, name TEXT)").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec("INSERT INTO staff (id, name) VALUES (1, 'a'), (2, 'b')").Error; err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	exec := NewExecutor(db, FormatTable)
+	exec.quiet = true
+	exec.out = &bytes.Buffer{}
+	return exec
+}
+
+func TestReadOnlyRejectsWrites(t *testing.T) {
+	cases := [][]string{
+		{"DELETE FROM staff WHERE id = 1"},
+		{"BEGIN", "WITH t AS (SELECT 1) DELETE FROM staff WHERE id IN (SELECT * FROM t)", "COMMIT"},
+		{"START TRANSACTION", "DELETE FROM staff", "COMMIT"},
+		{"WITH t AS (SELECT 1) DELETE FROM staff WHERE id IN (SELECT * FROM t)"},
+	}
+	for _, stmts := range cases {
+		exec := newTestExecutor(t)
+		exec.readOnly = true
+		for _, stmt := range stmts {
+			err := exec.Execute(stmt)
+			upper := strings.ToUpper(stmt)
+			if (upper == "BEGIN" || upper == "START TRANSACTION" || upper == "COMMIT") && !errors.Is(err, ErrReadOnly) {
+				t.Errorf("%q 应被拒绝, err = %v", stmt, err)
+			}
+		}
+		if exec.InTransaction() {
+			t.Errorf("%v: 只读模式下不应处于事务中", stmts)
+		}
+		var count int64
+		if err := exec.db.Raw("SELECT COUNT(*) FROM staff").Scan(&count).Error; err != nil {
+			t.Fatal(err)
+		}
+		if count != 2 {
+			t.Errorf("%v: 只读模式下数据被修改, count = %d", stmts, count)
+		}
+	}
+}
+
+func TestReadOnlyAllowsQueries(t *testing.T) {
+	exec := newTestExecutor(t)
+	exec.readOnly = true
+	out := exec.out.(*bytes.Buffer)
+	if err := exec.Execute("SELECT name FROM staff ORDER BY id"); err != nil {
+		t.Fatalf("查询失败: %v", err)
+	}
+	if !strings.Contains(out.String(), "共 2 行记录") {
+		t.Errorf("查询输出错误: %s", out.String())
+	}
+}
+
+func TestTransactionCommit(t *testing.T) {
+	exec := newTestExecutor(t)
+	for _, stmt := range []string{"BEGIN", "DELETE FROM staff WHERE id = 1", "COMMIT"} {
+		if err := exec.Execute(stmt); err != nil {
+			t.Fatalf("%q 执行失败: %v", stmt, err)
+		}
+	}
+	var count int64
+	exec.db.Raw("SELECT COUNT(*) FROM staff").Scan(&count)
+	if count != 1 {
+		t.Errorf("count = %d, want 1", count)
+	}
+}
+
+func TestRunStatementsUncommitted(t *testing.T) {
+	cases := []struct {
+		stmts   []string
+		opts    BranchOptions
+		wantErr bool
+		want    int64
+	}{
+		{[]string{"BEGIN", "DELETE FROM staff WHERE id = 1", "COMMIT"}, BranchOptions{}, false, 1},
+		// 开启的事务未提交时回滚并视为失败
+		{[]string{"BEGIN", "DELETE FROM staff WHERE id = 1"}, BranchOptions{}, true, 2},
+		{[]string{"DELETE FROM staff WHERE id = 1"}, BranchOptions{Transaction: true}, false, 1},
+		{[]string{"DELETE FROM staff WHERE id = 1", "DELETE FROM nothing"}, BranchOptions{Transaction: true}, true, 2},
+	}
+	for _, tc := range cases {
+		exec := newTestExecutor(t)
+		_, err := runStatements(exec, tc.stmts, &tc.opts)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%v: err = %v, wantErr %v", tc.stmts, err, tc.wantErr)
+		}
+		if exec.InTransaction() {
+			t.Errorf("%v: 执行结束后不应处于事务中", tc.stmts)
+		}
+		var count int64
+		exec.db.Raw("SELECT COUNT(*) FROM staff").Scan(&count)
+		if count != tc.want {
+			t.Errorf("%v: count = %d, want %d", tc.stmts, count, tc.want)
+		}
+	}
+}
+
+func TestCheckBranchStatements(t *testing.T) {
+	stmts := []string{"begin;", "DELETE FROM staff WHERE id = 1", "COMMIT"}
+	if err := checkBranchStatements(stmts, &BranchOptions{}); err != nil {
+		t.Errorf("未使用 -tx 时不检查: %v", err)
+	}
+	if err := checkBranchStatements(stmts, &BranchOptions{Transaction: true}); err == nil {
+		t.Error("使用 -tx 时应拒绝事务控制语句")
+	}
+	if err := checkBranchStatements(stmts[1:2], &BranchOptions{Transaction: true}); err != nil {
+		t.Errorf("err = %v", err)
+	}
+}
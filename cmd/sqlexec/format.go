@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// 查询结果输出格式
+const (
+	FormatTable = "table"
+	FormatCSV   = "csv"
+	FormatJSON  = "json"
+)
+
+func validFormat(format string) bool {
+	return format == FormatTable || format == FormatCSV || format == FormatJSON
+}
+
+// 查询结果，NULL 值以 nil 表示
+type QueryResult struct {
+	Columns []string
+	Rows    [][]interface{}
+}
+
+// 读取全部查询结果
+func scanRows(rows *sql.Rows) (*QueryResult, error) {
+	// 获取列名
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("获取列名失败: %v", err)
+	}
+	result := &QueryResult{Columns: columns}
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		valuePtrs := make([]interface{}, len(columns))
+		for i := range columns {
+			valuePtrs[i] = &values[i]
+		}
+		if err := rows.Scan(valuePtrs...); err != nil {
+			return nil, fmt.Errorf("扫描行数据失败: %v", err)
+		}
+		for i, val := range values {
+			// 处理字节数组转字符串
+			if v, ok := val.([]byte); ok {
+				values[i] = string(v)
+			}
+		}
+		result.Rows = append(result.Rows, values)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("读取行数据失败: %v", err)
+	}
+	return result, nil
+}
+
+func formatValue(val interface{}) string {
+	switch v := val.(type) {
+	case nil:
+		return "NULL"
+	case time.Time:
+		return v.Format("2006-01-02 15:04:05")
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// 计算字符串显示宽度，中文等全角字符占两列
+func displayWidth(str string) int {
+	width := 0
+	for _, r := range str {
+		if r >= 0x1100 && (r <= 0x115f || (r >= 0x2e80 && r <= 0xa4cf) || (r >= 0xac00 && r <= 0xd7a3) ||
+			(r >= 0xf900 && r <= 0xfaff) || (r >= 0xfe30 && r <= 0xfe4f) || (r >= 0xff00 && r <= 0xff60) ||
+			(r >= 0xffe0 && r <= 0xffe6)) {
+			width += 2
+		} else {
+			width++
+		}
+	}
+	return width
+}
+
+func padRight(str string, width int) string {
+	return str + strings.Repeat(" ", width-displayWidth(str))
+}
+
+// 以对齐表格输出
+func writeTable(w io.Writer, result *QueryResult) {
+	widths := make([]int, len(result.Columns))
+	for i, col := range result.Columns {
+		widths[i] = displayWidth(col)
+	}
+	cells := make([][]string, len(result.Rows))
+	for r, row := range result.Rows {
+		cells[r] = make([]string, len(row))
+		for i, val := range row {
+			// 单元格内换行替换为空格，避免破坏表格
+			cells[r][i] = strings.NewReplacer("\r", " ", "\n", " ", "\t", " ").Replace(formatValue(val))
+			if width := displayWidth(cells[r][i]); width > widths[i] {
+				widths[i] = width
+			}
+		}
+	}
+
+	separator := "+"
+	for _, width := range widths {
+		separator += strings.Repeat("-", width+2) + "+"
+	}
+	line := func(values []string) {
+		var b strings.Builder
+		b.WriteString("|")
+		for i, val := range values {
+			b.WriteString(" " + padRight(val, widths[i]) + " |")
+		}
+		fmt.Fprintln(w, b.String())
+	}
+
+	fmt.Fprintln(w, separator)
+	line(result.Columns)
+	fmt.Fprintln(w, separator)
+	for _, row := range cells {
+		line(row)
+	}
+	fmt.Fprintln(w, separator)
+}
+
+func writeCSV(w io.Writer, result *QueryResult) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(result.Columns); err != nil {
+		return err
+	}
+	for _, row := range result.Rows {
+		record := make([]string, len(row))
+		for i, val := range row {
+			if val != nil {
+				record[i] = formatValue(val)
+			}
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// 以 JSON 数组输出，每行为一个以列名为键的对象，键顺序与查询列顺序一致
+func writeJSON(w io.Writer, result *QueryResult) error {
+	var b bytes.Buffer
+	b.WriteString("[")
+	for r, row := range result.Rows {
+		if r > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString("\n  {")
+		for i, val := range row {
+			if t, ok := val.(time.Time); ok {
+				val = formatValue(t)
+			}
+			key, err := json.Marshal(result.Columns[i])
+			if err != nil {
+				return err
+			}
+			value, err := json.Marshal(val)
+			if err != nil {
+				return err
+			}
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			b.Write(key)
+			b.WriteString(": ")
+			b.Write(value)
+		}
+		b.WriteString("}")
+	}
+	if len(result.Rows) > 0 {
+		b.WriteString("\n")
+	}
+	b.WriteString("]\n")
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+func writeResult(w io.Writer, format string, result *QueryResult) error {
+	switch format {
+	case FormatCSV:
+		return writeCSV(w, result)
+	case FormatJSON:
+		return writeJSON(w, result)
+	default:
+		writeTable(w, result)
+		return nil
+	}
+}
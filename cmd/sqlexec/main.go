@@ -1,7 +1,7 @@
 package main
 
 import (
-	"bufio"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
@@ -34,6 +34,15 @@ type Config struct {
 	} `json:"db"`
 }
 
+// 当前配置环境
+func currentEnv() string {
+	env := os.Getenv("HRMS_ENV")
+	if env == "" {
+		env = "dev"
+	}
+	return env
+}
+
 // 初始化配置
 func InitConfig() (*Config, error) {
 	config := &Config{}
@@ -99,10 +108,9 @@ func InitDB(config *Config, dbName string) (*gorm.DB, error) {
 			dbPath = filepath.Join(".", "data", dbName+".db")
 		}
 
-		// 确保目录存在
-		dir := filepath.Dir(dbPath)
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			return nil, fmt.Errorf("创建SQLite数据库目录失败: %v", err)
+		// 数据库文件必须已存在，新建数据库请使用 createdb 工具
+		if _, err := os.Stat(dbPath); err != nil {
+			return nil, fmt.Errorf("SQLite数据库文件不存在: %s", dbPath)
 		}
 
 		db, err = gorm.Open(sqlite.Dialector{
@@ -112,7 +120,7 @@ func InitDB(config *Config, dbName string) (*gorm.DB, error) {
 			NamingStrategy: schema.NamingStrategy{
 				SingularTable: true, // 全局禁止表名复数
 			},
-			Logger: logger.Default.LogMode(logger.Info),
+			Logger: logger.Default.LogMode(logger.Silent),
 		})
 		if err != nil {
 			return nil, fmt.Errorf("SQLite连接失败: %v", err)
@@ -133,7 +141,7 @@ func InitDB(config *Config, dbName string) (*gorm.DB, error) {
 			NamingStrategy: schema.NamingStrategy{
 				SingularTable: true, // 全局禁止表名复数
 			},
-			Logger: logger.Default.LogMode(logger.Info),
+			Logger: logger.Default.LogMode(logger.Silent),
 		})
 		if err != nil {
 			return nil, fmt.Errorf("MySQL连接失败: %v", err)
@@ -144,174 +152,31 @@ func InitDB(config *Config, dbName string) (*gorm.DB, error) {
 	return db, nil
 }
 
-// 执行 SQL 语句
-func ExecuteSQL(db *gorm.DB, sqlStr string) error {
-	sqlStr = strings.TrimSpace(sqlStr)
-	if sqlStr == "" {
-		return nil
-	}
-
-	// 移除末尾的分号
-	sqlStr = strings.TrimSuffix(sqlStr, ";")
-
-	log.Printf("执行 SQL: %s", sqlStr)
-
-	// 判断是否为查询语句
-	upperSQL := strings.ToUpper(sqlStr)
-	if strings.HasPrefix(upperSQL, "SELECT") ||
-		strings.HasPrefix(upperSQL, "SHOW") ||
-		strings.HasPrefix(upperSQL, "DESCRIBE") ||
-		strings.HasPrefix(upperSQL, "DESC") ||
-		strings.HasPrefix(upperSQL, "EXPLAIN") {
-
-		// 查询语句，返回结果
-		rows, err := db.Raw(sqlStr).Rows()
-		if err != nil {
-			return fmt.Errorf("查询执行失败: %v", err)
-		}
-		defer rows.Close()
-
-		// 获取列名
-		columns, err := rows.Columns()
-		if err != nil {
-			return fmt.Errorf("获取列名失败: %v", err)
-		}
-
-		// 打印表头
-		fmt.Printf("\n")
-		for i, col := range columns {
-			if i > 0 {
-				fmt.Printf("\t")
-			}
-			fmt.Printf("%-20s", col)
-		}
-		fmt.Printf("\n")
-
-		// 打印分隔线
-		for i := range columns {
-			if i > 0 {
-				fmt.Printf("\t")
-			}
-			fmt.Printf("%-20s", strings.Repeat("-", 20))
-		}
-		fmt.Printf("\n")
-
-		// 打印数据行
-		values := make([]interface{}, len(columns))
-		valuePtrs := make([]interface{}, len(columns))
-		for i := range columns {
-			valuePtrs[i] = &values[i]
-		}
-
-		rowCount := 0
-		for rows.Next() {
-			err := rows.Scan(valuePtrs...)
-			if err != nil {
-				return fmt.Errorf("扫描行数据失败: %v", err)
-			}
-
-			for i, val := range values {
-				if i > 0 {
-					fmt.Printf("\t")
-				}
-				if val == nil {
-					fmt.Printf("%-20s", "NULL")
-				} else {
-					// 处理字节数组转字符串
-					switch v := val.(type) {
-					case []byte:
-						fmt.Printf("%-20s", string(v))
-					default:
-						fmt.Printf("%-20v", val)
-					}
-				}
-			}
-			fmt.Printf("\n")
-			rowCount++
-		}
-
-		fmt.Printf("\n查询完成，共 %d 行记录\n", rowCount)
-	} else {
-		// 非查询语句（INSERT, UPDATE, DELETE 等）
-		result := db.Exec(sqlStr)
-		if result.Error != nil {
-			return fmt.Errorf("SQL 执行失败: %v", result.Error)
-		}
-
-		fmt.Printf("SQL 执行成功，影响行数: %d\n", result.RowsAffected)
-	}
-
-	return nil
-}
-
-// 从文件读取 SQL 语句
-func ExecuteSQLFromFile(db *gorm.DB, filename string) error {
-	file, err := os.Open(filename)
-	if err != nil {
-		return fmt.Errorf("打开文件失败: %v", err)
-	}
-	defer file.Close()
-
-	scanner := bufio.NewScanner(file)
-	var sqlBuilder strings.Builder
-	lineNum := 0
-
-	for scanner.Scan() {
-		lineNum++
-		line := strings.TrimSpace(scanner.Text())
-
-		// 跳过空行和注释
-		if line == "" || strings.HasPrefix(line, "--") || strings.HasPrefix(line, "#") {
-			continue
-		}
-
-		sqlBuilder.WriteString(line)
-		sqlBuilder.WriteString(" ")
-
-		// 如果行以分号结尾，执行 SQL
-		if strings.HasSuffix(line, ";") {
-			sqlStr := strings.TrimSpace(sqlBuilder.String())
-			if sqlStr != "" {
-				fmt.Printf("\n=== 执行第 %d 行附近的 SQL ===\n", lineNum)
-				if err := ExecuteSQL(db, sqlStr); err != nil {
-					log.Printf("第 %d 行 SQL 执行失败: %v", lineNum, err)
-					return err
-				}
-			}
-			sqlBuilder.Reset()
-		}
-	}
-
-	// 处理最后一条没有分号的 SQL
-	if sqlBuilder.Len() > 0 {
-		sqlStr := strings.TrimSpace(sqlBuilder.String())
-		if sqlStr != "" {
-			fmt.Printf("\n=== 执行文件末尾的 SQL ===\n")
-			if err := ExecuteSQL(db, sqlStr); err != nil {
-				log.Printf("文件末尾 SQL 执行失败: %v", err)
-				return err
-			}
-		}
-	}
-
-	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("读取文件失败: %v", err)
-	}
-
-	return nil
-}
-
 func main() {
 	var (
 		dbName      string
 		sqlStr      string
 		filename    string
+		format      string
+		interactive bool
+		readOnly    bool
+		allBranches bool
+		parallel    int
+		onError     string
+		txPerBranch bool
 		help        bool
 	)
 
-	flag.StringVar(&dbName, "db", "", "指定数据库名称")
+	flag.StringVar(&dbName, "db", "", "指定数据库名称，多个用逗号分隔")
 	flag.StringVar(&sqlStr, "sql", "", "要执行的 SQL 语句")
 	flag.StringVar(&filename, "file", "", "包含 SQL 语句的文件路径")
+	flag.StringVar(&format, "format", FormatTable, "查询结果输出格式 (table/csv/json)")
+	flag.BoolVar(&interactive, "i", false, "进入交互式终端")
+	flag.BoolVar(&readOnly, "read-only", false, "只读模式，拒绝执行写操作")
+	flag.BoolVar(&allBranches, "all-branches", false, "在配置文件中的所有分公司数据库上执行")
+	flag.IntVar(&parallel, "parallel", 1, "多分公司执行时的并发数，1为顺序执行")
+	flag.StringVar(&onError, "on-error", "stop", "多分公司执行时遇到错误的处理方式 (stop/continue)")
+	flag.BoolVar(&txPerBranch, "tx", false, "每个分公司在一个事务中执行，任一语句失败则回滚该分公司")
 	flag.BoolVar(&help, "h", false, "显示帮助信息")
 	flag.BoolVar(&help, "help", false, "显示帮助信息")
 
@@ -326,28 +191,44 @@ func main() {
 		fmt.Println()
 		fmt.Println("选项:")
 		fmt.Println("  -h, --help         显示帮助信息")
-		fmt.Println("  -db string         指定数据库名称（必需）")
+		fmt.Println("  -db string         指定数据库名称，多个用逗号分隔（与 --all-branches 二选一）")
 		fmt.Println("  -sql string        要执行的 SQL 语句")
 		fmt.Println("  -file string       包含 SQL 语句的文件路径")
+		fmt.Println("  -i                 进入交互式终端")
+		fmt.Println("  -format string     查询结果输出格式 table/csv/json（默认: table）")
+		fmt.Println("  --read-only        只读模式，拒绝执行写操作")
+		fmt.Println("  --all-branches     在配置文件中的所有分公司数据库上执行")
+		fmt.Println("  -parallel int      多分公司执行时的并发数，1为顺序执行（默认: 1）")
+		fmt.Println("  -on-error string   遇到错误时 stop 停止启动后续分公司，continue 继续执行（默认: stop）")
+		fmt.Println("  -tx                每个分公司在一个事务中执行，任一语句失败则回滚该分公司")
 		fmt.Println()
 		fmt.Println("示例:")
-		fmt.Println("  # MySQL 示例")
 		fmt.Println("  sqlexec -db hrms_C001 -sql \"SELECT * FROM staff LIMIT 10\"")
+		fmt.Println("  sqlexec -db hrms_C001 -sql \"SELECT * FROM staff\" -format csv > staff.csv")
 		fmt.Println("  sqlexec -db hrms_C001 -file ./sql/query.sql")
 		fmt.Println("  sqlexec -db hrms_C001 -i")
-		fmt.Println()
-		fmt.Println("  # SQLite 示例（需要设置 HRMS_ENV=sqlite）")
-		fmt.Println("  HRMS_ENV=sqlite sqlexec -db hrms_C001 -sql \"SELECT * FROM staff LIMIT 10\"")
-		fmt.Println("  HRMS_ENV=sqlite sqlexec -db hrms_C001 -i")
+		fmt.Println("  HRMS_ENV=prod sqlexec -db hrms_C001 -i --read-only")
+		fmt.Println("  sqlexec --all-branches -file ./sql/hotfix.sql -tx")
+		fmt.Println("  sqlexec -db hrms_C001,hrms_C002 -sql \"SELECT COUNT(*) FROM staff\" -parallel 4 -on-error continue")
 		fmt.Println()
 		fmt.Println("环境变量:")
-		fmt.Println("  HRMS_ENV           指定配置环境 (dev/test/prod/self/sqlite，默认: self)")
+		fmt.Println("  HRMS_ENV           指定配置环境 (dev/test/prod/self，默认: dev)")
+		fmt.Println()
+		fmt.Println("安全说明:")
+		fmt.Println("  - prod 环境下执行不带 WHERE 条件的 DELETE/UPDATE 语句需要输入 yes 确认")
+		fmt.Println("  - 事务可通过 BEGIN/COMMIT/ROLLBACK 语句或交互终端中的 \\begin/\\commit/\\rollback 控制")
 		return
 	}
 
 	// 检查必需参数
-	if dbName == "" {
-		log.Fatal("错误: 必须指定数据库名称，使用 -db 参数")
+	if dbName == "" && !allBranches {
+		log.Fatal("错误: 必须指定数据库名称，使用 -db 或 --all-branches 参数")
+	}
+	if !validFormat(format) {
+		log.Fatalf("错误: 不支持的输出格式: %s", format)
+	}
+	if onError != "stop" && onError != "continue" {
+		log.Fatalf("错误: -on-error 只支持 stop 或 continue")
 	}
 
 	// 初始化配置
@@ -356,6 +237,62 @@ func main() {
 		log.Fatalf("配置初始化失败: %v", err)
 	}
 
+	// 解析目标数据库列表
+	if allBranches {
+		dbName = config.Db.DbName
+	}
+	var dbNames []string
+	for _, name := range strings.Split(dbName, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			dbNames = append(dbNames, name)
+		}
+	}
+	if len(dbNames) == 0 {
+		log.Fatal("错误: 未找到有效的数据库名称")
+	}
+
+	if len(dbNames) > 1 || allBranches || txPerBranch {
+		if interactive {
+			log.Fatal("错误: 交互式终端只支持单个数据库")
+		}
+		if sqlStr == "" && filename == "" {
+			log.Fatal("错误: 多分公司执行必须指定 -sql 或 -file 参数")
+		}
+		stmts, err := loadStatements(sqlStr, filename)
+		if err != nil {
+			log.Fatalf("读取 SQL 失败: %v", err)
+		}
+		opts := &BranchOptions{
+			Parallel:    parallel,
+			StopOnError: onError == "stop",
+			Transaction: txPerBranch,
+			Format:      format,
+			ReadOnly:    readOnly,
+		}
+		if err := checkBranchStatements(stmts, opts); err != nil {
+			log.Fatalf("错误: %v", err)
+		}
+		// 生产环境的危险语句在执行前统一确认一次
+		if currentEnv() == "prod" && !readOnly {
+			confirmer := NewExecutor(nil, format)
+			for _, stmt := range stmts {
+				if isUnboundedWrite(strings.ToUpper(strings.TrimSpace(stmt))) {
+					fmt.Printf("即将在 %d 个数据库上执行: %s\n", len(dbNames), strings.Join(dbNames, ", "))
+					if err := confirmer.confirmDangerous(stmt); err != nil {
+						log.Fatalf("SQL 执行失败: %v", err)
+					}
+				}
+			}
+		}
+		results := runBranches(config, dbNames, stmts, opts)
+		if failed := printSummary(results, len(stmts)); failed > 0 {
+			log.Fatalf("共 %d 个数据库执行失败", failed)
+		}
+		log.Println("操作完成")
+		return
+	}
+	dbName = dbNames[0]
+
 	// 连接数据库
 	db, err := InitDB(config, dbName)
 	if err != nil {
@@ -364,22 +301,34 @@ func main() {
 
 	log.Printf("成功连接到数据库: %s", dbName)
 
+	exec := NewExecutor(db, format)
+	exec.readOnly = readOnly
+	exec.confirm = currentEnv() == "prod"
+
 	// 根据参数执行不同操作
+	if interactive {
+		NewShell(exec, dbName).Run()
+		return
+	}
 	if filename != "" {
 		// 从文件执行 SQL
-		if err := ExecuteSQLFromFile(db, filename); err != nil {
-			log.Fatalf("从文件执行 SQL 失败: %v", err)
-		}
+		err = exec.ExecuteFile(filename)
 	} else if sqlStr != "" {
 		// 执行单条 SQL
-		if err := ExecuteSQL(db, sqlStr); err != nil {
-			log.Fatalf("SQL 执行失败: %v", err)
-		}
+		err = exec.Execute(sqlStr)
 	} else {
 		fmt.Println("错误: 必须指定 -sql、-file 或 -i 参数之一")
 		fmt.Println("使用 -h 查看帮助信息")
 		os.Exit(1)
 	}
+	// 文件中未提交的事务自动回滚，视为执行失败
+	if err == nil && exec.InTransaction() {
+		err = errors.New("脚本中开启的事务未提交，已回滚")
+	}
+	exec.Close()
+	if err != nil {
+		log.Fatalf("SQL 执行失败: %v", err)
+	}
 
 	log.Println("操作完成")
 }
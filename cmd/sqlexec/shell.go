@@ -0,0 +1,206 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// 历史记录最多保留条数
+const maxHistory = 1000
+
+// 交互式 SQL 终端
+type Shell struct {
+	exec        *Executor
+	dbName      string
+	history     []string
+	historyFile string
+}
+
+func NewShell(exec *Executor, dbName string) *Shell {
+	exec.quiet = true
+	shell := &Shell{exec: exec, dbName: dbName}
+	if home, err := os.UserHomeDir(); err == nil {
+		shell.historyFile = filepath.Join(home, ".hrms_sqlexec_history")
+	}
+	shell.loadHistory()
+	return shell
+}
+
+// 读取历史记录文件，每行一条语句
+func (s *Shell) loadHistory() {
+	if s.historyFile == "" {
+		return
+	}
+	data, err := os.ReadFile(s.historyFile)
+	if err != nil {
+		return
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			s.history = append(s.history, line)
+		}
+	}
+	if len(s.history) > maxHistory {
+		s.history = s.history[len(s.history)-maxHistory:]
+	}
+}
+
+func (s *Shell) addHistory(stmt string) {
+	stmt = strings.Join(strings.Fields(stmt), " ")
+	if stmt == "" || (len(s.history) > 0 && s.history[len(s.history)-1] == stmt) {
+		return
+	}
+	s.history = append(s.history, stmt)
+	if s.historyFile == "" {
+		return
+	}
+	file, err := os.OpenFile(s.historyFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	fmt.Fprintln(file, stmt)
+}
+
+func (s *Shell) prompt(continued bool) string {
+	name := s.dbName
+	if s.exec.InTransaction() {
+		name += "*"
+	}
+	if continued {
+		return strings.Repeat(" ", len(name)-1) + "-> "
+	}
+	return name + "> "
+}
+
+func (s *Shell) printHelp() {
+	fmt.Println("以分号结尾输入 SQL 语句，支持多行输入。元命令:")
+	fmt.Println("  \\q, \\quit           退出")
+	fmt.Println("  \\h, \\help           显示帮助信息")
+	fmt.Println("  \\format [格式]       查看或切换输出格式 (table/csv/json)")
+	fmt.Println("  \\begin               开启事务（也可输入 BEGIN;）")
+	fmt.Println("  \\commit              提交事务（也可输入 COMMIT;）")
+	fmt.Println("  \\rollback            回滚事务（也可输入 ROLLBACK;）")
+	fmt.Println("  \\tables              列出所有表")
+	fmt.Println("  \\history [n]         显示最近 n 条历史记录（默认20）")
+	fmt.Println("  \\redo n              重新执行第 n 条历史记录")
+	fmt.Println("提示符中的 * 表示当前处于事务中，退出时未提交的事务将被回滚")
+}
+
+// 执行元命令，返回 true 表示退出终端
+func (s *Shell) meta(line string) (bool, error) {
+	fields := strings.Fields(line)
+	cmd, args := fields[0], fields[1:]
+	switch cmd {
+	case `\q`, `\quit`:
+		return true, nil
+	case `\h`, `\help`, `\?`:
+		s.printHelp()
+	case `\format`:
+		if len(args) == 0 {
+			fmt.Printf("当前输出格式: %s\n", s.exec.format)
+			return false, nil
+		}
+		if !validFormat(args[0]) {
+			return false, fmt.Errorf("不支持的输出格式: %s", args[0])
+		}
+		s.exec.format = args[0]
+		fmt.Printf("输出格式已切换为: %s\n", args[0])
+	case `\begin`:
+		return false, s.exec.Begin()
+	case `\commit`:
+		return false, s.exec.Commit()
+	case `\rollback`:
+		return false, s.exec.Rollback()
+	case `\tables`:
+		tables, err := s.exec.conn().Migrator().GetTables()
+		if err != nil {
+			return false, fmt.Errorf("获取表列表失败: %v", err)
+		}
+		rows := make([][]interface{}, 0, len(tables))
+		for _, table := range tables {
+			rows = append(rows, []interface{}{table})
+		}
+		return false, writeResult(s.exec.out, s.exec.format, &QueryResult{Columns: []string{"table"}, Rows: rows})
+	case `\history`:
+		n := 20
+		if len(args) > 0 {
+			if v, err := strconv.Atoi(args[0]); err == nil && v > 0 {
+				n = v
+			}
+		}
+		start := len(s.history) - n
+		if start < 0 {
+			start = 0
+		}
+		for i := start; i < len(s.history); i++ {
+			fmt.Printf("%5d  %s\n", i+1, s.history[i])
+		}
+	case `\redo`:
+		if len(args) == 0 {
+			return false, fmt.Errorf("用法: \\redo n")
+		}
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 1 || n > len(s.history) {
+			return false, fmt.Errorf("历史记录编号无效: %s", args[0])
+		}
+		stmt := s.history[n-1]
+		fmt.Println(stmt)
+		s.addHistory(stmt)
+		return false, s.exec.Execute(stmt)
+	default:
+		return false, fmt.Errorf("未知命令: %s，输入 \\h 查看帮助", cmd)
+	}
+	return false, nil
+}
+
+// 运行交互式终端，直到用户退出或输入结束
+func (s *Shell) Run() {
+	defer s.exec.Close()
+
+	fmt.Printf("已连接到数据库 %s，输入 \\h 查看帮助，\\q 退出\n", s.dbName)
+	if s.exec.readOnly {
+		fmt.Println("当前为只读模式，写操作将被拒绝")
+	}
+	// 与执行器共用同一个输入流，危险语句确认时才能读到用户输入
+	var sqlBuilder strings.Builder
+	for {
+		fmt.Print(s.prompt(sqlBuilder.Len() > 0))
+		input, err := s.exec.in.ReadString('\n')
+		if err != nil && input == "" {
+			fmt.Println()
+			return
+		}
+		line := strings.TrimSpace(input)
+		if sqlBuilder.Len() == 0 {
+			if line == "" || strings.HasPrefix(line, "--") {
+				continue
+			}
+			// 元命令只在语句开头生效
+			if strings.HasPrefix(line, `\`) {
+				quit, err := s.meta(line)
+				if err != nil {
+					fmt.Printf("错误: %v\n", err)
+				}
+				if quit {
+					return
+				}
+				continue
+			}
+		}
+		sqlBuilder.WriteString(line)
+		sqlBuilder.WriteString(" ")
+		if !strings.HasSuffix(line, ";") {
+			continue
+		}
+		stmt := strings.TrimSpace(sqlBuilder.String())
+		sqlBuilder.Reset()
+		s.addHistory(stmt)
+		if err := s.exec.Execute(stmt); err != nil {
+			fmt.Printf("错误: %v\n", err)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"fmt"
+	"hrms/model"
+	"hrms/service"
+	"log"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 查询个人信息访问日志，仅超级管理员可用
+func AccessLogQuery(c *gin.Context) {
+	// 参数绑定
+	var query model.AccessLogQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		staffImportError(c, "AccessLogQuery", err)
+		return
+	}
+	start, limit := service.AcceptPage(c)
+	// 业务处理
+	logs, total, err := service.GetAccessLogs(c, &query, start, limit)
+	if err != nil {
+		staffImportError(c, "AccessLogQuery", err)
+		return
+	}
+	code := 2000
+	if total == 0 {
+		code = 2001
+	}
+	c.JSON(200, gin.H{
+		"status": code,
+		"total":  total,
+		"msg":    logs,
+	})
+}
+
+// 以 CSV 格式导出个人信息访问日志，筛选条件与查询接口一致
+func AccessLogExport(c *gin.Context) {
+	var query model.AccessLogQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		staffImportError(c, "AccessLogExport", err)
+		return
+	}
+	// 先校验权限及筛选条件，开始写出文件后无法再返回错误信息
+	if err := service.CheckAccessLogExport(c, &query); err != nil {
+		staffImportError(c, "AccessLogExport", err)
+		return
+	}
+	fileName := fmt.Sprintf("access_log_%v.csv", time.Now().Format("20060102150405"))
+	c.Header("Content-Disposition", "attachment; filename="+fileName)
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	if err := service.WriteAccessLogCSV(c, &query, c.Writer); err != nil {
+		log.Printf("[AccessLogExport] err = %v", err)
+	}
+}
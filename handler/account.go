@@ -9,6 +9,7 @@ import (
 	"log"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -145,6 +146,16 @@ func Login(c *gin.Context) {
 		return
 	}
 
+	// 离职类状态生效后禁止登录
+	if staff.HasLeft(time.Now()) {
+		log.Printf("[handler.Login] staff has left, user = %v", loginR)
+		c.JSON(200, gin.H{
+			"status": 2002,
+			"result": "该账号已停用",
+		})
+		return
+	}
+
 	log.Printf("[handler.Login] user login success, user = %v", loginR)
 	// set cookie user_cookie=角色_工号_分公司ID_员工姓名(base64编码)
 	c.SetCookie("user_cookie", fmt.Sprintf("%v_%v_%v_%v", loginDb.UserType, loginDb.StaffId, loginR.BranchId,
@@ -104,6 +104,7 @@ func GetCandidateByStaffId(c *gin.Context) {
 		})
 		return
 	}
+	maskCandidates(c, list)
 	c.JSON(200, gin.H{
 		"status": 2000,
 		"total":  total,
@@ -130,6 +131,7 @@ func GetCandidateByName(c *gin.Context) {
 		})
 		return
 	}
+	maskCandidates(c, list)
 	c.JSON(200, gin.H{
 		"status": 2000,
 		"total":  total,
@@ -174,3 +176,17 @@ func SetCandidateAcceptById(c *gin.Context) {
 		"status": 2000,
 	})
 }
+
+// 按查看人权限对候选人邮箱脱敏
+func maskCandidates(c *gin.Context, list []*model.Candidate) {
+	policy, err := service.NewMaskPolicy(c)
+	if err != nil {
+		for _, candidate := range list {
+			candidate.Email = service.MaskSensitive(model.SensitiveEmail, candidate.Email)
+		}
+		return
+	}
+	for _, candidate := range list {
+		policy.MaskCandidate(candidate)
+	}
+}
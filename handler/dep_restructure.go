@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"hrms/model"
+	"hrms/resource"
+	"hrms/service"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 预览部门调整
+func DepRestructurePreview(c *gin.Context) {
+	// 参数绑定
+	var dto model.DepRestructureDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		log.Printf("[DepRestructurePreview] err = %v", err)
+		c.JSON(200, gin.H{
+			"status": 5001,
+			"result": err.Error(),
+		})
+		return
+	}
+	// 业务处理
+	preview, err := service.PreviewDepRestructure(c, &dto)
+	if err != nil {
+		if err == resource.ErrUnauthorized {
+			c.JSON(http.StatusUnauthorized, gin.H{"status": 401, "message": "Unauthorized"})
+			return
+		}
+		if err == resource.ErrForbidden {
+			c.JSON(http.StatusForbidden, gin.H{"status": 403, "message": "Forbidden"})
+			return
+		}
+		log.Printf("[DepRestructurePreview] err = %v", err)
+		c.JSON(200, gin.H{
+			"status": 5002,
+			"result": err.Error(),
+		})
+		return
+	}
+	c.JSON(200, gin.H{
+		"status": 2000,
+		"msg":    preview,
+	})
+}
+
+// 执行部门调整
+func DepRestructureExecute(c *gin.Context) {
+	// 参数绑定
+	var dto model.DepRestructureDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		log.Printf("[DepRestructureExecute] err = %v", err)
+		c.JSON(200, gin.H{
+			"status": 5001,
+			"result": err.Error(),
+		})
+		return
+	}
+	// 业务处理
+	record, err := service.ExecuteDepRestructure(c, &dto)
+	if err != nil {
+		if err == resource.ErrUnauthorized {
+			c.JSON(http.StatusUnauthorized, gin.H{"status": 401, "message": "Unauthorized"})
+			return
+		}
+		if err == resource.ErrForbidden {
+			c.JSON(http.StatusForbidden, gin.H{"status": 403, "message": "Forbidden"})
+			return
+		}
+		log.Printf("[DepRestructureExecute] err = %v", err)
+		c.JSON(200, gin.H{
+			"status": 5002,
+			"result": err.Error(),
+		})
+		return
+	}
+	c.JSON(200, gin.H{
+		"status": 2000,
+		"msg":    record,
+	})
+}
+
+// 查询部门调整记录，dep_id 为 all 时查询全部
+func DepRestructureHistory(c *gin.Context) {
+	// 参数绑定
+	depId := c.Param("dep_id")
+	// 业务处理
+	records, err := service.GetDepRestructureByDepId(c, depId)
+	if err != nil {
+		if err == resource.ErrUnauthorized {
+			c.JSON(http.StatusUnauthorized, gin.H{"status": 401, "message": "Unauthorized"})
+			return
+		}
+		log.Printf("[DepRestructureHistory] err = %v", err)
+		c.JSON(200, gin.H{
+			"status": 5002,
+			"result": err.Error(),
+		})
+		return
+	}
+	c.JSON(200, gin.H{
+		"status": 2000,
+		"total":  len(records),
+		"msg":    records,
+	})
+}
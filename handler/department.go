@@ -42,6 +42,24 @@ func DepartCreate(c *gin.Context) {
 		DepId:       service.RandomID("dep"),
 		DepDescribe: departmentCreateDTO.DepDescribe,
 		DepName:     departmentCreateDTO.DepName,
+		ParentDepId: departmentCreateDTO.ParentDepId,
+		HeadStaffId: departmentCreateDTO.HeadStaffId,
+		CostCenter:  departmentCreateDTO.CostCenter,
+	}
+	var err error
+	if departmentCreate.ValidFrom, err = service.ParseDepDate(departmentCreateDTO.ValidFromStr); err == nil {
+		departmentCreate.ValidTo, err = service.ParseDepDate(departmentCreateDTO.ValidToStr)
+	}
+	if err == nil {
+		err = service.ValidateDepartment(db, &departmentCreate)
+	}
+	if err != nil {
+		log.Printf("[DepartCreate] err = %v", err)
+		c.JSON(200, gin.H{
+			"status": 5001,
+			"msg":    err.Error(),
+		})
+		return
 	}
 	if result = db.Create(&departmentCreate); result.Error != nil {
 		result.Rollback()
@@ -81,13 +99,73 @@ func DepartEdit(c *gin.Context) {
 		c.JSON(http.StatusUnauthorized, gin.H{"status": 401, "message": "Unauthorized"})
 		return
 	}
-	db.Model(&model.Department{}).Where("dep_id = ?", departmentEditDTO.DepId).
-		Updates(&model.Department{DepDescribe: departmentEditDTO.DepDescribe, DepName: departmentEditDTO.DepName})
+	var dep model.Department
+	db.Where("dep_id = ?", departmentEditDTO.DepId).Find(&dep)
+	if dep.ID == 0 {
+		// 部门不存在时与原有行为保持一致，不做修改
+		c.JSON(200, gin.H{
+			"status": 2000,
+		})
+		return
+	}
+	updates, err := buildDepartEditUpdates(db, &dep, &departmentEditDTO)
+	if err != nil {
+		log.Printf("[DepartEdit] err = %v", err)
+		c.JSON(200, gin.H{
+			"status": 5001,
+			"msg":    err.Error(),
+		})
+		return
+	}
+	if err := db.Model(&model.Department{}).Where("id = ?", dep.ID).Updates(updates).Error; err != nil {
+		log.Printf("[DepartEdit] err = %v", err)
+		c.JSON(500, gin.H{
+			"status": 5001,
+			"msg":    err.Error(),
+		})
+		return
+	}
 	c.JSON(200, gin.H{
 		"status": 2000,
 	})
 }
 
+// 根据编辑参数生成需要更新的字段，未传入的层级字段保持不变
+func buildDepartEditUpdates(db *gorm.DB, dep *model.Department, dto *model.DepartmentEditDTO) (map[string]interface{}, error) {
+	updates := map[string]interface{}{
+		"dep_describe": dto.DepDescribe,
+		"dep_name":     dto.DepName,
+	}
+	if dto.ParentDepId != nil {
+		dep.ParentDepId = *dto.ParentDepId
+		updates["parent_dep_id"] = dep.ParentDepId
+	}
+	if dto.HeadStaffId != nil {
+		dep.HeadStaffId = *dto.HeadStaffId
+		updates["head_staff_id"] = dep.HeadStaffId
+	}
+	if dto.CostCenter != nil {
+		updates["cost_center"] = *dto.CostCenter
+	}
+	var err error
+	if dto.ValidFromStr != nil {
+		if dep.ValidFrom, err = service.ParseDepDate(*dto.ValidFromStr); err != nil {
+			return nil, err
+		}
+		updates["valid_from"] = dep.ValidFrom
+	}
+	if dto.ValidToStr != nil {
+		if dep.ValidTo, err = service.ParseDepDate(*dto.ValidToStr); err != nil {
+			return nil, err
+		}
+		updates["valid_to"] = dep.ValidTo
+	}
+	if err = service.ValidateDepartment(db, dep); err != nil {
+		return nil, err
+	}
+	return updates, nil
+}
+
 func DepartQuery(c *gin.Context) {
 	var total int64 = 1
 	// 分页
@@ -113,10 +191,18 @@ func DepartQuery(c *gin.Context) {
 		}
 		// 总记录数
 		db.Model(&model.Department{}).Count(&total)
+		depVOs, err := service.ConvertDepartment2VO(db, deps)
+		if err != nil {
+			c.JSON(500, gin.H{
+				"status": 5001,
+				"msg":    err.Error(),
+			})
+			return
+		}
 		c.JSON(200, gin.H{
 			"status": code,
 			"total":  total,
-			"msg":    deps,
+			"msg":    depVOs,
 		})
 		return
 	}
@@ -126,10 +212,18 @@ func DepartQuery(c *gin.Context) {
 		code = 2001
 	}
 	total = int64(len(deps))
+	depVOs, err := service.ConvertDepartment2VO(db, deps)
+	if err != nil {
+		c.JSON(500, gin.H{
+			"status": 5001,
+			"msg":    err.Error(),
+		})
+		return
+	}
 	c.JSON(200, gin.H{
 		"status": code,
 		"total":  total,
-		"msg":    deps,
+		"msg":    depVOs,
 	})
 }
 
@@ -140,7 +234,23 @@ func DepartDel(c *gin.Context) {
 		c.JSON(http.StatusUnauthorized, gin.H{"status": 401, "message": "Unauthorized"})
 		return
 	}
-	if err := db.Where("dep_id = ?", depId).Delete(&model.Department{}).Error; err != nil {
+	// 仍有员工或下级部门时不允许删除，检查与删除在同一事务中执行
+	var checkErr error
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if checkErr = service.CheckDepartmentDeletable(tx, depId); checkErr != nil {
+			return checkErr
+		}
+		return tx.Where("dep_id = ?", depId).Delete(&model.Department{}).Error
+	})
+	if checkErr != nil {
+		log.Printf("[DepartDel] err = %v", checkErr)
+		c.JSON(200, gin.H{
+			"status": 5001,
+			"msg":    checkErr.Error(),
+		})
+		return
+	}
+	if err != nil {
 		log.Printf("[DepartDel] err = %v", err)
 		c.JSON(500, gin.H{
 			"status": 5001,
@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"hrms/model"
+	"hrms/resource"
+	"hrms/service"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func milestoneError(c *gin.Context, name string, err error) {
+	if err == resource.ErrUnauthorized {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": 401, "message": "Unauthorized"})
+		return
+	}
+	log.Printf("[%v] err = %v", name, err)
+	c.JSON(200, gin.H{
+		"status": 5002,
+		"result": err.Error(),
+	})
+}
+
+// 本人的生日、司龄纪念日及退休提醒退订情况
+func MilestoneOptOutQuery(c *gin.Context) {
+	optOuts, err := service.GetMilestoneOptOuts(c)
+	if err != nil {
+		milestoneError(c, "MilestoneOptOutQuery", err)
+		return
+	}
+	c.JSON(200, gin.H{
+		"status": 2000,
+		"msg":    optOuts,
+	})
+}
+
+func MilestoneOptOutSet(c *gin.Context) {
+	// 参数绑定
+	var dto model.MilestoneOptOutDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		log.Printf("[MilestoneOptOutSet] err = %v", err)
+		c.JSON(200, gin.H{
+			"status": 5001,
+			"result": err.Error(),
+		})
+		return
+	}
+	// 业务处理
+	if err := service.SetMilestoneOptOuts(c, &dto); err != nil {
+		milestoneError(c, "MilestoneOptOutSet", err)
+		return
+	}
+	c.JSON(200, gin.H{
+		"status": 2000,
+	})
+}
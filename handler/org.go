@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"fmt"
+	"hrms/model"
+	"hrms/resource"
+	"hrms/service"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func orgError(c *gin.Context, name string, err error) {
+	if err == resource.ErrUnauthorized {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": 401, "message": "Unauthorized"})
+		return
+	}
+	if err == service.ErrOrgStaffNotExist {
+		c.JSON(200, gin.H{
+			"status": 2001,
+			"result": err.Error(),
+		})
+		return
+	}
+	log.Printf("[%v] err = %v", name, err)
+	c.JSON(200, gin.H{
+		"status": 5002,
+		"result": err.Error(),
+	})
+}
+
+// 层数参数，不传或为0时不限层数
+func acceptDepth(c *gin.Context) (int, error) {
+	depthStr := c.DefaultQuery("depth", "0")
+	depth, err := strconv.Atoi(depthStr)
+	if err != nil || depth < 0 {
+		return 0, fmt.Errorf("层数参数错误: %v", depthStr)
+	}
+	return depth, nil
+}
+
+// 查询组织架构树，staff_id 为 all 时返回整个组织
+func OrgTree(c *gin.Context) {
+	// 参数绑定
+	staffId := c.Param("staff_id")
+	depth, err := acceptDepth(c)
+	if err != nil {
+		c.JSON(200, gin.H{
+			"status": 5001,
+			"result": err.Error(),
+		})
+		return
+	}
+	// 业务处理
+	nodes, err := service.GetOrgTree(c, staffId, depth)
+	if err != nil {
+		orgError(c, "OrgTree", err)
+		return
+	}
+	c.JSON(200, gin.H{
+		"status": 2000,
+		"total":  len(nodes),
+		"msg":    nodes,
+	})
+}
+
+// 查询员工到最高负责人的汇报链
+func OrgChain(c *gin.Context) {
+	// 参数绑定
+	staffId := c.Param("staff_id")
+	// 业务处理
+	chain, err := service.GetManagementChain(c, staffId)
+	if err != nil {
+		orgError(c, "OrgChain", err)
+		return
+	}
+	c.JSON(200, gin.H{
+		"status": 2000,
+		"total":  len(chain),
+		"msg":    chain,
+	})
+}
+
+// 查询管理幅度统计，staff_id 为 all 时统计整个组织
+func OrgSpan(c *gin.Context) {
+	// 参数绑定
+	staffId := c.Param("staff_id")
+	// 业务处理
+	stats, err := service.GetOrgSpanStats(c, staffId)
+	if err != nil {
+		orgError(c, "OrgSpan", err)
+		return
+	}
+	c.JSON(200, gin.H{
+		"status": 2000,
+		"msg":    stats,
+	})
+}
+
+// 查询汇报关系中的环路
+func OrgCycles(c *gin.Context) {
+	cycles, err := service.GetOrgCycles(c)
+	if err != nil {
+		orgError(c, "OrgCycles", err)
+		return
+	}
+	if cycles == nil {
+		cycles = [][]string{}
+	}
+	c.JSON(200, gin.H{
+		"status": 2000,
+		"total":  len(cycles),
+		"msg":    cycles,
+	})
+}
+
+// 导出组织架构树，format 为 json 或 dot
+func OrgExport(c *gin.Context) {
+	// 参数绑定
+	staffId := c.Param("staff_id")
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "dot" {
+		c.JSON(200, gin.H{
+			"status": 5001,
+			"result": "导出格式只支持 json 或 dot",
+		})
+		return
+	}
+	depth, err := acceptDepth(c)
+	if err != nil {
+		c.JSON(200, gin.H{
+			"status": 5001,
+			"result": err.Error(),
+		})
+		return
+	}
+	// 业务处理
+	nodes, err := service.GetOrgTree(c, staffId, depth)
+	if err != nil {
+		orgError(c, "OrgExport", err)
+		return
+	}
+	fileName := fmt.Sprintf("org_%v.%v", staffId, format)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%v", fileName))
+	if format == "dot" {
+		c.Data(200, "text/vnd.graphviz; charset=utf-8", []byte(service.OrgTreeToDot(nodes)))
+		return
+	}
+	if nodes == nil {
+		nodes = []*model.OrgNode{}
+	}
+	c.IndentedJSON(200, nodes)
+}
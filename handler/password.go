@@ -38,6 +38,11 @@ func PasswordQuery(c *gin.Context) {
 	}
 	db.Where("staff_id != 'root' and staff_id != 'admin'").Model(&model.Staff{}).Count(&total)
 	psws = result
+	staffIds := make([]string, 0, len(psws))
+	for _, psw := range psws {
+		staffIds = append(staffIds, psw.StaffId)
+	}
+	service.RecordAccess(c, model.AccessResourcePassword, "", staffIds...)
 	c.JSON(http.StatusOK, gin.H{
 		"status": code,
 		"total":  total,
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"hrms/model"
+	"hrms/resource"
+	"hrms/service"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func probationError(c *gin.Context, name string, err error) {
+	switch err {
+	case resource.ErrUnauthorized:
+		c.JSON(http.StatusUnauthorized, gin.H{"status": 401, "message": "Unauthorized"})
+	case resource.ErrForbidden:
+		c.JSON(http.StatusForbidden, gin.H{"status": 403, "message": "Forbidden"})
+	case service.ErrStaffProbationNotExist:
+		c.JSON(200, gin.H{
+			"status": 2001,
+			"result": err.Error(),
+		})
+	default:
+		log.Printf("[%v] err = %v", name, err)
+		c.JSON(200, gin.H{
+			"status": 5002,
+			"result": err.Error(),
+		})
+	}
+}
+
+// 上级填写试用期评估
+func ProbationEvaluate(c *gin.Context) {
+	// 参数绑定
+	var dto model.ProbationEvaluateDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		log.Printf("[ProbationEvaluate] err = %v", err)
+		c.JSON(200, gin.H{
+			"status": 5001,
+			"result": err.Error(),
+		})
+		return
+	}
+	// 业务处理
+	if err := service.EvaluateProbation(c, &dto); err != nil {
+		probationError(c, "ProbationEvaluate", err)
+		return
+	}
+	c.JSON(200, gin.H{
+		"status": 2000,
+	})
+}
+
+// 人事确认转正、延长试用期或不予录用
+func ProbationDecide(c *gin.Context) {
+	// 参数绑定
+	var dto model.ProbationDecideDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		log.Printf("[ProbationDecide] err = %v", err)
+		c.JSON(200, gin.H{
+			"status": 5001,
+			"result": err.Error(),
+		})
+		return
+	}
+	// 业务处理
+	if err := service.DecideProbation(c, &dto); err != nil {
+		probationError(c, "ProbationDecide", err)
+		return
+	}
+	c.JSON(200, gin.H{
+		"status": 2000,
+	})
+}
+
+func ProbationQuery(c *gin.Context) {
+	// 参数绑定
+	staffId := c.Param("staff_id")
+	// 业务处理
+	probations, err := service.GetStaffProbations(c, staffId)
+	if err != nil {
+		probationError(c, "ProbationQuery", err)
+		return
+	}
+	code := 2000
+	if len(probations) == 0 {
+		code = 2001
+	}
+	c.JSON(200, gin.H{
+		"status": code,
+		"total":  len(probations),
+		"msg":    probations,
+	})
+}
+
+// 当前用户待处理的试用期考核
+func ProbationPending(c *gin.Context) {
+	probations, err := service.GetPendingProbations(c)
+	if err != nil {
+		probationError(c, "ProbationPending", err)
+		return
+	}
+	code := 2000
+	if len(probations) == 0 {
+		code = 2001
+	}
+	c.JSON(200, gin.H{
+		"status": code,
+		"total":  len(probations),
+		"msg":    probations,
+	})
+}
@@ -35,8 +35,20 @@ func RankCreate(c *gin.Context) {
 		return
 	}
 	rank := model.Rank{
-		RankId:   service.RandomID("rank"),
-		RankName: rankCreateDto.RankName,
+		RankId:    service.RandomID("rank"),
+		RankName:  rankCreateDto.RankName,
+		Level:     rankCreateDto.Level,
+		JobFamily: rankCreateDto.JobFamily,
+		SalaryMin: rankCreateDto.SalaryMin,
+		SalaryMid: rankCreateDto.SalaryMid,
+		SalaryMax: rankCreateDto.SalaryMax,
+	}
+	if err := service.ValidateRank(&rank); err != nil {
+		c.JSON(200, gin.H{
+			"status": 5001,
+			"msg":    err.Error(),
+		})
+		return
 	}
 	db.Create(&rank)
 	c.JSON(200, gin.H{
@@ -60,8 +72,44 @@ func RankEdit(c *gin.Context) {
 		c.JSON(http.StatusUnauthorized, gin.H{"status": 401, "message": "Unauthorized"})
 		return
 	}
-	db.Model(&model.Rank{}).Where("rank_id = ?", rankEditDTO.RankId).
-		Updates(&model.Rank{RankName: rankEditDTO.RankName})
+	var rank model.Rank
+	if err := db.Where("rank_id = ?", rankEditDTO.RankId).First(&rank).Error; err != nil {
+		c.JSON(200, gin.H{
+			"status": 2001,
+			"msg":    "职级不存在",
+		})
+		return
+	}
+	// 未填写的等级、职族及薪资带宽保持不变
+	rank.RankName = rankEditDTO.RankName
+	for field, value := range map[*int64]*int64{
+		&rank.Level:     rankEditDTO.Level,
+		&rank.SalaryMin: rankEditDTO.SalaryMin,
+		&rank.SalaryMid: rankEditDTO.SalaryMid,
+		&rank.SalaryMax: rankEditDTO.SalaryMax,
+	} {
+		if value != nil {
+			*field = *value
+		}
+	}
+	if rankEditDTO.JobFamily != nil {
+		rank.JobFamily = *rankEditDTO.JobFamily
+	}
+	if err := service.ValidateRank(&rank); err != nil {
+		c.JSON(200, gin.H{
+			"status": 5001,
+			"msg":    err.Error(),
+		})
+		return
+	}
+	db.Model(&model.Rank{}).Where("id = ?", rank.ID).Updates(map[string]interface{}{
+		"rank_name":  rank.RankName,
+		"level":      rank.Level,
+		"job_family": rank.JobFamily,
+		"salary_min": rank.SalaryMin,
+		"salary_mid": rank.SalaryMid,
+		"salary_max": rank.SalaryMax,
+	})
 	c.JSON(200, gin.H{
 		"status": 2000,
 	})
@@ -174,3 +222,54 @@ func isStandardRankId(str string) bool {
 	}
 	return false
 }
+
+// 调整员工职级，按职级等级记为晋升、降级或平调
+func RankChange(c *gin.Context) {
+	var dto model.RankChangeDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		staffImportError(c, "RankChange", err)
+		return
+	}
+	if err := service.ChangeStaffRank(c, &dto); err != nil {
+		staffImportError(c, "RankChange", err)
+		return
+	}
+	c.JSON(200, gin.H{
+		"status": 2000,
+	})
+}
+
+func RankChangeQuery(c *gin.Context) {
+	changes, err := service.GetRankChanges(c, c.Param("staff_id"))
+	if err != nil {
+		staffImportError(c, "RankChangeQuery", err)
+		return
+	}
+	code := 2000
+	if len(changes) == 0 {
+		code = 2001
+	}
+	c.JSON(200, gin.H{
+		"status": code,
+		"total":  len(changes),
+		"msg":    changes,
+	})
+}
+
+// 基本工资超出员工职级薪资带宽的薪资模板
+func SalaryBandCheck(c *gin.Context) {
+	checks, err := service.CheckSalaryBands(c)
+	if err != nil {
+		staffImportError(c, "SalaryBandCheck", err)
+		return
+	}
+	code := 2000
+	if len(checks) == 0 {
+		code = 2001
+	}
+	c.JSON(200, gin.H{
+		"status": code,
+		"total":  len(checks),
+		"msg":    checks,
+	})
+}
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"hrms/resource"
+	"hrms/service"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+func revealError(c *gin.Context, name string, err error) {
+	switch err {
+	case resource.ErrUnauthorized:
+		c.JSON(http.StatusUnauthorized, gin.H{"status": 401, "message": "Unauthorized"})
+	case resource.ErrForbidden:
+		c.JSON(http.StatusForbidden, gin.H{"status": 403, "message": "Forbidden"})
+	case service.ErrRevealRecordNotExist:
+		c.JSON(200, gin.H{
+			"status": 2001,
+			"result": err.Error(),
+		})
+	default:
+		log.Printf("[%v] err = %v", name, err)
+		c.JSON(200, gin.H{
+			"status": 5002,
+			"result": err.Error(),
+		})
+	}
+}
+
+// 查看员工敏感字段明文，fields 为逗号分隔的字段名，为空时返回全部敏感字段
+func StaffReveal(c *gin.Context) {
+	// 参数绑定
+	staffId := c.Param("staff_id")
+	var fields []string
+	for _, field := range strings.Split(c.Query("fields"), ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+	// 业务处理
+	values, err := service.RevealStaffFields(c, staffId, fields)
+	if err != nil {
+		revealError(c, "StaffReveal", err)
+		return
+	}
+	c.JSON(200, gin.H{
+		"status": 2000,
+		"msg":    values,
+	})
+}
+
+// 查看候选人邮箱明文
+func CandidateReveal(c *gin.Context) {
+	// 参数绑定
+	candidateId := c.Param("candidate_id")
+	// 业务处理
+	email, err := service.RevealCandidateEmail(c, candidateId)
+	if err != nil {
+		revealError(c, "CandidateReveal", err)
+		return
+	}
+	c.JSON(200, gin.H{
+		"status": 2000,
+		"msg":    gin.H{"email": email},
+	})
+}
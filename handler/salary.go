@@ -60,9 +60,12 @@ func CreateSalary(c *gin.Context) {
 		})
 		return
 	}
-	c.JSON(200, gin.H{
-		"status": 2000,
-	})
+	// 基本工资超出职级薪资带宽时提示，不影响保存
+	resp := gin.H{"status": 2000}
+	if warning := service.SalaryBandWarning(c, dto.StaffId, dto.Base); warning != "" {
+		resp["warning"] = warning
+	}
+	c.JSON(200, resp)
 }
 
 func UpdateSalaryById(c *gin.Context) {
@@ -90,9 +93,11 @@ func UpdateSalaryById(c *gin.Context) {
 		})
 		return
 	}
-	c.JSON(200, gin.H{
-		"status": 2000,
-	})
+	resp := gin.H{"status": 2000}
+	if warning := service.SalaryBandWarning(c, dto.StaffId, dto.Base); warning != "" {
+		resp["warning"] = warning
+	}
+	c.JSON(200, resp)
 }
 
 func GetSalaryByStaffId(c *gin.Context) {
@@ -114,6 +119,11 @@ func GetSalaryByStaffId(c *gin.Context) {
 		})
 		return
 	}
+	staffIds := make([]string, 0, len(list))
+	for _, salary := range list {
+		staffIds = append(staffIds, salary.StaffId)
+	}
+	service.RecordAccess(c, model.AccessResourceSalary, "", staffIds...)
 	c.JSON(200, gin.H{
 		"status": 2000,
 		"total":  total,
@@ -206,6 +216,7 @@ func GetSalaryRecordByStaffId(c *gin.Context) {
 		})
 		return
 	}
+	recordSalaryRecordAccess(c, list)
 	c.JSON(200, gin.H{
 		"status": 2000,
 		"total":  total,
@@ -268,9 +279,18 @@ func GetHadPaySalaryRecordByStaffId(c *gin.Context) {
 		})
 		return
 	}
+	recordSalaryRecordAccess(c, list)
 	c.JSON(200, gin.H{
 		"status": 2000,
 		"total":  total,
 		"msg":    list,
 	})
 }
+
+func recordSalaryRecordAccess(c *gin.Context, list []*model.SalaryRecord) {
+	staffIds := make([]string, 0, len(list))
+	for _, record := range list {
+		staffIds = append(staffIds, record.StaffId)
+	}
+	service.RecordAccess(c, model.AccessResourceSalaryRecord, "", staffIds...)
+}
@@ -6,15 +6,10 @@ import (
 	"hrms/model"
 	"hrms/resource"
 	"hrms/service"
-	"io/ioutil"
 	"log"
 	"net/http"
-	"strings"
-	"sync/atomic"
 
 	"github.com/gin-gonic/gin"
-	"github.com/tealeg/xlsx"
-	"golang.org/x/sync/errgroup"
 	"gorm.io/gorm"
 )
 
@@ -37,7 +32,7 @@ func StaffCreate(c *gin.Context) {
 	}
 	log.Printf("[StaffCreate staff = %v]", staffCreateDto)
 	// 创建员工信息落表
-	if staff, err := buildStaffInfoSaveDB(c, staffCreateDto); err != nil {
+	if staff, err := service.CreateStaffWithTx(c, staffCreateDto); err != nil {
 		if err == resource.ErrUnauthorized {
 			c.JSON(http.StatusUnauthorized, gin.H{"status": 401, "message": "Unauthorized"})
 			return
@@ -55,63 +50,6 @@ func StaffCreate(c *gin.Context) {
 	}
 }
 
-func buildStaffInfoSaveDB(c *gin.Context, staffCreateDto model.StaffCreateDTO) (model.Staff, error) {
-	staffID := service.RandomStaffId()
-	staff := model.Staff{
-		StaffId:       staffID,
-		StaffName:     staffCreateDto.StaffName,
-		LeaderStaffId: staffCreateDto.LeaderStaffId,
-		Phone:         staffCreateDto.Phone,
-		Birthday:      service.Str2Time(staffCreateDto.BirthdayStr, 0),
-		IdentityNum:   staffCreateDto.IdentityNum,
-		Sex:           service.SexStr2Int64(staffCreateDto.SexStr),
-		Nation:        staffCreateDto.Nation,
-		School:        staffCreateDto.School,
-		Major:         staffCreateDto.Major,
-		EduLevel:      staffCreateDto.EduLevel,
-		BaseSalary:    staffCreateDto.BaseSalary,
-		CardNum:       staffCreateDto.CardNum,
-		RankId:        staffCreateDto.RankId,
-		DepId:         staffCreateDto.DepId,
-		Email:         staffCreateDto.Email,
-		EntryDate:     service.Str2Time(staffCreateDto.EntryDateStr, 0),
-	}
-	var exist int64
-	db := resource.HrmsDB(c)
-	if db == nil {
-		log.Printf("buildStaffInfoSaveDB: 数据库连接为空，鉴权失败")
-		return staff, resource.ErrUnauthorized
-	}
-	db.Model(&model.Staff{}).Where("identity_num = ? or staff_id = ?", staffCreateDto.IdentityNum, staffID).Count(&exist)
-	if exist != 0 {
-		return staff, errors.New("已经存在该员工")
-	}
-	// 查询leader名称
-	var leader model.Staff
-	db.Where("staff_id = ?", staffCreateDto.LeaderStaffId).Find(&leader)
-	staff.LeaderName = leader.StaffName
-	// 创建登陆信息，密码为身份证后六位
-	identLen := len(staff.IdentityNum)
-	login := model.Authority{
-		AuthorityId:  service.RandomID("auth"),
-		StaffId:      staffID,
-		UserPassword: service.MD5(staff.IdentityNum[identLen-6 : identLen]),
-		//Aval:         1,
-		UserType: "normal", // 暂时只能创建普通员工
-	}
-	err := db.Transaction(func(tx *gorm.DB) error {
-		if err := tx.Create(&staff).Error; err != nil {
-			return err
-		}
-		if err := tx.Create(&login).Error; err != nil {
-			return err
-		}
-		return nil
-	})
-
-	return staff, err
-}
-
 func StaffEdit(c *gin.Context) {
 	var staffEditDTO model.StaffEditDTO
 	if err := c.BindJSON(&staffEditDTO); err != nil {
@@ -123,6 +61,29 @@ func StaffEdit(c *gin.Context) {
 		return
 	}
 	log.Printf("[StaffEdit staff = %v]", staffEditDTO)
+	db := resource.HrmsDB(c)
+	if db == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": 401, "message": "Unauthorized"})
+		return
+	}
+	// 修改身份证号时校验其合法性，历史数据中不合法的身份证号未修改时不做校验
+	var old model.Staff
+	db.Where("staff_id = ?", staffEditDTO.StaffId).Find(&old)
+	// 查询结果中的身份证号、银行卡号已脱敏，原样提交时视为未修改
+	staffEditDTO.IdentityNum = service.UnmaskSubmitted(model.SensitiveIdentityNum, staffEditDTO.IdentityNum, old.IdentityNum)
+	staffEditDTO.CardNum = service.UnmaskSubmitted(model.SensitiveCardNum, staffEditDTO.CardNum, old.CardNum)
+	staffEditDTO.Address = service.UnmaskSubmitted(model.SensitiveAddress, staffEditDTO.Address, old.Address)
+	staffEditDTO.IdentityNum = service.NormalizeIdentityNum(staffEditDTO.IdentityNum)
+	if _, _, err := service.ParseIdentityNum(staffEditDTO.IdentityNum); err == nil || staffEditDTO.IdentityNum != old.IdentityNum {
+		if err := service.ResolveIdentity(staffEditDTO.IdentityNum, &staffEditDTO.BirthdayStr, &staffEditDTO.SexStr); err != nil {
+			log.Printf("[StaffEdit] err = %v", err)
+			c.JSON(200, gin.H{
+				"status": 5001,
+				"result": err.Error(),
+			})
+			return
+		}
+	}
 	staff := model.Staff{
 		StaffId:       staffEditDTO.StaffId,
 		StaffName:     staffEditDTO.StaffName,
@@ -141,18 +102,28 @@ func StaffEdit(c *gin.Context) {
 		DepId:         staffEditDTO.DepId,
 		Email:         staffEditDTO.Email,
 		EntryDate:     service.Str2Time(staffEditDTO.EntryDateStr, 0),
+		Address:       staffEditDTO.Address,
 	}
 	// 查询leader名称
 	var leader model.Staff
-	db := resource.HrmsDB(c)
-	if db == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"status": 401, "message": "Unauthorized"})
-		return
-	}
 	db.Where("staff_id = ?", staffEditDTO.LeaderStaffId).Find(&leader)
 	staff.LeaderName = leader.StaffName
-	db.Model(&model.Staff{}).Where("staff_id = ?", staffEditDTO.StaffId).
-		Updates(&staff)
+	if err := service.UpdateStaff(c, &staff); err != nil {
+		log.Printf("[StaffEdit] err = %v", err)
+		// 修改上级形成汇报环路
+		if errors.Is(err, service.ErrInvalidLeader) {
+			c.JSON(200, gin.H{
+				"status": 5001,
+				"result": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status": 5001,
+			"msg":    err.Error(),
+		})
+		return
+	}
 	c.JSON(200, gin.H{
 		"status": 2000,
 	})
@@ -173,18 +144,25 @@ func StaffQuery(c *gin.Context) {
 	staffId := c.Param("staff_id")
 	var staffs []model.Staff
 	if staffId == "all" {
+		query := db.Where("staff_id != 'root' and staff_id != 'admin'")
+		// 按在职状态过滤，不传时包含已离职员工
+		if status := c.Query("status"); status != "" {
+			query = query.Where("status = ?", status)
+		}
+		// 查询条件在列表及总数查询中复用
+		query = query.Session(&gorm.Session{})
 		// 查询全部
 		if start == -1 && start == -1 {
-			db.Where("staff_id != 'root' and staff_id != 'admin'").Find(&staffs)
+			query.Find(&staffs)
 		} else {
-			db.Where("staff_id != 'root' and staff_id != 'admin'").Offset(start).Limit(limit).Find(&staffs)
+			query.Offset(start).Limit(limit).Find(&staffs)
 		}
 		if len(staffs) == 0 {
 			// 不存在
 			code = 2001
 		}
 		// 总记录数
-		db.Model(&model.Staff{}).Where("staff_id != 'root' and staff_id != 'admin'").Count(&total)
+		query.Model(&model.Staff{}).Count(&total)
 		c.JSON(http.StatusOK, gin.H{
 			"status": code,
 			"total":  total,
@@ -211,30 +189,31 @@ func getRuleByStaffId(c *gin.Context, staffId string) string {
 	db := resource.HrmsDB(c)
 	if db != nil {
 		if err := db.Where("staff_id = ?", staffId).Find(&authority).Error; err == nil {
-			switch authority.UserType {
-			case "supersys":
-				userTypeName = "超级管理员"
-			case "sys":
-				userTypeName = "系统管理员"
-			case "normal":
-				userTypeName = "普通员工"
-			default:
-				userTypeName = "未知"
-			}
+			userTypeName = service.UserTypeName(authority.UserType)
 		}
 	}
 	return userTypeName
 }
 func convert2VO(c *gin.Context, staffs []model.Staff) []model.StaffVO {
 	var staffVOs []model.StaffVO
+	// 按查看人权限对敏感字段脱敏
+	policy, err := service.NewMaskPolicy(c)
+	if err != nil {
+		return staffVOs
+	}
 	for _, staff := range staffs {
-		staffVOs = append(staffVOs, model.StaffVO{
+		vo := model.StaffVO{
 			Staff:        staff,
 			DepName:      service.GetDepNameByDepId(c, staff.DepId),
 			RankName:     service.GetRankNameRankDepId(c, staff.RankId),
 			UserTypeName: getRuleByStaffId(c, staff.StaffId),
-		})
+			StatusName:   service.StaffStatusName(staff.Status),
+		}
+		policy.MaskStaff(&vo)
+		staffVOs = append(staffVOs, vo)
 	}
+	// 员工详情查询均经此处输出，统一记录访问日志
+	service.RecordStaffAccess(c, model.AccessResourceStaff, staffVOs)
 	return staffVOs
 }
 
@@ -271,42 +250,51 @@ func StaffQueryByName(c *gin.Context) {
 		})
 		return
 	}
-	db.Where("staff_name like ?", "%"+staffName+"%").Where("staff_id != 'root' and staff_id != 'admin'").Find(&staffs)
-	if len(staffs) == 0 {
-		// 不存在
-		code = 2001
-	}
-	total = int64(len(staffs))
-	c.JSON(http.StatusOK, gin.H{
-		"status": code,
-		"total":  total,
-		"msg":    convert2VO(c, staffs),
-	})
+	staffSearch(c, &model.StaffSearchQuery{StaffName: staffName}, start, limit)
 }
 
 func StaffQueryByDep(c *gin.Context) {
-	var total int64 = 1
 	// 分页
 	start, limit := service.AcceptPage(c)
-	code := 2000
-	depName := c.Param("dep_name")
-	var staffs []model.Staff
-	reqSql := `select * from staff as staff left join department as dep on staff.dep_id = dep.dep_id where staff.deleted_at is null and dep.dep_name like "%v"`
-	if start != -1 && limit != -1 {
-		reqSql += fmt.Sprintf(` limit %v,%v`, start, limit)
+	includeSub := false
+	staffSearch(c, &model.StaffSearchQuery{DepName: c.Param("dep_name"), IncludeSubDeps: &includeSub}, start, limit)
+}
+
+// 员工组合查询，支持多条件过滤及多字段排序
+func StaffSearch(c *gin.Context) {
+	var query model.StaffSearchQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		log.Printf("[StaffSearch] err = %v", err)
+		c.JSON(200, gin.H{
+			"status": 5001,
+			"msg":    err.Error(),
+		})
+		return
 	}
-	reqSql = fmt.Sprintf(reqSql, "%"+depName+"%")
-	db := resource.HrmsDB(c)
-	if db == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"status": 401, "message": "Unauthorized"})
+	// 分页
+	start, limit := service.AcceptPage(c)
+	staffSearch(c, &query, start, limit)
+}
+
+func staffSearch(c *gin.Context, query *model.StaffSearchQuery, start int, limit int) {
+	staffs, total, err := service.SearchStaff(c, query, start, limit)
+	if err != nil {
+		if err == resource.ErrUnauthorized {
+			c.JSON(http.StatusUnauthorized, gin.H{"status": 401, "message": "Unauthorized"})
+			return
+		}
+		log.Printf("[staffSearch] err = %v", err)
+		c.JSON(200, gin.H{
+			"status": 5001,
+			"msg":    err.Error(),
+		})
 		return
 	}
-	db.Raw(reqSql).Scan(&staffs)
+	code := 2000
 	if len(staffs) == 0 {
 		// 不存在
 		code = 2001
 	}
-	total = int64(len(staffs))
 	c.JSON(http.StatusOK, gin.H{
 		"status": code,
 		"total":  total,
@@ -314,6 +302,7 @@ func StaffQueryByDep(c *gin.Context) {
 	})
 }
 
+// 删除误录的员工，离职、辞退、退休等请通过状态变更处理以保留历史记录
 func StaffDel(c *gin.Context) {
 	db := resource.HrmsDB(c)
 	if db == nil {
@@ -322,20 +311,25 @@ func StaffDel(c *gin.Context) {
 	}
 
 	staffId := c.Param("staff_id")
-	if err := db.Where("staff_id = ?", staffId).Delete(&model.Staff{}).Error; err != nil {
-		log.Printf("[StaffDel] err = %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status": 5001,
-			"msg":    err,
-		})
-		return
-	}
-	// 密码删除
-	if err := db.Where("staff_id = ?", staffId).Delete(&model.Authority{}).Error; err != nil {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var staff model.Staff
+		if err := tx.Where("staff_id = ?", staffId).First(&staff).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&staff).Error; err != nil {
+			return err
+		}
+		// 密码删除
+		if err := tx.Where("staff_id = ?", staffId).Delete(&model.Authority{}).Error; err != nil {
+			return err
+		}
+		return service.RecordStaffHistory(tx, c, model.StaffActionDelete, &staff, nil)
+	})
+	if err != nil {
 		log.Printf("[StaffDel] err = %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status": 5001,
-			"msg":    err,
+			"msg":    err.Error(),
 		})
 		return
 	}
@@ -367,144 +361,20 @@ func StaffQueryByStaffId(c *gin.Context) {
 	})
 }
 
+// 从Excel导入员工信息，只导入校验通过的行
 func ExcelExport(c *gin.Context) {
-	var err error
-	defer func() {
-		if err != nil {
-			log.Printf("[ExcelExport err = %v]", err)
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"status": 5001,
-				"msg":    err.Error(),
-			})
-			return
-		}
-	}()
-	file, err := c.FormFile("excel_staffs")
+	xfile, err := openStaffImportFile(c)
 	if err != nil {
-		log.Printf("ExcelExport err = %v", err)
 		return
 	}
-	if strings.Split(file.Filename, ".")[1] != "xlsx" {
-		log.Printf("ExcelExport 只可上传xlsx格式文件")
-		return
-	}
-	fileOpen, err := file.Open()
-	if err != nil {
-		log.Printf("ExcelExport err = %v", err)
-		return
-	}
-	defer fileOpen.Close()
-	bytes, err := ioutil.ReadAll(fileOpen)
+	result, err := service.CommitStaffImport(c, xfile, false)
 	if err != nil {
-		log.Printf("ExcelExport err = %v", err)
+		staffImportError(c, "ExcelExport", err)
 		return
 	}
-	xfile, err := xlsx.OpenBinary(bytes)
-	if err != nil {
-		log.Printf("ExcelExport err = %v", err)
-		return
-	}
-	var exportStaffList []model.StaffCreateDTO
-	for _, sheet := range xfile.Sheets {
-		headers := sheet.Rows[0]
-		for _, r := range sheet.Rows[1:] {
-			staff := model.StaffCreateDTO{}
-			for i, v := range r.Cells {
-				switch headers.Cells[i].String() {
-				case "员工姓名":
-					staff.StaffName = v.String()
-				case "指定上级":
-					staff.LeaderName = v.String()
-				case "上级工号":
-					staff.LeaderStaffId = v.String()
-				case "员工性别":
-					staff.SexStr = v.String()
-				case "身份证号":
-					staff.IdentityNum = v.String()
-				case "出生日期":
-					staff.BirthdayStr = v.String()
-				case "民族":
-					staff.Nation = v.String()
-				case "毕业院校":
-					staff.School = v.String()
-				case "毕业专业":
-					staff.Major = v.String()
-				case "最高学历":
-					staff.EduLevel = v.String()
-				case "基本薪资":
-					if s, err := v.Int64(); err != nil {
-						staff.BaseSalary = -1
-					} else {
-						staff.BaseSalary = s
-					}
-				case "银行卡号":
-					staff.CardNum = v.String()
-				case "职位":
-					staff.RankId = getRankID(c, v.String())
-				case "部门":
-					staff.DepId = getDepID(c, v.String())
-				case "电子邮箱":
-					staff.Email = v.String()
-				case "手机号":
-					if s, err := v.Int64(); err != nil {
-						staff.Phone = -1
-					} else {
-						staff.Phone = s
-					}
-				case "入职日期":
-					staff.EntryDateStr = v.String()
-				}
-			}
-			exportStaffList = append(exportStaffList, staff)
-		}
-	}
-
-	var (
-		eg         errgroup.Group
-		successNum int64
-		errNum     int64
-	)
-	for _, s := range exportStaffList {
-		var s = s
-		eg.Go(func() error {
-			if _, err := buildStaffInfoSaveDB(c, s); err != nil {
-				atomic.AddInt64(&errNum, 1)
-				return err
-			}
-			atomic.AddInt64(&successNum, 1)
-			return nil
-		})
-	}
-	eg.Wait()
-
 	c.JSON(http.StatusOK, gin.H{
 		"status": 2000,
-		"msg":    fmt.Sprintf("完成员工信息导入，成功%v条，失败%v条", successNum, errNum),
+		"msg":    fmt.Sprintf("完成员工信息导入，成功%v条，失败%v条", result.Success, result.Failed),
+		"result": result,
 	})
 }
-
-func getDepID(c *gin.Context, depName string) string {
-	var dep model.Department
-	db := resource.HrmsDB(c)
-	if db == nil {
-		log.Printf("getDepID: 数据库连接为空，鉴权失败")
-		return "-1" // 鉴权失败时返回 -1
-	}
-	if err := db.Model(&model.Department{}).Where("dep_name = ?", depName).Take(&dep).Error; err != nil {
-		return "-1"
-	}
-	return dep.DepId
-}
-
-func getRankID(c *gin.Context, rankName string) string {
-	var rank model.Rank
-	db := resource.HrmsDB(c)
-	if db == nil {
-		log.Printf("getRankID: 数据库连接为空，鉴权失败")
-		return "-1" // 鉴权失败时返回 -1
-	}
-	if err := db.Model(&model.Rank{}).Where("rank_name = ?", rankName).Take(&rank).Error; err != nil {
-		return "-1"
-	}
-	return rank.RankId
-}
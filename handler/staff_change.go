@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"hrms/model"
+	"hrms/resource"
+	"hrms/service"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func staffChangeError(c *gin.Context, name string, err error) {
+	switch err {
+	case resource.ErrUnauthorized:
+		c.JSON(http.StatusUnauthorized, gin.H{"status": 401, "message": "Unauthorized"})
+	case resource.ErrForbidden:
+		c.JSON(http.StatusForbidden, gin.H{"status": 403, "message": "Forbidden"})
+	case service.ErrStaffChangeNotExist, service.ErrStaffRecordNotExist:
+		c.JSON(200, gin.H{
+			"status": 2001,
+			"result": err.Error(),
+		})
+	default:
+		log.Printf("[%v] err = %v", name, err)
+		c.JSON(200, gin.H{
+			"status": 5002,
+			"result": err.Error(),
+		})
+	}
+}
+
+// 员工提交手机号、邮箱、银行卡号、住址及紧急联系人的修改申请
+func StaffChangeSubmit(c *gin.Context) {
+	// 参数绑定
+	var dto model.StaffChangeSubmitDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		log.Printf("[StaffChangeSubmit] err = %v", err)
+		c.JSON(200, gin.H{
+			"status": 5001,
+			"result": err.Error(),
+		})
+		return
+	}
+	// 业务处理
+	request, err := service.SubmitStaffChange(c, &dto)
+	if err != nil {
+		staffChangeError(c, "StaffChangeSubmit", err)
+		return
+	}
+	c.JSON(200, gin.H{
+		"status": 2000,
+		"msg":    request.RequestId,
+	})
+}
+
+func StaffChangeCancel(c *gin.Context) {
+	if err := service.CancelStaffChange(c, c.Param("request_id")); err != nil {
+		staffChangeError(c, "StaffChangeCancel", err)
+		return
+	}
+	c.JSON(200, gin.H{
+		"status": 2000,
+	})
+}
+
+// 人事审批修改申请
+func StaffChangeReview(c *gin.Context) {
+	// 参数绑定
+	var dto model.StaffChangeReviewDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		log.Printf("[StaffChangeReview] err = %v", err)
+		c.JSON(200, gin.H{
+			"status": 5001,
+			"result": err.Error(),
+		})
+		return
+	}
+	// 业务处理
+	if err := service.ReviewStaffChange(c, &dto); err != nil {
+		staffChangeError(c, "StaffChangeReview", err)
+		return
+	}
+	c.JSON(200, gin.H{
+		"status": 2000,
+	})
+}
+
+func StaffChangeQuery(c *gin.Context) {
+	requests, err := service.GetStaffChanges(c, c.Param("staff_id"))
+	if err != nil {
+		staffChangeError(c, "StaffChangeQuery", err)
+		return
+	}
+	code := 2000
+	if len(requests) == 0 {
+		code = 2001
+	}
+	c.JSON(200, gin.H{
+		"status": code,
+		"total":  len(requests),
+		"msg":    requests,
+	})
+}
+
+// 待人事审批的修改申请
+func StaffChangePending(c *gin.Context) {
+	requests, err := service.GetPendingStaffChanges(c)
+	if err != nil {
+		staffChangeError(c, "StaffChangePending", err)
+		return
+	}
+	code := 2000
+	if len(requests) == 0 {
+		code = 2001
+	}
+	c.JSON(200, gin.H{
+		"status": code,
+		"total":  len(requests),
+		"msg":    requests,
+	})
+}
@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"hrms/model"
+	"hrms/resource"
+	"hrms/service"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func staffContractError(c *gin.Context, name string, err error) {
+	switch err {
+	case resource.ErrUnauthorized:
+		c.JSON(http.StatusUnauthorized, gin.H{"status": 401, "message": "Unauthorized"})
+	case resource.ErrForbidden:
+		c.JSON(http.StatusForbidden, gin.H{"status": 403, "message": "Forbidden"})
+	case service.ErrStaffContractNotExist:
+		c.JSON(200, gin.H{
+			"status": 2001,
+			"result": err.Error(),
+		})
+	default:
+		log.Printf("[%v] err = %v", name, err)
+		c.JSON(200, gin.H{
+			"status": 5002,
+			"result": err.Error(),
+		})
+	}
+}
+
+func StaffContractCreate(c *gin.Context) {
+	// 参数绑定
+	var dto model.StaffContractCreateDTO
+	if err := c.BindJSON(&dto); err != nil {
+		log.Printf("[StaffContractCreate] err = %v", err)
+		c.JSON(200, gin.H{
+			"status": 5001,
+			"result": err.Error(),
+		})
+		return
+	}
+	// 业务处理
+	contract, err := service.CreateStaffContract(c, &dto)
+	if err != nil {
+		staffContractError(c, "StaffContractCreate", err)
+		return
+	}
+	c.JSON(200, gin.H{
+		"status": 2000,
+		"msg":    contract,
+	})
+}
+
+func StaffContractRenew(c *gin.Context) {
+	// 参数绑定
+	var dto model.StaffContractRenewDTO
+	if err := c.BindJSON(&dto); err != nil {
+		log.Printf("[StaffContractRenew] err = %v", err)
+		c.JSON(200, gin.H{
+			"status": 5001,
+			"result": err.Error(),
+		})
+		return
+	}
+	// 业务处理
+	contract, err := service.RenewStaffContract(c, &dto)
+	if err != nil {
+		staffContractError(c, "StaffContractRenew", err)
+		return
+	}
+	c.JSON(200, gin.H{
+		"status": 2000,
+		"msg":    contract,
+	})
+}
+
+func StaffContractTerminate(c *gin.Context) {
+	// 参数绑定
+	var dto model.StaffContractTerminateDTO
+	if err := c.BindJSON(&dto); err != nil {
+		log.Printf("[StaffContractTerminate] err = %v", err)
+		c.JSON(200, gin.H{
+			"status": 5001,
+			"result": err.Error(),
+		})
+		return
+	}
+	// 业务处理
+	if err := service.TerminateStaffContract(c, &dto); err != nil {
+		staffContractError(c, "StaffContractTerminate", err)
+		return
+	}
+	c.JSON(200, gin.H{
+		"status": 2000,
+	})
+}
+
+// 查询员工的合同及续签记录，staff_id 为 all 时查询全部员工
+func StaffContractQuery(c *gin.Context) {
+	// 参数绑定
+	staffId := c.Param("staff_id")
+	// 业务处理
+	contracts, err := service.GetStaffContracts(c, staffId)
+	if err != nil {
+		staffContractError(c, "StaffContractQuery", err)
+		return
+	}
+	code := 2000
+	if len(contracts) == 0 {
+		code = 2001
+	}
+	c.JSON(200, gin.H{
+		"status": code,
+		"total":  len(contracts),
+		"msg":    contracts,
+	})
+}
+
+// 查询即将到期的合同及试用期，可通过 days 参数指定天数，默认使用配置的提醒天数
+func StaffContractExpiring(c *gin.Context) {
+	// 参数绑定
+	var days int64
+	if s := c.Query("days"); s != "" {
+		var err error
+		if days, err = strconv.ParseInt(s, 10, 64); err != nil || days < 0 {
+			c.JSON(200, gin.H{
+				"status": 5001,
+				"result": "days 参数应为非负整数",
+			})
+			return
+		}
+	}
+	// 业务处理
+	contracts, err := service.GetExpiringStaffContracts(c, days)
+	if err != nil {
+		staffContractError(c, "StaffContractExpiring", err)
+		return
+	}
+	code := 2000
+	if len(contracts) == 0 {
+		code = 2001
+	}
+	c.JSON(200, gin.H{
+		"status": code,
+		"total":  len(contracts),
+		"msg":    contracts,
+	})
+}
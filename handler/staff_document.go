@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hrms/resource"
+	"hrms/service"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func staffDocumentError(c *gin.Context, name string, err error) {
+	switch err {
+	case resource.ErrUnauthorized:
+		c.JSON(http.StatusUnauthorized, gin.H{"status": 401, "message": "Unauthorized"})
+	case resource.ErrForbidden:
+		c.JSON(http.StatusForbidden, gin.H{"status": 403, "message": "Forbidden"})
+	case service.ErrStaffDocumentNotExist:
+		c.JSON(200, gin.H{
+			"status": 2001,
+			"result": err.Error(),
+		})
+	default:
+		log.Printf("[%v] err = %v", name, err)
+		c.JSON(200, gin.H{
+			"status": 5002,
+			"result": err.Error(),
+		})
+	}
+}
+
+// 上传员工档案文件，表单字段 file 为文件，doc_type 为档案类型，remark 可选
+func StaffDocumentUpload(c *gin.Context) {
+	// 参数绑定
+	staffId := c.Param("staff_id")
+	// 请求体超出文件大小上限时直接拒绝，预留表单其他字段的空间
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, service.DocumentMaxSize()+1<<20)
+	file, err := c.FormFile("file")
+	if err != nil {
+		log.Printf("[StaffDocumentUpload] err = %v", err)
+		c.JSON(200, gin.H{
+			"status": 5001,
+			"result": fmt.Sprintf("读取上传文件失败，文件大小不能超过%vMB", service.DocumentMaxSize()>>20),
+		})
+		return
+	}
+	// 业务处理
+	doc, err := service.UploadStaffDocument(c, staffId, c.PostForm("doc_type"), c.PostForm("remark"), file)
+	if err != nil {
+		staffDocumentError(c, "StaffDocumentUpload", err)
+		return
+	}
+	c.JSON(200, gin.H{
+		"status": 2000,
+		"msg":    doc,
+	})
+}
+
+func StaffDocumentList(c *gin.Context) {
+	// 参数绑定
+	staffId := c.Param("staff_id")
+	// 业务处理
+	docs, err := service.GetStaffDocuments(c, staffId)
+	if err != nil {
+		staffDocumentError(c, "StaffDocumentList", err)
+		return
+	}
+	code := 2000
+	if len(docs) == 0 {
+		code = 2001
+	}
+	c.JSON(200, gin.H{
+		"status": code,
+		"total":  len(docs),
+		"msg":    docs,
+	})
+}
+
+func StaffDocumentDownload(c *gin.Context) {
+	// 参数绑定
+	documentId := c.Param("document_id")
+	// 业务处理
+	doc, r, err := service.OpenStaffDocument(c, documentId)
+	if err != nil {
+		staffDocumentError(c, "StaffDocumentDownload", err)
+		return
+	}
+	defer r.Close()
+	c.Header("Content-Type", doc.MimeType)
+	c.Header("Content-Length", strconv.FormatInt(doc.Size, 10))
+	c.Header("Content-Disposition", "attachment; filename*=UTF-8''"+url.PathEscape(doc.FileName))
+	c.Header("X-Checksum-Sha256", doc.Sha256)
+	// 边下载边校验，文件内容与上传时不一致时记录日志
+	hash := sha256.New()
+	if _, err := io.Copy(c.Writer, io.TeeReader(r, hash)); err != nil {
+		log.Printf("[StaffDocumentDownload] err = %v", err)
+		return
+	}
+	if sum := hex.EncodeToString(hash.Sum(nil)); sum != doc.Sha256 {
+		log.Printf("[StaffDocumentDownload] 档案文件 %v 校验和不一致, expect = %v, actual = %v", doc.DocumentId, doc.Sha256, sum)
+	}
+}
+
+func StaffDocumentDel(c *gin.Context) {
+	// 参数绑定
+	documentId := c.Param("document_id")
+	// 业务处理
+	if err := service.DelStaffDocument(c, documentId); err != nil {
+		staffDocumentError(c, "StaffDocumentDel", err)
+		return
+	}
+	c.JSON(200, gin.H{
+		"status": 2000,
+	})
+}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"fmt"
+	"hrms/model"
+	"hrms/service"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 导出员工信息，筛选条件与员工组合查询一致，format 为 xlsx 或 csv
+func StaffExport(c *gin.Context) {
+	var query model.StaffSearchQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		staffImportError(c, "StaffExport", err)
+		return
+	}
+	format := c.DefaultQuery("format", "xlsx")
+	if format != "xlsx" && format != "csv" {
+		c.JSON(http.StatusOK, gin.H{
+			"status": 5001,
+			"msg":    "导出格式只支持 xlsx 或 csv",
+		})
+		return
+	}
+	// reveal=true 时导出身份证号等敏感字段明文，仅管理员可用且记录查看；
+	// 默认脱敏导出的文件不能直接再次导入
+	exporter, err := service.NewStaffExporter(c, c.Query("columns"), c.Query("reveal") == "true")
+	if err != nil {
+		staffImportError(c, "StaffExport", err)
+		return
+	}
+	// records 指定一并导出的紧急联系人、家庭成员等子记录，仅支持 xlsx
+	if err := exporter.SetRecords(c.Query("records")); err != nil {
+		staffImportError(c, "StaffExport", err)
+		return
+	}
+	if exporter.HasRecords() && format != "xlsx" {
+		c.JSON(http.StatusOK, gin.H{
+			"status": 5001,
+			"msg":    "子记录仅支持 xlsx 格式导出",
+		})
+		return
+	}
+	// 先校验筛选条件，开始写出文件后无法再返回错误信息
+	if _, _, err := service.SearchStaff(c, &query, 0, 1); err != nil {
+		staffImportError(c, "StaffExport", err)
+		return
+	}
+	if err := exporter.LogReveal(c); err != nil {
+		staffImportError(c, "StaffExport", err)
+		return
+	}
+	fileName := fmt.Sprintf("staff_%v.%v", time.Now().Format("20060102150405"), format)
+	if format == "csv" {
+		// 大批量导出时分批写出，不在内存中生成完整文件
+		c.Header("Content-Disposition", "attachment; filename="+fileName)
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		if err := exporter.WriteCSV(c, &query, c.Writer); err != nil {
+			log.Printf("[StaffExport] err = %v", err)
+		}
+		return
+	}
+	file, err := exporter.BuildXlsx(c, &query)
+	if err != nil {
+		staffImportError(c, "StaffExport", err)
+		return
+	}
+	c.Header("Content-Disposition", "attachment; filename="+fileName)
+	c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	if err := file.Write(c.Writer); err != nil {
+		log.Printf("[StaffExport] err = %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"hrms/model"
+	"hrms/resource"
+	"hrms/service"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func StaffHistory(c *gin.Context) {
+	// 参数绑定
+	staffId := c.Param("staff_id")
+	start, limit := service.AcceptPage(c)
+	// 业务处理
+	histories, total, err := service.GetStaffHistoryByStaffId(c, staffId, start, limit)
+	if err != nil {
+		if err == resource.ErrUnauthorized {
+			c.JSON(http.StatusUnauthorized, gin.H{"status": 401, "message": "Unauthorized"})
+			return
+		}
+		log.Printf("[StaffHistory] err = %v", err)
+		c.JSON(200, gin.H{
+			"status": 5002,
+			"result": err.Error(),
+		})
+		return
+	}
+	// 身份证号等字段的变更前后值按查看人权限脱敏
+	policy, err := service.NewMaskPolicy(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": 401, "message": "Unauthorized"})
+		return
+	}
+	var plainFields []string
+	plain := make(map[string]bool)
+	for _, history := range histories {
+		if policy.MaskStaffHistory(history) && !plain[history.Field] {
+			plain[history.Field] = true
+			plainFields = append(plainFields, history.Field)
+		}
+	}
+	service.RecordAccess(c, model.AccessResourceStaffHistory, strings.Join(plainFields, ","), staffId)
+	c.JSON(200, gin.H{
+		"status": 2000,
+		"total":  total,
+		"msg":    histories,
+	})
+}
+
+// 查询员工在指定日期的信息
+func StaffQueryAsOf(c *gin.Context) {
+	// 参数绑定
+	staffId := c.Param("staff_id")
+	date, err := time.ParseInLocation("2006-01-02", c.Param("date"), time.Local)
+	if err != nil {
+		c.JSON(200, gin.H{
+			"status": 5001,
+			"result": "日期格式错误，应为 YYYY-MM-DD",
+		})
+		return
+	}
+	// 业务处理
+	staff, err := service.GetStaffAsOf(c, staffId, date)
+	if err != nil {
+		if err == resource.ErrUnauthorized {
+			c.JSON(http.StatusUnauthorized, gin.H{"status": 401, "message": "Unauthorized"})
+			return
+		}
+		if err == service.ErrStaffNotExist {
+			c.JSON(200, gin.H{
+				"status": 2001,
+				"total":  0,
+				"msg":    []model.StaffVO{},
+			})
+			return
+		}
+		log.Printf("[StaffQueryAsOf] err = %v", err)
+		c.JSON(200, gin.H{
+			"status": 5002,
+			"result": err.Error(),
+		})
+		return
+	}
+	c.JSON(200, gin.H{
+		"status": 2000,
+		"total":  1,
+		"msg":    convert2VO(c, []model.Staff{*staff}),
+	})
+}
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"hrms/resource"
+	"hrms/service"
+	"log"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tealeg/xlsx"
+)
+
+func staffImportError(c *gin.Context, name string, err error) {
+	if err == resource.ErrUnauthorized {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": 401, "message": "Unauthorized"})
+		return
+	}
+	if err == resource.ErrForbidden {
+		c.JSON(http.StatusForbidden, gin.H{"status": 403, "message": "Forbidden"})
+		return
+	}
+	log.Printf("[%v] err = %v", name, err)
+	c.JSON(http.StatusOK, gin.H{
+		"status": 5001,
+		"msg":    err.Error(),
+	})
+}
+
+// 读取上传的员工导入文件，失败时直接返回错误响应
+func openStaffImportFile(c *gin.Context) (*xlsx.File, error) {
+	file, err := c.FormFile("excel_staffs")
+	if err == nil {
+		var xfile *xlsx.File
+		if xfile, err = service.OpenStaffImportFile(file); err == nil {
+			return xfile, nil
+		}
+	}
+	staffImportError(c, "openStaffImportFile", err)
+	return nil, err
+}
+
+// 校验员工导入文件，不写入数据
+func StaffImportValidate(c *gin.Context) {
+	xfile, err := openStaffImportFile(c)
+	if err != nil {
+		return
+	}
+	result, err := service.ValidateStaffImport(c, xfile)
+	if err != nil {
+		staffImportError(c, "StaffImportValidate", err)
+		return
+	}
+	code := 2000
+	if len(result.Errors) > 0 {
+		code = 2001
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status": code,
+		"msg":    result,
+	})
+}
+
+// 校验员工导入文件，返回标注了校验结果的xlsx文件
+func StaffImportReport(c *gin.Context) {
+	xfile, err := openStaffImportFile(c)
+	if err != nil {
+		return
+	}
+	if _, err = service.AnnotateStaffImport(c, xfile); err != nil {
+		staffImportError(c, "StaffImportReport", err)
+		return
+	}
+	c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	c.Header("Content-Disposition", "attachment; filename*=UTF-8''"+url.PathEscape("员工导入校验结果.xlsx"))
+	if err := xfile.Write(c.Writer); err != nil {
+		log.Printf("[StaffImportReport] err = %v", err)
+	}
+}
+
+// 导入员工，默认任一行有误时全部不导入，atomic=false 时只导入校验通过的行
+func StaffImportCommit(c *gin.Context) {
+	xfile, err := openStaffImportFile(c)
+	if err != nil {
+		return
+	}
+	atomic := c.DefaultQuery("atomic", "true") != "false"
+	result, err := service.CommitStaffImport(c, xfile, atomic)
+	if err != nil {
+		staffImportError(c, "StaffImportCommit", err)
+		return
+	}
+	code := 2000
+	if len(result.Errors) > 0 {
+		code = 2001
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status": code,
+		"msg":    result,
+	})
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"hrms/resource"
+	"hrms/service"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func staffRecordError(c *gin.Context, name string, err error) {
+	switch err {
+	case resource.ErrUnauthorized:
+		c.JSON(http.StatusUnauthorized, gin.H{"status": 401, "message": "Unauthorized"})
+	case resource.ErrForbidden:
+		c.JSON(http.StatusForbidden, gin.H{"status": 403, "message": "Forbidden"})
+	case service.ErrStaffRecordNotExist:
+		c.JSON(200, gin.H{
+			"status": 2001,
+			"result": err.Error(),
+		})
+	default:
+		log.Printf("[%v] err = %v", name, err)
+		c.JSON(200, gin.H{
+			"status": 5002,
+			"result": err.Error(),
+		})
+	}
+}
+
+// 新增或修改员工子记录，kind 为 contact、family、education 或 employment，record_id 为空时新增
+func StaffRecordSave(c *gin.Context) {
+	// 参数绑定
+	form, err := service.NewStaffRecordForm(c.Param("kind"))
+	if err == nil {
+		err = c.ShouldBindJSON(form)
+	}
+	if err != nil {
+		log.Printf("[StaffRecordSave] err = %v", err)
+		c.JSON(200, gin.H{
+			"status": 5001,
+			"result": err.Error(),
+		})
+		return
+	}
+	// 业务处理
+	record, err := service.SaveStaffRecord(c, c.Param("kind"), form)
+	if err != nil {
+		staffRecordError(c, "StaffRecordSave", err)
+		return
+	}
+	c.JSON(200, gin.H{
+		"status": 2000,
+		"msg":    record,
+	})
+}
+
+func StaffRecordDel(c *gin.Context) {
+	// 参数绑定
+	kind := c.Param("kind")
+	recordId := c.Param("record_id")
+	// 业务处理
+	if err := service.DelStaffRecord(c, kind, recordId); err != nil {
+		staffRecordError(c, "StaffRecordDel", err)
+		return
+	}
+	c.JSON(200, gin.H{
+		"status": 2000,
+	})
+}
+
+func StaffRecordQuery(c *gin.Context) {
+	// 参数绑定
+	kind := c.Param("kind")
+	staffId := c.Param("staff_id")
+	// 业务处理
+	records, total, err := service.GetStaffRecords(c, kind, staffId)
+	if err != nil {
+		staffRecordError(c, "StaffRecordQuery", err)
+		return
+	}
+	code := 2000
+	if total == 0 {
+		code = 2001
+	}
+	c.JSON(200, gin.H{
+		"status": code,
+		"total":  total,
+		"msg":    records,
+	})
+}
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"hrms/model"
+	"hrms/resource"
+	"hrms/service"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func StaffStatusChange(c *gin.Context) {
+	// 参数绑定
+	var dto model.StaffStatusChangeDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		log.Printf("[StaffStatusChange] err = %v", err)
+		c.JSON(200, gin.H{
+			"status": 5001,
+			"result": err.Error(),
+		})
+		return
+	}
+	// 业务处理
+	err := service.ChangeStaffStatus(c, &dto)
+	if err != nil {
+		if err == resource.ErrUnauthorized {
+			c.JSON(http.StatusUnauthorized, gin.H{"status": 401, "message": "Unauthorized"})
+			return
+		}
+		if err == resource.ErrForbidden {
+			c.JSON(http.StatusForbidden, gin.H{"status": 403, "message": "Forbidden"})
+			return
+		}
+		log.Printf("[StaffStatusChange] err = %v", err)
+		c.JSON(200, gin.H{
+			"status": 5002,
+			"result": err.Error(),
+		})
+		return
+	}
+	c.JSON(200, gin.H{
+		"status": 2000,
+	})
+}
+
+func StaffStatusHistory(c *gin.Context) {
+	// 参数绑定
+	staffId := c.Param("staff_id")
+	// 业务处理
+	logs, err := service.GetStaffStatusLogByStaffId(c, staffId)
+	if err != nil {
+		if err == resource.ErrUnauthorized {
+			c.JSON(http.StatusUnauthorized, gin.H{"status": 401, "message": "Unauthorized"})
+			return
+		}
+		log.Printf("[StaffStatusHistory] err = %v", err)
+		c.JSON(200, gin.H{
+			"status": 5002,
+			"result": err.Error(),
+		})
+		return
+	}
+	c.JSON(200, gin.H{
+		"status": 2000,
+		"total":  len(logs),
+		"msg":    logs,
+	})
+}
@@ -3,7 +3,9 @@ package main
 import (
 	"fmt"
 	"hrms/handler"
+	"hrms/model"
 	"hrms/resource"
+	"hrms/service"
 	"log"
 	"net/http"
 	"os"
@@ -54,6 +56,14 @@ func InitConfig() error {
 	}
 	log.Printf("[config.Init] 初始化配置成功,config=%v", config)
 	resource.HrmsConf = config
+	if err := model.InitFieldCrypto(config.Crypto); err != nil {
+		log.Printf("[config.Init] 加载加密密钥失败, err = %v", err)
+		return err
+	}
+	if err := service.InitDocumentStorage(config.Document); err != nil {
+		log.Printf("[config.Init] 初始化档案文件存储失败, err = %v", err)
+		return err
+	}
 	return nil
 }
 
@@ -72,6 +82,8 @@ func InitGin() error {
 }
 
 func routerInit(server *gin.Engine) {
+	// 为每个请求分配请求ID，用于关联访问日志
+	server.Use(resource.RequestId())
 	// 根路径重定向到首页
 	server.GET("/", func(c *gin.Context) {
 		c.Redirect(http.StatusMovedPermanently, "/index")
@@ -93,6 +105,9 @@ func routerInit(server *gin.Engine) {
 	departGroup.POST("/edit", handler.DepartEdit)
 	departGroup.GET("/query/:dep_id", handler.DepartQuery)
 	departGroup.GET("/query", handler.DepartQuery)
+	departGroup.POST("/restructure/preview", handler.DepRestructurePreview)
+	departGroup.POST("/restructure/execute", handler.DepRestructureExecute)
+	departGroup.GET("/restructure/history/:dep_id", handler.DepRestructureHistory)
 	// 职级相关
 	rankGroup := server.Group("/rank")
 	rankGroup.POST("/create", handler.RankCreate)
@@ -100,10 +115,16 @@ func routerInit(server *gin.Engine) {
 	rankGroup.POST("/edit", handler.RankEdit)
 	rankGroup.GET("/query/:rank_id", handler.RankQuery)
 	rankGroup.GET("/query", handler.RankQuery)
+	rankGroup.POST("/change", handler.RankChange)
+	rankGroup.GET("/change/query/:staff_id", handler.RankChangeQuery)
 	// 员工信息相关
 	staffGroup := server.Group("/staff")
 	staffGroup.POST("/create", handler.StaffCreate)
 	staffGroup.POST("/excel_export", handler.ExcelExport)
+	staffGroup.POST("/import/validate", handler.StaffImportValidate)
+	staffGroup.POST("/import/report", handler.StaffImportReport)
+	staffGroup.POST("/import/commit", handler.StaffImportCommit)
+	staffGroup.GET("/export", handler.StaffExport)
 	staffGroup.DELETE("/del/:staff_id", handler.StaffDel)
 	staffGroup.POST("/edit", handler.StaffEdit)
 	staffGroup.GET("/query/:staff_id", handler.StaffQuery)
@@ -111,6 +132,50 @@ func routerInit(server *gin.Engine) {
 	staffGroup.GET("/query_by_name/:staff_name", handler.StaffQueryByName)
 	staffGroup.GET("/query_by_dep/:dep_name", handler.StaffQueryByDep)
 	staffGroup.GET("/query_by_staff_id/:staff_id", handler.StaffQueryByStaffId)
+	staffGroup.GET("/search", handler.StaffSearch)
+	staffGroup.POST("/status/change", handler.StaffStatusChange)
+	staffGroup.GET("/status/history/:staff_id", handler.StaffStatusHistory)
+	staffGroup.GET("/history/:staff_id", handler.StaffHistory)
+	staffGroup.GET("/query_as_of/:staff_id/:date", handler.StaffQueryAsOf)
+	staffGroup.GET("/reveal/:staff_id", handler.StaffReveal)
+	staffGroup.POST("/document/upload/:staff_id", handler.StaffDocumentUpload)
+	staffGroup.GET("/document/list/:staff_id", handler.StaffDocumentList)
+	staffGroup.GET("/document/download/:document_id", handler.StaffDocumentDownload)
+	staffGroup.DELETE("/document/del/:document_id", handler.StaffDocumentDel)
+	// 紧急联系人、家庭成员、教育经历及工作经历
+	staffGroup.POST("/record/:kind/save", handler.StaffRecordSave)
+	staffGroup.DELETE("/record/:kind/del/:record_id", handler.StaffRecordDel)
+	staffGroup.GET("/record/:kind/query/:staff_id", handler.StaffRecordQuery)
+	// 员工自助修改申请及人事审批
+	staffGroup.POST("/change/submit", handler.StaffChangeSubmit)
+	staffGroup.POST("/change/cancel/:request_id", handler.StaffChangeCancel)
+	staffGroup.POST("/change/review", handler.StaffChangeReview)
+	staffGroup.GET("/change/query/:staff_id", handler.StaffChangeQuery)
+	staffGroup.GET("/change/pending", handler.StaffChangePending)
+	// 劳动合同相关
+	contractGroup := server.Group("/contract")
+	contractGroup.POST("/create", handler.StaffContractCreate)
+	contractGroup.POST("/renew", handler.StaffContractRenew)
+	contractGroup.POST("/terminate", handler.StaffContractTerminate)
+	contractGroup.GET("/query/:staff_id", handler.StaffContractQuery)
+	contractGroup.GET("/expiring", handler.StaffContractExpiring)
+	// 试用期考核相关
+	probationGroup := server.Group("/probation")
+	probationGroup.POST("/evaluate", handler.ProbationEvaluate)
+	probationGroup.POST("/decide", handler.ProbationDecide)
+	probationGroup.GET("/query/:staff_id", handler.ProbationQuery)
+	probationGroup.GET("/pending", handler.ProbationPending)
+	// 个人信息访问日志相关
+	accessLogGroup := server.Group("/access_log")
+	accessLogGroup.GET("/query", handler.AccessLogQuery)
+	accessLogGroup.GET("/export", handler.AccessLogExport)
+	// 组织架构相关
+	orgGroup := server.Group("/org")
+	orgGroup.GET("/tree/:staff_id", handler.OrgTree)
+	orgGroup.GET("/chain/:staff_id", handler.OrgChain)
+	orgGroup.GET("/span/:staff_id", handler.OrgSpan)
+	orgGroup.GET("/cycles", handler.OrgCycles)
+	orgGroup.GET("/export/:staff_id", handler.OrgExport)
 	// 密码管理信息相关
 	passwordGroup := server.Group("/password")
 	passwordGroup.GET("/query/:staff_id", handler.PasswordQuery)
@@ -130,6 +195,9 @@ func routerInit(server *gin.Engine) {
 	notificationGroup.POST("/edit", handler.UpdateNotificationById)
 	notificationGroup.GET("/query/:notice_title", handler.GetNotificationByTitle)
 	notificationGroup.GET("/query", handler.GetNotificationByTitle)
+	// 生日、司龄纪念日及退休提醒的退订设置
+	notificationGroup.GET("/milestone/opt_out", handler.MilestoneOptOutQuery)
+	notificationGroup.POST("/milestone/opt_out", handler.MilestoneOptOutSet)
 	// 分公司相关
 	companyGroup := server.Group("/company")
 	companyGroup.GET("/query", handler.BranchCompanyQuery)
@@ -140,6 +208,7 @@ func routerInit(server *gin.Engine) {
 	salaryGroup.POST("/edit", handler.UpdateSalaryById)
 	salaryGroup.GET("/query/:staff_id", handler.GetSalaryByStaffId)
 	salaryGroup.GET("/query", handler.GetSalaryByStaffId)
+	salaryGroup.GET("/band_check", handler.SalaryBandCheck)
 	// 薪资发放相关
 	salaryRecordGroup := server.Group("/salary_record")
 	//salaryRecordGroup.POST("/create", handler.CreateSalaryRecord)
@@ -177,6 +246,7 @@ func routerInit(server *gin.Engine) {
 	candidateGroup.GET("/query_by_staff_id/:staff_id", handler.GetCandidateByStaffId)
 	candidateGroup.GET("/reject/:id", handler.SetCandidateRejectById)
 	candidateGroup.GET("/accept/:id", handler.SetCandidateAcceptById)
+	candidateGroup.GET("/reveal/:candidate_id", handler.CandidateReveal)
 	// 考试管理相关
 	exampleGroup := server.Group("/example")
 	exampleGroup.POST("/create", handler.CreateExample)
@@ -258,7 +328,8 @@ func InitGorm() error {
 
 			db, err = gorm.Open(sqlite.Dialector{
 				DriverName: "sqlite",
-				DSN:        dbPath + "?_pragma=foreign_keys(1)",
+				// 并发写入时等待锁释放，事务开始即获取写锁，避免并发生成编号时出现 SQLITE_BUSY
+				DSN: dbPath + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_txlock=immediate",
 			}, &gorm.Config{
 				NamingStrategy: schema.NamingStrategy{
 					// 全局禁止表名复数
@@ -317,6 +388,12 @@ func main() {
 	if err := InitGorm(); err != nil {
 		log.Fatal(err)
 	}
+	// 定时清理超出保留期限的访问日志
+	service.StartAccessLogPurge(resource.HrmsConf.AccessLog.RetentionDays)
+	// 定时生成合同及试用期到期提醒
+	service.StartContractReminder(resource.HrmsConf.Contract.ReminderDays, resource.HrmsConf.Contract.ProbationReminderDays)
+	// 定时生成生日、司龄纪念日及退休提醒
+	service.StartMilestoneNotice(resource.HrmsConf.Milestone)
 	if err := InitGin(); err != nil {
 		log.Fatal(err)
 	}
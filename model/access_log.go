@@ -0,0 +1,63 @@
+package model
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// 被访问的个人信息类型
+const (
+	AccessResourceStaff        = "staff"          // 员工详情
+	AccessResourceStaffHistory = "staff_history"  // 员工信息变更记录
+	AccessResourceStaffExport  = "staff_export"   // 员工信息导出
+	AccessResourceStaffReveal  = "staff_reveal"   // 查看员工敏感字段明文
+	AccessResourceSalary       = "salary"         // 薪资模板
+	AccessResourceSalaryRecord = "salary_record"  // 薪资发放记录
+	AccessResourcePassword     = "password"       // 账号密码
+	AccessResourceDocument     = "staff_document" // 员工档案文件
+	AccessResourceStaffRecord  = "staff_record"   // 紧急联系人、家庭成员等员工子记录
+	AccessResourceStaffChange  = "staff_change"   // 员工自助修改申请
+)
+
+var ErrAccessLogAppendOnly = errors.New("访问日志不允许修改或删除")
+
+// 个人信息访问日志，只允许追加，超出保留期限的记录由定时任务清理
+type AccessLog struct {
+	gorm.Model
+	LogId string `gorm:"column:log_id;size:64;uniqueIndex" json:"log_id"`
+	// 同一请求产生的日志使用相同的请求ID
+	RequestId  string `gorm:"column:request_id;size:64;index" json:"request_id"`
+	OperatorId string `gorm:"column:operator_id;size:64;index" json:"operator_id"`
+	UserType   string `gorm:"column:user_type" json:"user_type"`
+	// 被访问的员工工号
+	TargetStaffId string `gorm:"column:target_staff_id;size:64;index" json:"target_staff_id"`
+	Resource      string `gorm:"column:resource;size:32;index" json:"resource"`
+	// 以明文返回的敏感字段，多个用逗号分隔，为空表示敏感字段均已脱敏或不涉及
+	Fields   string `gorm:"column:fields" json:"fields"`
+	Path     string `gorm:"column:path" json:"path"`
+	ClientIp string `gorm:"column:client_ip" json:"client_ip"`
+}
+
+func (l AccessLog) TableName() string {
+	return "access_log"
+}
+
+func (l *AccessLog) BeforeUpdate(tx *gorm.DB) error {
+	return ErrAccessLogAppendOnly
+}
+
+func (l *AccessLog) BeforeDelete(tx *gorm.DB) error {
+	return ErrAccessLogAppendOnly
+}
+
+// 访问日志查询条件，均为可选
+type AccessLogQuery struct {
+	OperatorId    string `form:"operator_id"`
+	TargetStaffId string `form:"target_staff_id"`
+	Resource      string `form:"resource"`
+	RequestId     string `form:"request_id"`
+	// 访问日期范围，格式 YYYY-MM-DD，包含首尾两天
+	DateFrom string `form:"date_from"`
+	DateTo   string `form:"date_to"`
+}
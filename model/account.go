@@ -12,7 +12,7 @@ type LoginDTO struct {
 
 type Authority struct {
 	gorm.Model
-	AuthorityId  string `gorm:"column:authority_id" json:"authority_id"`
+	AuthorityId  string `gorm:"column:authority_id;size:64;uniqueIndex" json:"authority_id"`
 	StaffId      string `gorm:"column:staff_id" json:"staff_id"`
 	UserPassword string `gorm:"column:user_password" json:"user_password"`
 	//Aval         int64  `gorm:"column:aval" json:"aval"`
@@ -4,7 +4,7 @@ import "gorm.io/gorm"
 
 type AttendanceRecord struct {
 	gorm.Model
-	AttendanceId string `gorm:"column:attendance_id" json:"attendance_id"`
+	AttendanceId string `gorm:"column:attendance_id;size:64;uniqueIndex" json:"attendance_id"`
 	StaffId      string `gorm:"column:staff_id" json:"staff_id"`
 	StaffName    string `gorm:"column:staff_name" json:"staff_name"`
 	Date         string `gorm:"column:date" json:"date"`
@@ -2,7 +2,7 @@ package model
 
 type BranchCompany struct {
 	ID       int64  `gorm:"column:id" json:"id"`
-	BranchId string `gorm:"column:branch_id" json:"branch_id"`
+	BranchId string `gorm:"column:branch_id;size:64;uniqueIndex" json:"branch_id"`
 	Name     string `gorm:"column:name" json:"name"`
 	Desc     string `gorm:"column:desc" json:"desc"`
 }
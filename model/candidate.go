@@ -4,7 +4,7 @@ import "gorm.io/gorm"
 
 type Candidate struct {
 	gorm.Model
-	CandidateId string `gorm:"column:candidate_id" json:"candidate_id"`
+	CandidateId string `gorm:"column:candidate_id;size:64;uniqueIndex" json:"candidate_id"`
 	StaffId     string `gorm:"column:staff_id" json:"staff_id"`
 	Name        string `gorm:"column:name" json:"name"`
 	JobName     string `gorm:"column:job_name" json:"job_name"`
@@ -12,7 +12,7 @@ type Candidate struct {
 	Major       string `gorm:"column:major" json:"major"`
 	Experience  string `gorm:"column:experience" json:"experience"`
 	Describe    string `gorm:"column:describe" json:"describe"`
-	Email       string `gorm:"column:email" json:"email"`
+	Email       string `gorm:"column:email;type:varchar(255);serializer:encrypted" json:"email"`
 	Evaluation  string `gorm:"column:evaluation" json:"evaluation"`
 	Status      int64  `gorm:"column:status" json:"status"`
 }
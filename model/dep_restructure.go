@@ -0,0 +1,73 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 部门调整类型
+const (
+	DepRestructureMerge  = "merge"  // 将源部门并入目标部门
+	DepRestructureSplit  = "split"  // 将源部门的部分员工拆分到新部门
+	DepRestructureRename = "rename" // 部门更名
+)
+
+// 部门调整记录
+type DepRestructure struct {
+	gorm.Model
+	RestructureId string `gorm:"column:restructure_id;size:64;uniqueIndex" json:"restructure_id"`
+	Type          string `gorm:"column:type" json:"type"`
+	SourceDepId   string `gorm:"column:source_dep_id" json:"source_dep_id"`
+	// 调整前的源部门名称
+	SourceDepName string `gorm:"column:source_dep_name" json:"source_dep_name"`
+	// 合并的目标部门或拆分出的新部门，更名时与源部门相同
+	TargetDepId   string `gorm:"column:target_dep_id" json:"target_dep_id"`
+	TargetDepName string `gorm:"column:target_dep_name" json:"target_dep_name"`
+	// 调动的员工工号，逗号分隔
+	StaffIds      string    `gorm:"column:staff_ids" json:"staff_ids"`
+	StaffCount    int64     `gorm:"column:staff_count" json:"staff_count"`
+	EffectiveDate time.Time `gorm:"column:effective_date" json:"effective_date"`
+	Reason        string    `gorm:"column:reason" json:"reason"`
+	// 操作人工号
+	OperatorId string `gorm:"column:operator_id" json:"operator_id"`
+}
+
+func (r DepRestructure) TableName() string {
+	return "dep_restructure"
+}
+
+type DepRestructureDTO struct {
+	Type        string `json:"type" binding:"required"`
+	SourceDepId string `json:"source_dep_id" binding:"required"`
+	// 合并时必填
+	TargetDepId string `json:"target_dep_id"`
+	// 拆分、更名时必填
+	NewDepName     string `json:"new_dep_name"`
+	NewDepDescribe string `json:"new_dep_describe"`
+	// 拆分时必填，须均属于源部门
+	StaffIds []string `json:"staff_ids"`
+	// 格式 YYYY-MM-DD，为空时取当天
+	EffectiveDateStr string `json:"effective_date"`
+	Reason           string `json:"reason" binding:"required"`
+}
+
+// 受影响的员工
+type DepRestructureStaff struct {
+	StaffId   string `json:"staff_id"`
+	StaffName string `json:"staff_name"`
+	FromDepId string `json:"from_dep_id"`
+	// 拆分出的新部门尚未创建时为空
+	ToDepId string `json:"to_dep_id"`
+}
+
+// 部门调整预览
+type DepRestructurePreview struct {
+	Type      string     `json:"type"`
+	SourceDep Department `json:"source_dep"`
+	// 拆分时为将要创建的新部门，更名时为更名后的部门
+	TargetDep Department            `json:"target_dep"`
+	Staffs    []DepRestructureStaff `json:"staffs"`
+	// 合并时改挂到目标部门下的下级部门
+	ChildDeps []Department `json:"child_deps"`
+}
@@ -1,28 +1,78 @@
 package model
 
 import (
+	"time"
+
 	"gorm.io/gorm"
 )
 
 type DepartmentCreateDTO struct {
 	DepDescribe string `json:"dep_describe" binding:"required"`
 	DepName     string `json:"dep_name" binding:"required"`
+	ParentDepId string `json:"parent_dep_id"`
+	HeadStaffId string `json:"head_staff_id"`
+	CostCenter  string `json:"cost_center"`
+	// 生效日期、失效日期，格式 YYYY-MM-DD，为空表示不限
+	ValidFromStr string `json:"valid_from"`
+	ValidToStr   string `json:"valid_to"`
 }
 
+// 层级相关字段为空指针时不修改，为空字符串时清空
 type DepartmentEditDTO struct {
-	DepId       string `json:"dep_id" binding:"required"`
-	DepDescribe string `json:"dep_describe" binding:"required"`
-	DepName     string `json:"dep_name" binding:"required"`
+	DepId        string  `json:"dep_id" binding:"required"`
+	DepDescribe  string  `json:"dep_describe" binding:"required"`
+	DepName      string  `json:"dep_name" binding:"required"`
+	ParentDepId  *string `json:"parent_dep_id"`
+	HeadStaffId  *string `json:"head_staff_id"`
+	CostCenter   *string `json:"cost_center"`
+	ValidFromStr *string `json:"valid_from"`
+	ValidToStr   *string `json:"valid_to"`
 }
 
 type Department struct {
 	gorm.Model
-	DepId       string `gorm:"column:dep_id" db:"column:dep_id" json:"dep_id"`
+	DepId       string `gorm:"column:dep_id;size:64;uniqueIndex" db:"column:dep_id" json:"dep_id"`
 	DepDescribe string `gorm:"column:dep_describe" json:"dep_describe"`
 	DepName     string `gorm:"column:dep_name" db:"column:dep_name" json:"dep_name"`
+	// 上级部门编号，为空表示顶级部门
+	ParentDepId string `gorm:"column:parent_dep_id" json:"parent_dep_id"`
+	// 部门负责人工号
+	HeadStaffId string `gorm:"column:head_staff_id" json:"head_staff_id"`
+	// 成本中心编码
+	CostCenter string `gorm:"column:cost_center" json:"cost_center"`
+	// 零值表示不限
+	ValidFrom time.Time `gorm:"column:valid_from" json:"valid_from"`
+	ValidTo   time.Time `gorm:"column:valid_to" json:"valid_to"`
 }
 
 func (d *Department) AfterFind(tx *gorm.DB) (err error) {
 
 	return nil
 }
+
+// 部门在指定时间是否有效，失效日期当天仍有效
+func (d Department) IsActive(t time.Time) bool {
+	if !d.ValidFrom.IsZero() && t.Before(d.ValidFrom) {
+		return false
+	}
+	if !d.ValidTo.IsZero() && !t.Before(d.ValidTo.AddDate(0, 0, 1)) {
+		return false
+	}
+	return true
+}
+
+// 部门查询结果，含人数及薪资汇总
+type DepartmentVO struct {
+	Department
+	ParentDepName string `json:"parent_dep_name"`
+	HeadStaffName string `json:"head_staff_name"`
+	Active        bool   `json:"active"`
+	// 本部门在职人数
+	Headcount int64 `json:"headcount"`
+	// 含全部下级部门的在职人数
+	TotalHeadcount int64 `json:"total_headcount"`
+	// 本部门在职员工的月薪资合计（按薪资套账）
+	Payroll int64 `json:"payroll"`
+	// 含全部下级部门的月薪资合计
+	TotalPayroll int64 `json:"total_payroll"`
+}
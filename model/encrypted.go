@@ -0,0 +1,194 @@
+package model
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm/schema"
+)
+
+// 敏感字段加密配置，未配置密钥时按明文读写
+type CryptoConfig struct {
+	// 加密新数据使用的密钥编号
+	ActiveKeyId string `json:"activeKeyId"`
+	// 密钥编号到 base64 编码的32字节密钥，轮换期间需同时保留新旧密钥
+	Keys map[string]string `json:"keys"`
+	// 盲索引使用的 base64 编码密钥
+	BlindIndexKey string `json:"blindIndexKey"`
+}
+
+// 打印配置时隐藏密钥内容
+func (c CryptoConfig) String() string {
+	keyIds := make([]string, 0, len(c.Keys))
+	for keyId := range c.Keys {
+		keyIds = append(keyIds, keyId)
+	}
+	sort.Strings(keyIds)
+	return fmt.Sprintf("{activeKeyId:%v keys:%v}", c.ActiveKeyId, keyIds)
+}
+
+// 密文前缀，完整格式为 enc:<密钥编号>:<base64(nonce+密文)>
+const encryptedPrefix = "enc:"
+
+var fieldCrypto = struct {
+	activeKeyId string
+	aeads       map[string]cipher.AEAD
+	blindKey    []byte
+}{aeads: make(map[string]cipher.AEAD)}
+
+func init() {
+	schema.RegisterSerializer("encrypted", EncryptedSerializer{})
+}
+
+// 加载加密密钥，需在读写数据库前调用
+func InitFieldCrypto(conf CryptoConfig) error {
+	aeads := make(map[string]cipher.AEAD)
+	for keyId, encoded := range conf.Keys {
+		// 配置文件中的键会被统一转为小写
+		keyId = strings.ToLower(keyId)
+		if strings.Contains(keyId, ":") {
+			return fmt.Errorf("密钥编号 %v 不能包含冒号", keyId)
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(key) != 32 {
+			return fmt.Errorf("密钥 %v 应为 base64 编码的32字节密钥", keyId)
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return err
+		}
+		aeads[keyId] = aead
+	}
+	activeKeyId := strings.ToLower(conf.ActiveKeyId)
+	if activeKeyId != "" && aeads[activeKeyId] == nil {
+		return fmt.Errorf("未找到当前密钥 %v", conf.ActiveKeyId)
+	}
+	var blindKey []byte
+	if conf.BlindIndexKey != "" {
+		key, err := base64.StdEncoding.DecodeString(conf.BlindIndexKey)
+		if err != nil || len(key) < 16 {
+			return errors.New("盲索引密钥应为 base64 编码且不少于16字节")
+		}
+		blindKey = key
+	} else if activeKeyId != "" {
+		return errors.New("启用加密时必须配置盲索引密钥")
+	}
+	fieldCrypto.activeKeyId = activeKeyId
+	fieldCrypto.aeads = aeads
+	fieldCrypto.blindKey = blindKey
+	return nil
+}
+
+// 计算盲索引，相同明文得到相同结果，用于加密字段的等值查询及唯一性校验
+func BlindIndex(value string) string {
+	mac := hmac.New(sha256.New, fieldCrypto.blindKey)
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// 使用当前密钥加密，column 作为附加数据防止密文被挪用到其他字段
+func encryptField(column, plaintext string) (string, error) {
+	aead := fieldCrypto.aeads[fieldCrypto.activeKeyId]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), []byte(column))
+	return encryptedPrefix + fieldCrypto.activeKeyId + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// 解密字段，非密文格式的历史数据原样返回
+func decryptField(column, value string) (string, error) {
+	if !strings.HasPrefix(value, encryptedPrefix) {
+		return value, nil
+	}
+	parts := strings.SplitN(strings.TrimPrefix(value, encryptedPrefix), ":", 2)
+	if len(parts) != 2 {
+		return "", fmt.Errorf("字段 %v 密文格式错误", column)
+	}
+	aead, ok := fieldCrypto.aeads[parts[0]]
+	if !ok {
+		return "", fmt.Errorf("字段 %v 使用的密钥 %v 未配置", column, parts[0])
+	}
+	sealed, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", fmt.Errorf("字段 %v 密文格式错误", column)
+	}
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(column))
+	if err != nil {
+		return "", fmt.Errorf("字段 %v 解密失败: %v", column, err)
+	}
+	return string(plaintext), nil
+}
+
+// 字段级加密序列化器，支持 string 及 int64 字段，使用方式为 gorm:"serializer:encrypted"
+type EncryptedSerializer struct{}
+
+func (EncryptedSerializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	var value string
+	switch v := dbValue.(type) {
+	case nil:
+	case string:
+		value = v
+	case []byte:
+		value = string(v)
+	default:
+		value = fmt.Sprint(v)
+	}
+	plaintext, err := decryptField(field.DBName, value)
+	if err != nil {
+		return err
+	}
+	fieldValue := reflect.New(field.FieldType).Elem()
+	switch field.FieldType.Kind() {
+	case reflect.String:
+		fieldValue.SetString(plaintext)
+	case reflect.Int64:
+		if plaintext != "" {
+			n, err := strconv.ParseInt(plaintext, 10, 64)
+			if err != nil {
+				return fmt.Errorf("字段 %v 不是有效的整数: %v", field.DBName, err)
+			}
+			fieldValue.SetInt(n)
+		}
+	default:
+		return fmt.Errorf("字段 %v 的类型不支持加密", field.DBName)
+	}
+	field.ReflectValueOf(ctx, dst).Set(fieldValue)
+	return nil
+}
+
+func (EncryptedSerializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	var plaintext string
+	switch v := fieldValue.(type) {
+	case string:
+		plaintext = v
+	case int64:
+		if v != 0 {
+			plaintext = strconv.FormatInt(v, 10)
+		}
+	default:
+		return nil, fmt.Errorf("字段 %v 的类型不支持加密", field.DBName)
+	}
+	// 未启用加密或空值时按明文写入
+	if fieldCrypto.activeKeyId == "" || plaintext == "" {
+		return fieldValue, nil
+	}
+	return encryptField(field.DBName, plaintext)
+}
@@ -0,0 +1,211 @@
+package model
+
+import (
+	"encoding/base64"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+	_ "modernc.org/sqlite"
+)
+
+// 生成固定内容的测试密钥
+func testKey(b byte) string {
+	return base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string(b), 32)))
+}
+
+// 加载测试密钥，测试结束后恢复为不加密
+func setCrypto(t *testing.T, conf CryptoConfig) {
+	t.Helper()
+	if err := InitFieldCrypto(conf); err != nil {
+		t.Fatalf("InitFieldCrypto err = %v", err)
+	}
+	t.Cleanup(func() { InitFieldCrypto(CryptoConfig{}) })
+}
+
+func TestInitFieldCrypto(t *testing.T) {
+	t.Cleanup(func() { InitFieldCrypto(CryptoConfig{}) })
+	cases := []struct {
+		name    string
+		conf    CryptoConfig
+		wantErr bool
+	}{
+		{"未配置", CryptoConfig{}, false},
+		{"正常", CryptoConfig{ActiveKeyId: "k1", Keys: map[string]string{"k1": testKey('a')}, BlindIndexKey: testKey('b')}, false},
+		{"编号大小写", CryptoConfig{ActiveKeyId: "K1", Keys: map[string]string{"K1": testKey('a')}, BlindIndexKey: testKey('b')}, false},
+		{"只配置解密密钥", CryptoConfig{Keys: map[string]string{"k1": testKey('a')}}, false},
+		{"密钥长度错误", CryptoConfig{ActiveKeyId: "k1", Keys: map[string]string{"k1": base64.StdEncoding.EncodeToString([]byte("short"))}, BlindIndexKey: testKey('b')}, true},
+		{"密钥非base64", CryptoConfig{ActiveKeyId: "k1", Keys: map[string]string{"k1": "not base64!"}, BlindIndexKey: testKey('b')}, true},
+		{"当前密钥不存在", CryptoConfig{ActiveKeyId: "k2", Keys: map[string]string{"k1": testKey('a')}, BlindIndexKey: testKey('b')}, true},
+		{"编号包含冒号", CryptoConfig{ActiveKeyId: "k:1", Keys: map[string]string{"k:1": testKey('a')}, BlindIndexKey: testKey('b')}, true},
+		{"缺少盲索引密钥", CryptoConfig{ActiveKeyId: "k1", Keys: map[string]string{"k1": testKey('a')}}, true},
+		{"盲索引密钥过短", CryptoConfig{ActiveKeyId: "k1", Keys: map[string]string{"k1": testKey('a')}, BlindIndexKey: base64.StdEncoding.EncodeToString([]byte("short"))}, true},
+	}
+	for _, tc := range cases {
+		if err := InitFieldCrypto(tc.conf); (err != nil) != tc.wantErr {
+			t.Errorf("%v: InitFieldCrypto err = %v, wantErr %v", tc.name, err, tc.wantErr)
+		}
+	}
+}
+
+func TestEncryptDecryptField(t *testing.T) {
+	setCrypto(t, CryptoConfig{ActiveKeyId: "k1", Keys: map[string]string{"k1": testKey('a')}, BlindIndexKey: testKey('b')})
+	for _, plaintext := range []string{"460034199905215518", "13912345678", "北京市海淀区", " "} {
+		encrypted, err := encryptField("identity_num", plaintext)
+		if err != nil {
+			t.Fatalf("encryptField(%q) err = %v", plaintext, err)
+		}
+		if !strings.HasPrefix(encrypted, "enc:k1:") || strings.Contains(encrypted, plaintext) {
+			t.Errorf("encryptField(%q) = %q", plaintext, encrypted)
+		}
+		decrypted, err := decryptField("identity_num", encrypted)
+		if err != nil || decrypted != plaintext {
+			t.Errorf("decryptField(%q) = %q, %v, want %q", encrypted, decrypted, err, plaintext)
+		}
+		// 密文不能挪用到其他字段
+		if _, err := decryptField("card_num", encrypted); err == nil {
+			t.Errorf("decryptField 使用其他字段名解密应返回错误")
+		}
+	}
+	// 相同明文每次加密结果不同
+	a, _ := encryptField("phone", "13912345678")
+	b, _ := encryptField("phone", "13912345678")
+	if a == b {
+		t.Errorf("相同明文的密文不应相同: %q", a)
+	}
+	// 非密文格式的历史数据原样返回
+	for _, value := range []string{"", "460034199905215518", "encrypted"} {
+		if got, err := decryptField("identity_num", value); err != nil || got != value {
+			t.Errorf("decryptField(%q) = %q, %v", value, got, err)
+		}
+	}
+	for _, value := range []string{"enc:k1", "enc:k1:!!!", "enc:k1:YWJj", "enc:k9:" + strings.TrimPrefix(a, "enc:k1:")} {
+		if _, err := decryptField("phone", value); err == nil {
+			t.Errorf("decryptField(%q) 应返回错误", value)
+		}
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	setCrypto(t, CryptoConfig{ActiveKeyId: "k1", Keys: map[string]string{"k1": testKey('a')}, BlindIndexKey: testKey('b')})
+	old, err := encryptField("phone", "13912345678")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 轮换期间按密文中的密钥编号解密，新数据使用新密钥加密
+	setCrypto(t, CryptoConfig{ActiveKeyId: "k2", Keys: map[string]string{"k1": testKey('a'), "k2": testKey('c')}, BlindIndexKey: testKey('b')})
+	if got, err := decryptField("phone", old); err != nil || got != "13912345678" {
+		t.Errorf("decryptField(%q) = %q, %v", old, got, err)
+	}
+	cur, err := encryptField("phone", "13912345678")
+	if err != nil || !strings.HasPrefix(cur, "enc:k2:") {
+		t.Fatalf("encryptField = %q, %v", cur, err)
+	}
+
+	// 移除旧密钥后旧密文无法解密
+	setCrypto(t, CryptoConfig{ActiveKeyId: "k2", Keys: map[string]string{"k2": testKey('c')}, BlindIndexKey: testKey('b')})
+	if _, err := decryptField("phone", old); err == nil {
+		t.Errorf("旧密钥移除后解密应返回错误")
+	}
+	if got, err := decryptField("phone", cur); err != nil || got != "13912345678" {
+		t.Errorf("decryptField(%q) = %q, %v", cur, got, err)
+	}
+
+	// 同一编号对应的密钥被替换时解密失败
+	setCrypto(t, CryptoConfig{ActiveKeyId: "k2", Keys: map[string]string{"k2": testKey('d')}, BlindIndexKey: testKey('b')})
+	if _, err := decryptField("phone", cur); err == nil {
+		t.Errorf("密钥不匹配时解密应返回错误")
+	}
+}
+
+func TestBlindIndex(t *testing.T) {
+	setCrypto(t, CryptoConfig{ActiveKeyId: "k1", Keys: map[string]string{"k1": testKey('a')}, BlindIndexKey: testKey('b')})
+	a := BlindIndex("460034199905215518")
+	if len(a) != 64 {
+		t.Errorf("BlindIndex 长度 = %d, want 64", len(a))
+	}
+	if BlindIndex("460034199905215518") != a {
+		t.Errorf("相同明文的盲索引应相同")
+	}
+	if BlindIndex("460034199905215519") == a {
+		t.Errorf("不同明文的盲索引不应相同")
+	}
+	// 盲索引只与盲索引密钥有关，轮换加密密钥不影响
+	setCrypto(t, CryptoConfig{ActiveKeyId: "k2", Keys: map[string]string{"k2": testKey('c')}, BlindIndexKey: testKey('b')})
+	if BlindIndex("460034199905215518") != a {
+		t.Errorf("轮换加密密钥后盲索引不应变化")
+	}
+	setCrypto(t, CryptoConfig{ActiveKeyId: "k2", Keys: map[string]string{"k2": testKey('c')}, BlindIndexKey: testKey('e')})
+	if BlindIndex("460034199905215518") == a {
+		t.Errorf("更换盲索引密钥后盲索引应变化")
+	}
+}
+
+func TestEncryptedSerializer(t *testing.T) {
+	db, err := gorm.Open(sqlite.Dialector{
+		DriverName: "sqlite",
+		DSN:        filepath.Join(t.TempDir(), "test.db"),
+	}, &gorm.Config{
+		NamingStrategy: schema.NamingStrategy{SingularTable: true},
+		Logger:         logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		defer sqlDB.Close()
+	}
+	if err := db.AutoMigrate(&Staff{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 未启用加密时写入的明文在启用后仍可读取
+	if err := db.Create(&Staff{StaffId: "H00001", IdentityNum: "460034199905215518", Phone: 13912345678}).Error; err != nil {
+		t.Fatal(err)
+	}
+	setCrypto(t, CryptoConfig{ActiveKeyId: "k1", Keys: map[string]string{"k1": testKey('a')}, BlindIndexKey: testKey('b')})
+	if err := db.Create(&Staff{StaffId: "H00002", IdentityNum: "11010519491231002X", Phone: 13800000000, CardNum: "6222000011112222"}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	var raw struct {
+		IdentityNum  string
+		Phone        string
+		CardNum      string
+		IdentityHash string
+	}
+	db.Raw("select identity_num, phone, card_num, identity_hash from staff where staff_id = ?", "H00002").Scan(&raw)
+	for _, value := range []string{raw.IdentityNum, raw.Phone, raw.CardNum} {
+		if !strings.HasPrefix(value, "enc:k1:") {
+			t.Errorf("数据库中应为密文, got %q", value)
+		}
+	}
+	if raw.IdentityHash != BlindIndex("11010519491231002X") {
+		t.Errorf("identity_hash = %q, want %q", raw.IdentityHash, BlindIndex("11010519491231002X"))
+	}
+
+	cases := []struct {
+		staffId     string
+		identityNum string
+		phone       int64
+		cardNum     string
+	}{
+		{"H00001", "460034199905215518", 13912345678, ""},
+		{"H00002", "11010519491231002X", 13800000000, "6222000011112222"},
+	}
+	for _, tc := range cases {
+		var staff Staff
+		if err := db.Where("staff_id = ?", tc.staffId).First(&staff).Error; err != nil {
+			t.Fatalf("读取 %v 失败: %v", tc.staffId, err)
+		}
+		if staff.IdentityNum != tc.identityNum || staff.Phone != tc.phone || staff.CardNum != tc.cardNum {
+			t.Errorf("%v = %q, %v, %q, want %q, %v, %q", tc.staffId, staff.IdentityNum, staff.Phone, staff.CardNum,
+				tc.identityNum, tc.phone, tc.cardNum)
+		}
+	}
+}
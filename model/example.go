@@ -6,7 +6,7 @@ import (
 
 type Example struct {
 	gorm.Model
-	ExampleId string `gorm:"column:example_id" json:"example_id"`
+	ExampleId string `gorm:"column:example_id;size:64;uniqueIndex" json:"example_id"`
 	Name      string `gorm:"column:name" json:"name"`
 	Describe  string `gorm:"column:describe" json:"describe"`
 	Date      string `gorm:"column:date" json:"date"`
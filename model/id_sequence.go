@@ -0,0 +1,16 @@
+package model
+
+import "gorm.io/gorm"
+
+// 业务编号序列，每个分公司数据库独立维护
+// Scope 为编号格式中除序号外的部分，如 C001-2026-{seq}，年份等变化时序号重新从1开始
+type IdSequence struct {
+	gorm.Model
+	Name  string `gorm:"column:name;size:32;uniqueIndex:idx_id_sequence_scope" json:"name"`
+	Scope string `gorm:"column:scope;size:64;uniqueIndex:idx_id_sequence_scope" json:"scope"`
+	Value int64  `gorm:"column:value" json:"value"`
+}
+
+func (s IdSequence) TableName() string {
+	return "id_sequence"
+}
@@ -0,0 +1,38 @@
+package model
+
+import "gorm.io/gorm"
+
+// 员工纪念日通知类型
+const (
+	MilestoneBirthday    = "birthday"    // 生日
+	MilestoneAnniversary = "anniversary" // 司龄纪念日
+	MilestoneRetirement  = "retirement"  // 即将到达退休年龄
+)
+
+var MilestoneNames = map[string]string{
+	MilestoneBirthday:    "生日祝福",
+	MilestoneAnniversary: "司龄纪念日",
+	MilestoneRetirement:  "退休提醒",
+}
+
+// 员工不接收的纪念日通知，每类一条；退休提醒仍会通知人事
+type MilestoneOptOut struct {
+	gorm.Model
+	StaffId string `gorm:"column:staff_id;size:64;uniqueIndex:idx_milestone_opt_out" json:"staff_id"`
+	Kind    string `gorm:"column:kind;size:16;uniqueIndex:idx_milestone_opt_out" json:"kind"`
+}
+
+func (o MilestoneOptOut) TableName() string {
+	return "milestone_opt_out"
+}
+
+type MilestoneOptOutVO struct {
+	Kind   string `json:"kind"`
+	Name   string `json:"name"`
+	OptOut bool   `json:"opt_out"`
+}
+
+// 设置本人不接收的通知类型，为空表示全部接收
+type MilestoneOptOutDTO struct {
+	Kinds []string `json:"kinds"`
+}
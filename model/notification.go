@@ -5,13 +5,25 @@ import (
 	"time"
 )
 
+// 通知可见范围
+const (
+	NoticeAudienceAll   = ""      // 全体员工
+	NoticeAudienceAdmin = "admin" // 仅管理员（人事）
+	NoticeAudienceStaff = "staff" // 仅指定员工本人
+)
+
 type Notification struct {
 	gorm.Model
-	NoticeId      string    `gorm:"column:notice_id" json:"notice_id"`
+	NoticeId      string    `gorm:"column:notice_id;size:64;uniqueIndex" json:"notice_id"`
 	NoticeTitle   string    `gorm:"column:notice_title" json:"notice_title"`
 	NoticeContent string    `gorm:"column:notice_content" json:"notice_content"`
 	Type          string    `gorm:"column:type" json:"type"`
 	Date          time.Time `gorm:"column:date" json:"date"`
+	Audience      string    `gorm:"column:audience;size:16;default:''" json:"audience"`
+	// 个人消息的接收员工
+	StaffId string `gorm:"column:staff_id;size:64;index" json:"staff_id,omitempty"`
+	// 定时任务生成的通知的去重标识，如 contract_expiry:<合同编号>；手工发布的通知为 NULL，不参与唯一性校验
+	SourceKey *string `gorm:"column:source_key;size:128;uniqueIndex:idx_notification_source_key_unique" json:"-"`
 }
 
 type NotificationEditDTO struct {
@@ -0,0 +1,38 @@
+package model
+
+// 组织架构树节点
+type OrgNode struct {
+	StaffId       string `json:"staff_id"`
+	StaffName     string `json:"staff_name"`
+	LeaderStaffId string `json:"leader_staff_id"`
+	DepId         string `json:"dep_id"`
+	DepName       string `json:"dep_name"`
+	RankId        string `json:"rank_id"`
+	// 直接下属人数
+	DirectReports int `json:"direct_reports"`
+	// 全部下属人数（含间接下属）
+	TotalReports int `json:"total_reports"`
+	// 处于汇报关系环路中
+	InCycle  bool       `json:"in_cycle,omitempty"`
+	Children []*OrgNode `json:"children,omitempty"`
+}
+
+// 单个管理者的管理幅度
+type OrgSpan struct {
+	StaffId       string `json:"staff_id"`
+	StaffName     string `json:"staff_name"`
+	DirectReports int    `json:"direct_reports"`
+	TotalReports  int    `json:"total_reports"`
+	// 所在层级，最高负责人为1
+	Level int `json:"level"`
+}
+
+// 管理幅度统计
+type OrgSpanStats struct {
+	Headcount int       `json:"headcount"`
+	Managers  int       `json:"managers"`
+	AvgSpan   float64   `json:"avg_span"`
+	MaxSpan   int       `json:"max_span"`
+	MaxDepth  int       `json:"max_depth"`
+	Spans     []OrgSpan `json:"spans"`
+}
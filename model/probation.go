@@ -0,0 +1,105 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 试用期考核状态
+const (
+	ProbationStatusPending    = "pending"    // 待上级评估
+	ProbationStatusEvaluated  = "evaluated"  // 上级已评估，待人事确认
+	ProbationStatusConverted  = "converted"  // 已转正
+	ProbationStatusTerminated = "terminated" // 未通过试用期或试用期内离职
+)
+
+var ProbationStatusNames = map[string]string{
+	ProbationStatusPending:    "待上级评估",
+	ProbationStatusEvaluated:  "待人事确认",
+	ProbationStatusConverted:  "已转正",
+	ProbationStatusTerminated: "已终止",
+}
+
+// 上级评估建议及人事确认结果
+const (
+	ProbationDecisionConvert   = "convert"   // 转正
+	ProbationDecisionExtend    = "extend"    // 延长试用期
+	ProbationDecisionTerminate = "terminate" // 不予录用
+)
+
+var ProbationDecisionNames = map[string]string{
+	ProbationDecisionConvert:   "转正",
+	ProbationDecisionExtend:    "延长试用期",
+	ProbationDecisionTerminate: "不予录用",
+}
+
+// 员工试用期考核记录，以试用期状态入职时创建
+type StaffProbation struct {
+	gorm.Model
+	ProbationId string    `gorm:"column:probation_id;size:64;uniqueIndex" json:"probation_id"`
+	StaffId     string    `gorm:"column:staff_id;size:64;index" json:"staff_id"`
+	StaffName   string    `gorm:"column:staff_name" json:"staff_name"`
+	StartDate   time.Time `gorm:"column:start_date" json:"start_date"`
+	// 试用期结束日期，延长试用期时顺延
+	EndDate time.Time `gorm:"column:end_date" json:"end_date"`
+	// 入职时约定的试用期结束日期
+	OriginalEndDate time.Time `gorm:"column:original_end_date" json:"original_end_date"`
+	ExtendCount     int64     `gorm:"column:extend_count" json:"extend_count"`
+	Status          string    `gorm:"column:status;size:16;index" json:"status"`
+	// 上级评估，延长试用期后需重新评估
+	EvaluatorId    string    `gorm:"column:evaluator_id" json:"evaluator_id"`
+	EvalScore      int64     `gorm:"column:eval_score" json:"eval_score"`
+	EvalComment    string    `gorm:"column:eval_comment" json:"eval_comment"`
+	Recommendation string    `gorm:"column:recommendation" json:"recommendation"`
+	EvalDate       time.Time `gorm:"column:eval_date" json:"eval_date"`
+	// 人事确认
+	Decision       string    `gorm:"column:decision" json:"decision"`
+	DecisionRemark string    `gorm:"column:decision_remark" json:"decision_remark"`
+	DeciderId      string    `gorm:"column:decider_id" json:"decider_id"`
+	DecisionDate   time.Time `gorm:"column:decision_date" json:"decision_date"`
+	// 转正时调整的基本工资，未调整时为0
+	SalaryBaseBefore int64 `gorm:"column:salary_base_before" json:"salary_base_before"`
+	SalaryBaseAfter  int64 `gorm:"column:salary_base_after" json:"salary_base_after"`
+}
+
+func (p StaffProbation) TableName() string {
+	return "staff_probation"
+}
+
+type StaffProbationVO struct {
+	StaffProbation
+	StatusName         string `json:"status_name"`
+	RecommendationName string `json:"recommendation_name"`
+	DecisionName       string `json:"decision_name"`
+}
+
+type ProbationEvaluateDTO struct {
+	ProbationId string `json:"probation_id" binding:"required"`
+	// 评分，0-100
+	EvalScore      int64  `json:"eval_score"`
+	EvalComment    string `json:"eval_comment" binding:"required"`
+	Recommendation string `json:"recommendation" binding:"required"`
+}
+
+type ProbationDecideDTO struct {
+	ProbationId string `json:"probation_id" binding:"required"`
+	Decision    string `json:"decision" binding:"required"`
+	// 转正或终止的生效日期，格式 YYYY-MM-DD，默认为试用期结束日期
+	EffectiveDate string `json:"effective_date"`
+	// 延长的月数，仅延长试用期时填写
+	ExtendMonths int64  `json:"extend_months"`
+	Remark       string `json:"remark"`
+	// 转正后的薪资，仅转正时填写，为空时不调整薪资
+	Salary *ProbationSalaryDTO `json:"salary"`
+}
+
+// 转正后的薪资，按此更新员工的薪资模板
+type ProbationSalaryDTO struct {
+	Base       int64 `json:"base" binding:"required"`
+	Subsidy    int64 `json:"subsidy"`
+	Bonus      int64 `json:"bonus"`
+	Commission int64 `json:"commission"`
+	Other      int64 `json:"other"`
+	Fund       int64 `json:"fund"`
+}
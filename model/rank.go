@@ -1,22 +1,110 @@
 package model
 
-import "gorm.io/gorm"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type Rank struct {
 	gorm.Model
-	RankId   string `gorm:"column:rank_id" json:"rank_id"`
+	RankId   string `gorm:"column:rank_id;size:64;uniqueIndex" json:"rank_id"`
 	RankName string `gorm:"column:rank_name" json:"rank_name"`
+	// 职级等级，数字越大职级越高，用于区分晋升及降级
+	Level int64 `gorm:"column:level" json:"level"`
+	// 职族，如技术、管理、销售
+	JobFamily string `gorm:"column:job_family" json:"job_family"`
+	// 基本工资的薪资带宽，均为0时不设带宽
+	SalaryMin int64 `gorm:"column:salary_min" json:"salary_min"`
+	SalaryMid int64 `gorm:"column:salary_mid" json:"salary_mid"`
+	SalaryMax int64 `gorm:"column:salary_max" json:"salary_max"`
 }
 
 type RankCreateDTO struct {
-	RankName string `json:"rank_name" binding:"required"`
+	RankName  string `json:"rank_name" binding:"required"`
+	Level     int64  `json:"level"`
+	JobFamily string `json:"job_family"`
+	SalaryMin int64  `json:"salary_min"`
+	SalaryMid int64  `json:"salary_mid"`
+	SalaryMax int64  `json:"salary_max"`
 }
 
+// 未填写的字段保持不变
 type RankEditDTO struct {
-	RankId   string `json:"rank_id" binding:"required"`
-	RankName string `json:"rank_name" binding:"required"`
+	RankId    string  `json:"rank_id" binding:"required"`
+	RankName  string  `json:"rank_name" binding:"required"`
+	Level     *int64  `json:"level"`
+	JobFamily *string `json:"job_family"`
+	SalaryMin *int64  `json:"salary_min"`
+	SalaryMid *int64  `json:"salary_mid"`
+	SalaryMax *int64  `json:"salary_max"`
 }
 
 func (d Rank) TableName() string {
 	return "rank"
 }
+
+// 职级变动类型
+const (
+	RankChangePromotion = "promotion" // 晋升
+	RankChangeDemotion  = "demotion"  // 降级
+	RankChangeLateral   = "lateral"   // 平调
+)
+
+var RankChangeNames = map[string]string{
+	RankChangePromotion: "晋升",
+	RankChangeDemotion:  "降级",
+	RankChangeLateral:   "平调",
+}
+
+// 员工职级变动记录，按变动前后的职级等级区分晋升、降级及平调
+type RankChange struct {
+	gorm.Model
+	ChangeId      string    `gorm:"column:change_id;size:64;uniqueIndex" json:"change_id"`
+	StaffId       string    `gorm:"column:staff_id;size:64;index" json:"staff_id"`
+	StaffName     string    `gorm:"column:staff_name" json:"staff_name"`
+	FromRankId    string    `gorm:"column:from_rank_id" json:"from_rank_id"`
+	FromRankName  string    `gorm:"column:from_rank_name" json:"from_rank_name"`
+	FromLevel     int64     `gorm:"column:from_level" json:"from_level"`
+	ToRankId      string    `gorm:"column:to_rank_id" json:"to_rank_id"`
+	ToRankName    string    `gorm:"column:to_rank_name" json:"to_rank_name"`
+	ToLevel       int64     `gorm:"column:to_level" json:"to_level"`
+	ChangeType    string    `gorm:"column:change_type" json:"change_type"`
+	EffectiveDate time.Time `gorm:"column:effective_date" json:"effective_date"`
+	Reason        string    `gorm:"column:reason" json:"reason"`
+	OperatorId    string    `gorm:"column:operator_id" json:"operator_id"`
+}
+
+func (r RankChange) TableName() string {
+	return "rank_change"
+}
+
+type RankChangeVO struct {
+	RankChange
+	ChangeTypeName string `json:"change_type_name"`
+}
+
+type RankChangeDTO struct {
+	StaffId string `json:"staff_id" binding:"required"`
+	RankId  string `json:"rank_id" binding:"required"`
+	// 生效日期，格式 YYYY-MM-DD，默认为当天
+	EffectiveDate string `json:"effective_date"`
+	Reason        string `json:"reason" binding:"required"`
+}
+
+// 薪资模板的基本工资与员工职级带宽的比对结果
+type SalaryBandCheck struct {
+	SalaryId  string `json:"salary_id"`
+	StaffId   string `json:"staff_id"`
+	StaffName string `json:"staff_name"`
+	RankId    string `json:"rank_id"`
+	RankName  string `json:"rank_name"`
+	Base      int64  `json:"base"`
+	SalaryMin int64  `json:"salary_min"`
+	SalaryMid int64  `json:"salary_mid"`
+	SalaryMax int64  `json:"salary_max"`
+	// below 低于带宽下限，above 高于带宽上限
+	Deviation string `json:"deviation"`
+	// 基本工资与带宽中位值之比
+	CompaRatio float64 `json:"compa_ratio"`
+}
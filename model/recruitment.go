@@ -4,7 +4,7 @@ import "gorm.io/gorm"
 
 type Recruitment struct {
 	gorm.Model
-	RecruitmentId string `gorm:"column:recruitment_id" json:"recruitment_id"`
+	RecruitmentId string `gorm:"column:recruitment_id;size:64;uniqueIndex" json:"recruitment_id"`
 	JobName       string `gorm:"column:job_name" json:"job_name"`
 	JobType       string `gorm:"column:job_type" json:"job_type"`
 	BaseLocation  string `gorm:"column:base_location" json:"base_location"`
@@ -6,7 +6,7 @@ import (
 
 type Salary struct {
 	gorm.Model
-	SalaryId   string `gorm:"column:salary_id" json:"salary_id"`
+	SalaryId   string `gorm:"column:salary_id;size:64;uniqueIndex" json:"salary_id"`
 	StaffId    string `gorm:"column:staff_id" json:"staff_id"`
 	StaffName  string `gorm:"column:staff_name" json:"staff_name"`
 	Base       int64  `gorm:"column:base" json:"base"`
@@ -42,7 +42,7 @@ type SalaryEditDTO struct {
 
 type SalaryRecord struct {
 	gorm.Model
-	SalaryRecordId        string  `gorm:"column:salary_record_id" json:"salary_record_id"`
+	SalaryRecordId        string  `gorm:"column:salary_record_id;size:64;uniqueIndex" json:"salary_record_id"`
 	StaffId               string  `gorm:"column:staff_id" json:"staff_id"`
 	StaffName             string  `gorm:"column:staff_name" json:"staff_name"`
 	Base                  int64   `gorm:"column:base" json:"base"`
@@ -0,0 +1,36 @@
+package model
+
+import "gorm.io/gorm"
+
+// 敏感字段名称
+const (
+	SensitiveIdentityNum = "identity_num"
+	SensitiveCardNum     = "card_num"
+	SensitivePhone       = "phone"
+	SensitiveEmail       = "email"
+	SensitiveAddress     = "address"
+)
+
+// 查看明文的记录类型
+const (
+	RevealRecordStaff     = "staff"
+	RevealRecordCandidate = "candidate"
+	// 明文导出员工信息，RecordId 为导出的筛选条件
+	RevealRecordStaffExport = "staff_export"
+)
+
+// 敏感字段明文查看记录，每查看一个字段记录一条
+type SensitiveRevealLog struct {
+	gorm.Model
+	LogId      string `gorm:"column:log_id;size:64;uniqueIndex" json:"log_id"`
+	OperatorId string `gorm:"column:operator_id;index" json:"operator_id"`
+	UserType   string `gorm:"column:user_type" json:"user_type"`
+	RecordType string `gorm:"column:record_type" json:"record_type"`
+	RecordId   string `gorm:"column:record_id;index" json:"record_id"`
+	Field      string `gorm:"column:field" json:"field"`
+	ClientIp   string `gorm:"column:client_ip" json:"client_ip"`
+}
+
+func (l SensitiveRevealLog) TableName() string {
+	return "sensitive_reveal_log"
+}
@@ -7,24 +7,47 @@ import (
 
 type Staff struct {
 	gorm.Model
-	StaffId       string    `gorm:"column:staff_id" json:"staff_id"`
+	StaffId       string    `gorm:"column:staff_id;size:64;uniqueIndex" json:"staff_id"`
 	StaffName     string    `gorm:"column:staff_name" json:"staff_name"`
 	LeaderStaffId string    `gorm:"column:leader_staff_id" json:"leader_staff_id"`
 	LeaderName    string    `gorm:"column:leader_name" json:"leader_name"`
 	Birthday      time.Time `gorm:"column:birthday" json:"birthday"`
-	IdentityNum   string    `gorm:"column:identity_num" json:"identity_num"`
+	IdentityNum   string    `gorm:"column:identity_num;type:varchar(255);serializer:encrypted" json:"identity_num"`
 	Sex           int64     `gorm:"column:sex" json:"sex"`
 	Nation        string    `gorm:"column:nation" json:"nation"`
 	School        string    `gorm:"column:school" json:"school"`
 	Major         string    `gorm:"column:major" json:"major"`
 	EduLevel      string    `gorm:"column:edu_level" json:"edu_level"`
 	BaseSalary    int64     `gorm:"column:base_salary" json:"base_salary"`
-	CardNum       string    `gorm:"column:card_num" json:"card_num"`
+	CardNum       string    `gorm:"column:card_num;type:varchar(255);serializer:encrypted" json:"card_num"`
 	RankId        string    `gorm:"column:rank_id" json:"rank_id"`
 	DepId         string    `gorm:"column:dep_id" json:"dep_id"`
 	Email         string    `gorm:"column:email" json:"email"`
-	Phone         int64     `gorm:"column:phone" json:"phone"`
+	Phone         int64     `gorm:"column:phone;type:varchar(255);serializer:encrypted" json:"phone"`
 	EntryDate     time.Time `gorm:"column:entry_date" json:"entry_date"`
+	// 现居住地址
+	Address string `gorm:"column:address;type:varchar(512);serializer:encrypted" json:"address"`
+	// 在职状态，取值见 StaffStatus 常量
+	Status int64 `gorm:"column:status;default:2" json:"status"`
+	// 当前状态的生效日期
+	StatusDate time.Time `gorm:"column:status_date" json:"status_date"`
+	// 身份证号盲索引，身份证号加密存储后用于唯一性校验及按身份证号查询
+	// 未填写身份证号或尚未生成盲索引的历史数据为 NULL，不参与唯一性校验
+	IdentityHash *string `gorm:"column:identity_hash;size:64;uniqueIndex:idx_staff_identity_hash_unique" json:"-"`
+}
+
+// 写入身份证号时同步更新盲索引
+func (s *Staff) BeforeSave(tx *gorm.DB) error {
+	staff := s
+	// 以 Model(&Staff{}).Updates(staff) 方式更新时，身份证号在 Dest 中
+	if dest, ok := tx.Statement.Dest.(*Staff); ok {
+		staff = dest
+	}
+	if staff.IdentityNum != "" {
+		hash := BlindIndex(staff.IdentityNum)
+		tx.Statement.SetColumn("IdentityHash", &hash)
+	}
+	return nil
 }
 
 type StaffVO struct {
@@ -32,15 +55,41 @@ type StaffVO struct {
 	DepName      string `json:"dep_name"`
 	RankName     string `json:"rank_name"`
 	UserTypeName string `json:"user_type_name"`
+	StatusName   string `json:"status_name"`
+	// 按查看人权限脱敏后的手机号，覆盖 Staff 中数值类型的手机号输出
+	PhoneStr string `json:"phone"`
+	// 已脱敏的字段，可通过查看明文接口获取
+	MaskedFields []string `json:"masked_fields,omitempty"`
+}
+
+// 员工组合查询条件，均为可选
+type StaffSearchQuery struct {
+	StaffId string `form:"staff_id"`
+	// 姓名、部门名称为模糊匹配
+	StaffName string `form:"staff_name"`
+	DepId     string `form:"dep_id"`
+	DepName   string `form:"dep_name"`
+	// 是否包含下级部门的员工，默认包含
+	IncludeSubDeps *bool  `form:"include_sub_deps"`
+	RankId         string `form:"rank_id"`
+	EduLevel       string `form:"edu_level"`
+	// 入职日期范围，格式 YYYY-MM-DD，包含首尾两天
+	EntryDateFrom string `form:"entry_date_from"`
+	EntryDateTo   string `form:"entry_date_to"`
+	// 在职状态，多个用逗号分隔
+	Status        string `form:"status"`
+	LeaderStaffId string `form:"leader_staff_id"`
+	// 排序字段，多个用逗号分隔，字段前加 - 表示倒序，如 -entry_date,staff_id
+	Sort string `form:"sort"`
 }
 
 type StaffCreateDTO struct {
 	StaffName     string `json:"staff_name" binding:"required"`
 	LeaderStaffId string `gorm:"column:leader_staff_id" json:"leader_staff_id"`
 	LeaderName    string `gorm:"column:leader_name" json:"leader_name"`
-	BirthdayStr   string `json:"birthday_str" binding:"required"`
+	BirthdayStr   string `json:"birthday_str"` // 为空时由身份证号推导
 	IdentityNum   string `json:"identity_num" binding:"required"`
-	SexStr        string `json:"sex_str" binding:"required""`
+	SexStr        string `json:"sex_str"` // 为空时由身份证号推导
 	Nation        string `json:"nation" binding:"required"`
 	School        string `json:"school" binding:"required"`
 	Major         string `json:"major" binding:"required"`
@@ -52,6 +101,11 @@ type StaffCreateDTO struct {
 	Email         string `json:"email" binding:"required"`
 	Phone         int64  `gorm:"column:phone" json:"phone" binding:"required"`
 	EntryDateStr  string `json:"entry_date_str" binding:"required"`
+	Address       string `json:"address"`
+	// 入职时的在职状态，可选试用期或在职，默认在职
+	Status int64 `json:"status"`
+	// 试用期月数，仅以试用期入职时有效，为0时使用配置的默认月数
+	ProbationMonths int64 `json:"probation_months"`
 }
 
 type StaffEditDTO struct {
@@ -74,8 +128,14 @@ type StaffEditDTO struct {
 	Email         string `json:"email"`
 	Phone         int64  `gorm:"column:phone" json:"phone"`
 	EntryDateStr  string `json:"entry_date_str"`
+	Address       string `json:"address"`
 }
 
 func (s Staff) TableName() string {
 	return "staff"
 }
+
+// 离职类状态已生效，生效后禁止登录
+func (s Staff) HasLeft(now time.Time) bool {
+	return IsExitStatus(s.Status) && !s.StatusDate.After(now)
+}
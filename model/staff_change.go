@@ -0,0 +1,95 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 员工自助修改申请状态
+const (
+	StaffChangePending   = "pending"   // 待人事审批
+	StaffChangeApproved  = "approved"  // 已通过并生效
+	StaffChangeRejected  = "rejected"  // 已驳回
+	StaffChangeCancelled = "cancelled" // 员工已撤回
+)
+
+var StaffChangeStatusNames = map[string]string{
+	StaffChangePending:   "待审批",
+	StaffChangeApproved:  "已通过",
+	StaffChangeRejected:  "已驳回",
+	StaffChangeCancelled: "已撤回",
+}
+
+// 人事审批结果
+const (
+	StaffChangeDecisionApprove = "approve"
+	StaffChangeDecisionReject  = "reject"
+)
+
+// 员工可自助申请修改的内容
+const (
+	StaffChangeFieldContacts = "contacts" // 紧急联系人
+)
+
+var StaffChangeFieldNames = map[string]string{
+	SensitivePhone:           "手机号",
+	SensitiveEmail:           "电子邮箱",
+	SensitiveCardNum:         "银行卡号",
+	SensitiveAddress:         "现居住地址",
+	StaffChangeFieldContacts: "紧急联系人",
+}
+
+// 员工自助修改个人信息的申请，人事审批通过后写入员工信息并记录变更
+type StaffChangeRequest struct {
+	gorm.Model
+	RequestId string `gorm:"column:request_id;size:64;uniqueIndex" json:"request_id"`
+	StaffId   string `gorm:"column:staff_id;size:64;index" json:"staff_id"`
+	StaffName string `gorm:"column:staff_name" json:"staff_name"`
+	// 申请修改的内容，多个用逗号分隔，如 phone,card_num
+	Fields string `gorm:"column:fields" json:"fields"`
+	// 修改后的值，未申请修改的为空
+	Phone   int64  `gorm:"column:phone;type:varchar(255);serializer:encrypted" json:"phone"`
+	Email   string `gorm:"column:email" json:"email"`
+	CardNum string `gorm:"column:card_num;type:varchar(255);serializer:encrypted" json:"card_num"`
+	Address string `gorm:"column:address;type:varchar(512);serializer:encrypted" json:"address"`
+	// 修改后的全部紧急联系人，JSON 格式
+	Contacts string `gorm:"column:contacts;type:text;serializer:encrypted" json:"-"`
+	Reason   string `gorm:"column:reason" json:"reason"`
+	Status   string `gorm:"column:status;size:16;index" json:"status"`
+	// 人事审批
+	ReviewerId    string    `gorm:"column:reviewer_id" json:"reviewer_id"`
+	ReviewComment string    `gorm:"column:review_comment" json:"review_comment"`
+	ReviewDate    time.Time `gorm:"column:review_date" json:"review_date"`
+}
+
+func (r StaffChangeRequest) TableName() string {
+	return "staff_change_request"
+}
+
+type StaffChangeRequestVO struct {
+	StaffChangeRequest
+	StatusName string   `json:"status_name"`
+	FieldNames []string `json:"field_names"`
+	// 申请修改的紧急联系人
+	ContactList []StaffContactDTO `json:"contacts"`
+}
+
+// 只填写需要修改的内容，未填写的保持不变；
+// contacts 不为空时替换全部紧急联系人，修改已有联系人时需带上 record_id
+type StaffChangeSubmitDTO struct {
+	Phone    int64             `json:"phone"`
+	Email    string            `json:"email"`
+	CardNum  string            `json:"card_num"`
+	Address  string            `json:"address"`
+	Contacts []StaffContactDTO `json:"contacts" binding:"dive"`
+	Reason   string            `json:"reason"`
+}
+
+type StaffChangeReviewDTO struct {
+	RequestId string `json:"request_id" binding:"required"`
+	// approve 或 reject
+	Decision string `json:"decision" binding:"required"`
+	// 驳回时必须填写
+	Comment string `json:"comment"`
+}
@@ -0,0 +1,93 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 劳动合同类型
+const (
+	ContractTypeFixedTerm  = "fixed_term" // 固定期限
+	ContractTypeOpenEnded  = "open_ended" // 无固定期限
+	ContractTypeInternship = "internship" // 实习协议
+	ContractTypeDispatch   = "dispatch"   // 劳务派遣
+)
+
+var ContractTypeNames = map[string]string{
+	ContractTypeFixedTerm:  "固定期限",
+	ContractTypeOpenEnded:  "无固定期限",
+	ContractTypeInternship: "实习协议",
+	ContractTypeDispatch:   "劳务派遣",
+}
+
+// 劳动合同状态
+const (
+	ContractStatusActive     = "active"     // 履行中
+	ContractStatusRenewed    = "renewed"    // 已续签
+	ContractStatusTerminated = "terminated" // 已终止
+)
+
+var ContractStatusNames = map[string]string{
+	ContractStatusActive:     "履行中",
+	ContractStatusRenewed:    "已续签",
+	ContractStatusTerminated: "已终止",
+}
+
+// 员工劳动合同，续签时新建合同并关联上一份合同
+type StaffContract struct {
+	gorm.Model
+	ContractId   string    `gorm:"column:contract_id;size:64;uniqueIndex" json:"contract_id"`
+	StaffId      string    `gorm:"column:staff_id;size:64;index" json:"staff_id"`
+	StaffName    string    `gorm:"column:staff_name" json:"staff_name"`
+	ContractType string    `gorm:"column:contract_type" json:"contract_type"`
+	StartDate    time.Time `gorm:"column:start_date" json:"start_date"`
+	// 无固定期限合同没有结束日期
+	EndDate time.Time `gorm:"column:end_date" json:"end_date"`
+	// 试用期结束日期，无试用期时为空
+	ProbationEndDate time.Time `gorm:"column:probation_end_date" json:"probation_end_date"`
+	Status           string    `gorm:"column:status;size:16;index" json:"status"`
+	// 续签前的合同编号，首签合同为空
+	PreviousContractId string `gorm:"column:previous_contract_id" json:"previous_contract_id"`
+	// 第几次签订，首签为1
+	Seq int64 `gorm:"column:seq" json:"seq"`
+	// 提前终止日期及原因
+	TerminateDate time.Time `gorm:"column:terminate_date" json:"terminate_date"`
+	Remark        string    `gorm:"column:remark" json:"remark"`
+	OperatorId    string    `gorm:"column:operator_id" json:"operator_id"`
+}
+
+func (s StaffContract) TableName() string {
+	return "staff_contract"
+}
+
+type StaffContractVO struct {
+	StaffContract
+	ContractTypeName string `json:"contract_type_name"`
+	StatusName       string `json:"status_name"`
+}
+
+type StaffContractCreateDTO struct {
+	StaffId      string `json:"staff_id" binding:"required"`
+	ContractType string `json:"contract_type" binding:"required"`
+	// 日期格式均为 YYYY-MM-DD
+	StartDate        string `json:"start_date" binding:"required"`
+	EndDate          string `json:"end_date"`
+	ProbationEndDate string `json:"probation_end_date"`
+	Remark           string `json:"remark"`
+}
+
+// 续签合同，新合同的员工与上一份合同相同
+type StaffContractRenewDTO struct {
+	PreviousContractId string `json:"previous_contract_id" binding:"required"`
+	ContractType       string `json:"contract_type" binding:"required"`
+	StartDate          string `json:"start_date" binding:"required"`
+	EndDate            string `json:"end_date"`
+	Remark             string `json:"remark"`
+}
+
+type StaffContractTerminateDTO struct {
+	ContractId    string `json:"contract_id" binding:"required"`
+	TerminateDate string `json:"terminate_date" binding:"required"`
+	Remark        string `json:"remark"`
+}
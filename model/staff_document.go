@@ -0,0 +1,44 @@
+package model
+
+import "gorm.io/gorm"
+
+// 员工档案文件类型
+const (
+	DocTypeContract    = "contract"    // 劳动合同
+	DocTypeCertificate = "certificate" // 学历及资格证书
+	DocTypeIdCard      = "id_card"     // 身份证扫描件
+	DocTypeOther       = "other"       // 其他
+)
+
+var StaffDocumentTypeNames = map[string]string{
+	DocTypeContract:    "劳动合同",
+	DocTypeCertificate: "证书",
+	DocTypeIdCard:      "身份证扫描件",
+	DocTypeOther:       "其他",
+}
+
+// 员工档案文件，文件内容保存在存储服务中，删除时只做软删除
+type StaffDocument struct {
+	gorm.Model
+	DocumentId string `gorm:"column:document_id;size:64;uniqueIndex" json:"document_id"`
+	StaffId    string `gorm:"column:staff_id;size:64;index" json:"staff_id"`
+	DocType    string `gorm:"column:doc_type" json:"doc_type"`
+	FileName   string `gorm:"column:file_name" json:"file_name"`
+	MimeType   string `gorm:"column:mime_type" json:"mime_type"`
+	Size       int64  `gorm:"column:size" json:"size"`
+	// 文件内容的 SHA-256，十六进制
+	Sha256 string `gorm:"column:sha256;size:64" json:"sha256"`
+	// 文件在存储服务中的路径
+	StorageKey string `gorm:"column:storage_key" json:"-"`
+	UploaderId string `gorm:"column:uploader_id" json:"uploader_id"`
+	Remark     string `gorm:"column:remark" json:"remark"`
+}
+
+func (d StaffDocument) TableName() string {
+	return "staff_document"
+}
+
+type StaffDocumentVO struct {
+	StaffDocument
+	DocTypeName string `json:"doc_type_name"`
+}
@@ -0,0 +1,36 @@
+package model
+
+import "gorm.io/gorm"
+
+// 员工信息变更类型
+const (
+	StaffActionCreate = "create"
+	StaffActionEdit   = "edit"
+	StaffActionStatus = "status"
+	StaffActionDelete = "delete"
+	// 部门调整导致的变更
+	StaffActionRestructure = "restructure"
+	// 试用期转正时的调薪
+	StaffActionConvert = "convert"
+	// 职级调整
+	StaffActionRank = "rank"
+	// 员工自助申请并经人事审批的修改
+	StaffActionSelfService = "self_service"
+)
+
+// 员工信息字段级变更记录，同一次修改的各字段变更使用相同的 ChangeId
+type StaffHistory struct {
+	gorm.Model
+	ChangeId string `gorm:"column:change_id" json:"change_id"`
+	StaffId  string `gorm:"column:staff_id" json:"staff_id"`
+	Action   string `gorm:"column:action" json:"action"`
+	Field    string `gorm:"column:field" json:"field"`
+	// 变更前后的值可能包含身份证号等敏感信息，加密存储
+	OldValue   string `gorm:"column:old_value;serializer:encrypted" json:"old_value"`
+	NewValue   string `gorm:"column:new_value;serializer:encrypted" json:"new_value"`
+	OperatorId string `gorm:"column:operator_id" json:"operator_id"`
+}
+
+func (h StaffHistory) TableName() string {
+	return "staff_history"
+}
@@ -0,0 +1,26 @@
+package model
+
+// 员工导入文件中一个单元格或一行的校验错误
+type StaffImportError struct {
+	Sheet string `json:"sheet"`
+	// Excel 行号，表头为第1行
+	Row int `json:"row"`
+	// 表头名称，整行错误时为空
+	Column  string `json:"column"`
+	Value   string `json:"value"`
+	Message string `json:"message"`
+}
+
+// 员工导入结果
+type StaffImportResult struct {
+	// 数据行数，不含表头及空行
+	Total int `json:"total"`
+	// 导入成功的行数，仅校验时为校验通过的行数
+	Success int `json:"success"`
+	Failed  int `json:"failed"`
+	// 是否写入了数据库
+	Committed bool               `json:"committed"`
+	Errors    []StaffImportError `json:"errors"`
+	// 导入成功的员工工号
+	StaffIds []string `json:"staff_ids"`
+}
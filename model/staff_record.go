@@ -0,0 +1,172 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 员工子记录类型
+const (
+	StaffRecordContact    = "contact"    // 紧急联系人
+	StaffRecordFamily     = "family"     // 家庭成员
+	StaffRecordEducation  = "education"  // 教育经历
+	StaffRecordEmployment = "employment" // 工作经历
+)
+
+var StaffRecordNames = map[string]string{
+	StaffRecordContact:    "紧急联系人",
+	StaffRecordFamily:     "家庭成员",
+	StaffRecordEducation:  "教育经历",
+	StaffRecordEmployment: "工作经历",
+}
+
+// 家庭成员关系
+const (
+	FamilyRelationSpouse  = "spouse"  // 配偶
+	FamilyRelationChild   = "child"   // 子女
+	FamilyRelationParent  = "parent"  // 父母
+	FamilyRelationSibling = "sibling" // 兄弟姐妹
+	FamilyRelationOther   = "other"   // 其他
+)
+
+var FamilyRelationNames = map[string]string{
+	FamilyRelationSpouse:  "配偶",
+	FamilyRelationChild:   "子女",
+	FamilyRelationParent:  "父母",
+	FamilyRelationSibling: "兄弟姐妹",
+	FamilyRelationOther:   "其他",
+}
+
+// 各类员工子记录的公共字段，一名员工可有多条
+type StaffRecordBase struct {
+	gorm.Model
+	RecordId string `gorm:"column:record_id;size:64;uniqueIndex" json:"record_id"`
+	StaffId  string `gorm:"column:staff_id;size:64;index" json:"staff_id"`
+}
+
+func (r *StaffRecordBase) Base() *StaffRecordBase {
+	return r
+}
+
+// 员工子记录
+type StaffRecord interface {
+	Base() *StaffRecordBase
+}
+
+// 紧急联系人
+type StaffContact struct {
+	StaffRecordBase
+	Name     string `gorm:"column:name" json:"name"`
+	Relation string `gorm:"column:relation" json:"relation"`
+	Phone    string `gorm:"column:phone;type:varchar(255);serializer:encrypted" json:"phone"`
+	Address  string `gorm:"column:address;type:varchar(512);serializer:encrypted" json:"address"`
+	// 首选联系人，每名员工至多一位
+	IsPrimary bool `gorm:"column:is_primary" json:"is_primary"`
+}
+
+func (r StaffContact) TableName() string {
+	return "staff_contact"
+}
+
+// 家庭成员，赡养老人、子女教育等专项附加扣除按被扶养人计算
+type StaffFamily struct {
+	StaffRecordBase
+	Name        string    `gorm:"column:name" json:"name"`
+	Relation    string    `gorm:"column:relation" json:"relation"`
+	Birthday    time.Time `gorm:"column:birthday" json:"birthday"`
+	IdentityNum string    `gorm:"column:identity_num;type:varchar(255);serializer:encrypted" json:"identity_num"`
+	Phone       string    `gorm:"column:phone;type:varchar(255);serializer:encrypted" json:"phone"`
+	// 是否为被扶养人，仅子女及父母可设置
+	IsDependant bool   `gorm:"column:is_dependant" json:"is_dependant"`
+	Remark      string `gorm:"column:remark" json:"remark"`
+}
+
+func (r StaffFamily) TableName() string {
+	return "staff_family"
+}
+
+// 教育经历
+type StaffEducation struct {
+	StaffRecordBase
+	School    string    `gorm:"column:school" json:"school"`
+	Major     string    `gorm:"column:major" json:"major"`
+	EduLevel  string    `gorm:"column:edu_level" json:"edu_level"`
+	StartDate time.Time `gorm:"column:start_date" json:"start_date"`
+	EndDate   time.Time `gorm:"column:end_date" json:"end_date"`
+	// 是否为最高学历，设置后同步到员工的毕业院校、专业及学历
+	IsHighest bool `gorm:"column:is_highest" json:"is_highest"`
+}
+
+func (r StaffEducation) TableName() string {
+	return "staff_education"
+}
+
+// 入职前的工作经历
+type StaffEmployment struct {
+	StaffRecordBase
+	Company     string    `gorm:"column:company" json:"company"`
+	Position    string    `gorm:"column:position" json:"position"`
+	StartDate   time.Time `gorm:"column:start_date" json:"start_date"`
+	EndDate     time.Time `gorm:"column:end_date" json:"end_date"`
+	LeaveReason string    `gorm:"column:leave_reason" json:"leave_reason"`
+}
+
+func (r StaffEmployment) TableName() string {
+	return "staff_employment"
+}
+
+// 新增时 record_id 为空，修改时 staff_id 以原记录为准
+type StaffRecordDTO struct {
+	RecordId string `json:"record_id"`
+	StaffId  string `json:"staff_id"`
+}
+
+func (d *StaffRecordDTO) RecordDTO() *StaffRecordDTO {
+	return d
+}
+
+// 员工子记录的新增或修改表单
+type StaffRecordForm interface {
+	RecordDTO() *StaffRecordDTO
+}
+
+type StaffContactDTO struct {
+	StaffRecordDTO
+	Name      string `json:"name" binding:"required"`
+	Relation  string `json:"relation" binding:"required"`
+	Phone     string `json:"phone" binding:"required"`
+	Address   string `json:"address"`
+	IsPrimary bool   `json:"is_primary"`
+}
+
+type StaffFamilyDTO struct {
+	StaffRecordDTO
+	Name     string `json:"name" binding:"required"`
+	Relation string `json:"relation" binding:"required"`
+	// 为空时由身份证号推导，格式 YYYY-MM-DD
+	BirthdayStr string `json:"birthday_str"`
+	IdentityNum string `json:"identity_num"`
+	Phone       string `json:"phone"`
+	IsDependant bool   `json:"is_dependant"`
+	Remark      string `json:"remark"`
+}
+
+type StaffEducationDTO struct {
+	StaffRecordDTO
+	School    string `json:"school" binding:"required"`
+	Major     string `json:"major"`
+	EduLevel  string `json:"edu_level" binding:"required"`
+	StartDate string `json:"start_date" binding:"required"`
+	EndDate   string `json:"end_date"`
+	IsHighest bool   `json:"is_highest"`
+}
+
+type StaffEmploymentDTO struct {
+	StaffRecordDTO
+	Company     string `json:"company" binding:"required"`
+	Position    string `json:"position"`
+	StartDate   string `json:"start_date" binding:"required"`
+	EndDate     string `json:"end_date" binding:"required"`
+	LeaveReason string `json:"leave_reason"`
+}
@@ -0,0 +1,56 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 员工在职状态
+const (
+	StaffStatusProbation  int64 = 1 // 试用期
+	StaffStatusActive     int64 = 2 // 在职
+	StaffStatusSuspended  int64 = 3 // 停职（含休假等暂时离岗）
+	StaffStatusResigned   int64 = 4 // 主动离职
+	StaffStatusTerminated int64 = 5 // 辞退
+	StaffStatusRetired    int64 = 6 // 退休
+)
+
+var StaffStatusNames = map[int64]string{
+	StaffStatusProbation:  "试用期",
+	StaffStatusActive:     "在职",
+	StaffStatusSuspended:  "停职",
+	StaffStatusResigned:   "离职",
+	StaffStatusTerminated: "辞退",
+	StaffStatusRetired:    "退休",
+}
+
+// 是否为离职类状态
+func IsExitStatus(status int64) bool {
+	return status == StaffStatusResigned || status == StaffStatusTerminated || status == StaffStatusRetired
+}
+
+// 员工状态变更记录
+type StaffStatusLog struct {
+	gorm.Model
+	LogId         string    `gorm:"column:log_id;size:64;uniqueIndex" json:"log_id"`
+	StaffId       string    `gorm:"column:staff_id" json:"staff_id"`
+	StaffName     string    `gorm:"column:staff_name" json:"staff_name"`
+	FromStatus    int64     `gorm:"column:from_status" json:"from_status"`
+	ToStatus      int64     `gorm:"column:to_status" json:"to_status"`
+	EffectiveDate time.Time `gorm:"column:effective_date" json:"effective_date"`
+	Reason        string    `gorm:"column:reason" json:"reason"`
+	// 操作人工号
+	OperatorId string `gorm:"column:operator_id" json:"operator_id"`
+}
+
+type StaffStatusChangeDTO struct {
+	StaffId          string `json:"staff_id" binding:"required"`
+	Status           int64  `json:"status" binding:"required"`
+	EffectiveDateStr string `json:"effective_date_str" binding:"required"`
+	Reason           string `json:"reason" binding:"required"`
+}
+
+func (l StaffStatusLog) TableName() string {
+	return "staff_status_log"
+}
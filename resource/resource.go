@@ -1,10 +1,14 @@
 package resource
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"hrms/model"
 	"log"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -13,6 +17,9 @@ import (
 // 定义鉴权失败错误
 var ErrUnauthorized = errors.New("unauthorized")
 
+// 定义无权操作错误
+var ErrForbidden = errors.New("forbidden")
+
 // 全局配置文件
 var HrmsConf *Config
 
@@ -44,11 +51,101 @@ func HrmsDB(c *gin.Context) *gorm.DB {
 
 	branchId := parts[2]
 	dbName := fmt.Sprintf("hrms_%v", branchId)
-	if db, ok := DbMapper[dbName]; ok {
-		return db
+	db, ok := DbMapper[dbName]
+	if !ok || !staffActive(c, db, parts[1]) {
+		c.Abort()
+		return nil
+	}
+	return db
+}
+
+// 上下文中缓存当前用户是否在职的键名
+const staffActiveKey = "staff_active"
+
+// 离职类状态生效后已登录的 cookie 同样失效，每个请求只查询一次
+// 员工表中没有记录的内置账号视为在职
+func staffActive(c *gin.Context, db *gorm.DB, staffId string) bool {
+	if active, ok := c.Get(staffActiveKey); ok {
+		return active.(bool)
+	}
+	var staffs []model.Staff
+	if err := db.Select("staff_id", "status", "status_date").Where("staff_id = ?", staffId).Find(&staffs).Error; err != nil {
+		log.Printf("HrmsDB: 查询员工状态失败: %v", err)
+		return false
+	}
+	active := len(staffs) == 0 || !staffs[0].HasLeft(time.Now())
+	if !active {
+		log.Printf("HrmsDB: 员工 %v 已离职，拒绝访问", staffId)
+	}
+	c.Set(staffActiveKey, active)
+	return active
+}
+
+// 解析cookie中当前登录用户的工号，未登录时返回空字符串
+func CurrentStaffId(c *gin.Context) string {
+	cookie, err := c.Cookie("user_cookie")
+	if err != nil || cookie == "" {
+		return ""
+	}
+	parts := strings.Split(cookie, "_")
+	if len(parts) < 2 {
+		return ""
+	}
+	return parts[1]
+}
+
+// 解析cookie中的分公司Id，未登录时返回空字符串
+func CurrentBranchId(c *gin.Context) string {
+	cookie, err := c.Cookie("user_cookie")
+	if err != nil || cookie == "" {
+		return ""
+	}
+	parts := strings.Split(cookie, "_")
+	if len(parts) < 3 {
+		return ""
+	}
+	return parts[2]
+}
+
+// 解析cookie中当前登录用户的角色，未登录时返回空字符串
+func CurrentUserType(c *gin.Context) string {
+	cookie, err := c.Cookie("user_cookie")
+	if err != nil || cookie == "" {
+		return ""
+	}
+	return strings.Split(cookie, "_")[0]
+}
+
+// 请求ID在上下文及响应头中的名称
+const (
+	requestIdKey    = "request_id"
+	RequestIdHeader = "X-Request-Id"
+)
+
+// 为每个请求分配请求ID，调用方传入 X-Request-Id 时沿用
+func RequestId() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestId := c.GetHeader(RequestIdHeader)
+		if requestId == "" || len(requestId) > 64 {
+			buf := make([]byte, 16)
+			rand.Read(buf)
+			requestId = hex.EncodeToString(buf)
+		}
+		c.Set(requestIdKey, requestId)
+		c.Header(RequestIdHeader, requestId)
+		c.Next()
 	}
-	c.Abort()
-	return nil
+}
+
+// 当前请求的请求ID
+func CurrentRequestId(c *gin.Context) string {
+	return c.GetString(requestIdKey)
+}
+
+// 当前登录用户是否为管理员，包括超级管理员及系统管理员
+func IsAdmin(c *gin.Context) bool {
+	userType := CurrentUserType(c)
+	return userType == "supersys" || userType == "sys"
 }
 
 type Db struct {
@@ -61,7 +158,114 @@ type Db struct {
 	Path     string `json:"path"` // SQLite 数据库文件路径
 }
 
+// 业务编号生成规则
+type IdGen struct {
+	// 工号格式，支持 {branch}、{yyyy}、{yy}、{mm} 及 {seq:N} 占位符，如 {branch}-{yyyy}-{seq:5}
+	StaffPattern string `json:"staffPattern"`
+	// 按分公司覆盖工号格式，键为分公司编号
+	BranchPatterns map[string]string `json:"branchPatterns"`
+}
+
+// 未配置时沿用原有的 H+5位数字 工号格式
+const defaultStaffIdPattern = "H{seq:5}"
+
+// 个人信息访问日志配置
+type AccessLog struct {
+	// 保留天数，为0时永久保留
+	RetentionDays int64 `json:"retentionDays"`
+}
+
+// 劳动合同及试用期配置
+type Contract struct {
+	// 以试用期入职时默认的试用期月数，默认3个月
+	ProbationMonths int64 `json:"probationMonths"`
+	// 合同到期前多少天提醒人事，为0时不提醒
+	ReminderDays int64 `json:"reminderDays"`
+	// 试用期结束前多少天提醒人事，为0时不提醒
+	ProbationReminderDays int64 `json:"probationReminderDays"`
+}
+
+// 生日、司龄纪念日及退休提醒配置
+type Milestone struct {
+	// 是否启用，默认不启用
+	Enabled bool `json:"enabled"`
+	// 生日及司龄纪念日的通知方式，branch 为分公司全员可见的通知，personal 为仅员工本人可见的个人消息，默认 personal
+	Mode string `json:"mode"`
+	// 发送司龄纪念日通知的入职年数，默认 1、3、5、10 年
+	AnniversaryYears []int64 `json:"anniversaryYears"`
+	// 到达退休年龄前多少天提醒人事及员工本人，为0时不提醒
+	RetirementReminderDays int64 `json:"retirementReminderDays"`
+	// 退休年龄，默认男60岁、女55岁
+	RetirementAgeMale   int64 `json:"retirementAgeMale"`
+	RetirementAgeFemale int64 `json:"retirementAgeFemale"`
+	// 通知模板，未配置时使用默认模板
+	Templates MilestoneTemplates `json:"templates"`
+}
+
+// 通知模板，支持 {name}、{staff_id}、{dep_name}、{years}、{date} 占位符
+type MilestoneTemplate struct {
+	Title   string `json:"title"`
+	Content string `json:"content"`
+}
+
+type MilestoneTemplates struct {
+	Birthday    MilestoneTemplate `json:"birthday"`
+	Anniversary MilestoneTemplate `json:"anniversary"`
+	Retirement  MilestoneTemplate `json:"retirement"`
+}
+
+// 员工档案文件配置
+type Document struct {
+	// 单个文件大小上限，单位MB，默认20
+	MaxSizeMB int64 `json:"maxSizeMB"`
+	// 允许上传的文件类型，按文件内容识别，默认 PDF、JPEG、PNG
+	AllowedMimeTypes []string        `json:"allowedMimeTypes"`
+	Storage          DocumentStorage `json:"storage"`
+}
+
+// 档案文件存储配置
+type DocumentStorage struct {
+	// 存储类型，local 或 s3，默认 local
+	Type string `json:"type"`
+	// 本地存储目录，默认 ./data/documents
+	Path string `json:"path"`
+	// S3 兼容存储的服务地址，如 https://s3.cn-north-1.amazonaws.com.cn
+	Endpoint  string `json:"endpoint"`
+	Region    string `json:"region"`
+	Bucket    string `json:"bucket"`
+	AccessKey string `json:"accessKey"`
+	SecretKey string `json:"secretKey"`
+	// 使用路径风格访问，MinIO 等需开启
+	PathStyle bool `json:"pathStyle"`
+}
+
+// 打印配置时隐藏密钥
+func (s DocumentStorage) String() string {
+	return fmt.Sprintf("{type:%v path:%v endpoint:%v bucket:%v}", s.Type, s.Path, s.Endpoint, s.Bucket)
+}
+
 type Config struct {
-	Gin `json:"gin"`
-	Db  `json:"db"`
+	Gin       `json:"gin"`
+	Db        `json:"db"`
+	IdGen     `json:"idGen"`
+	AccessLog `json:"accessLog"`
+	Document  `json:"document"`
+	Contract  `json:"contract"`
+	Milestone `json:"milestone"`
+	Crypto    model.CryptoConfig `json:"crypto"`
+}
+
+// 获取分公司的工号格式
+func StaffIdPattern(branchId string) string {
+	if HrmsConf == nil {
+		return defaultStaffIdPattern
+	}
+	// 配置文件中的键会被统一转为小写
+	if pattern, ok := HrmsConf.IdGen.BranchPatterns[strings.ToLower(branchId)]; ok && pattern != "" {
+		return pattern
+	}
+	if HrmsConf.IdGen.StaffPattern != "" {
+		return HrmsConf.IdGen.StaffPattern
+	}
+	return defaultStaffIdPattern
 }
@@ -143,6 +143,19 @@ execute_init_sql() {
     fi
 }
 
+# 迁移到最新表结构，补齐初始化 SQL 之后新增的字段及表
+migrate_new_database() {
+    log_info "迁移数据库表结构: ${TEMP_DB_NAME}"
+
+    cd "${PROJECT_ROOT}"
+    if go run ./cmd/migrate -db "${TEMP_DB_NAME}"; then
+        log_success "表结构迁移完成"
+    else
+        log_error "表结构迁移失败"
+        exit 1
+    fi
+}
+
 # 验证数据库
 verify_database() {
     log_info "验证数据库结构..."
@@ -204,6 +217,7 @@ main() {
     backup_existing_database
     create_new_database
     execute_init_sql
+    migrate_new_database
     # verify_database
     replace_target_database
 
@@ -0,0 +1,230 @@
+package service
+
+import (
+	"encoding/csv"
+	"hrms/model"
+	"hrms/resource"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 每批写入及导出的访问日志条数
+const accessLogBatch = 500
+
+// 按被访问员工记录访问日志，同一请求中同一员工只记录一次；
+// 写入失败时只打印日志，不影响本次查询
+func RecordAccess(c *gin.Context, accessResource, fields string, staffIds ...string) {
+	fieldsByStaff := make(map[string]string, len(staffIds))
+	for _, staffId := range staffIds {
+		fieldsByStaff[staffId] = fields
+	}
+	recordAccess(c, accessResource, staffIds, fieldsByStaff)
+}
+
+// 记录员工详情的访问日志，按各员工实际以明文返回的敏感字段记录
+func RecordStaffAccess(c *gin.Context, accessResource string, vos []model.StaffVO) {
+	staffIds := make([]string, 0, len(vos))
+	fieldsByStaff := make(map[string]string, len(vos))
+	for _, vo := range vos {
+		staffIds = append(staffIds, vo.StaffId)
+		fieldsByStaff[vo.StaffId] = strings.Join(plainStaffFields(&vo), ",")
+	}
+	recordAccess(c, accessResource, staffIds, fieldsByStaff)
+}
+
+// 未脱敏且有值的敏感字段
+func plainStaffFields(vo *model.StaffVO) []string {
+	var fields []string
+	for _, field := range StaffSensitiveFields {
+		masked := false
+		for _, f := range vo.MaskedFields {
+			masked = masked || f == field
+		}
+		empty := (field == model.SensitiveIdentityNum && vo.IdentityNum == "") ||
+			(field == model.SensitiveCardNum && vo.CardNum == "") ||
+			(field == model.SensitivePhone && vo.PhoneStr == "") ||
+			(field == model.SensitiveAddress && vo.Address == "")
+		if !masked && !empty {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+func recordAccess(c *gin.Context, accessResource string, staffIds []string, fieldsByStaff map[string]string) {
+	db := resource.HrmsDB(c)
+	if db == nil || len(staffIds) == 0 {
+		return
+	}
+	var logs []model.AccessLog
+	seen := make(map[string]bool, len(staffIds))
+	for _, staffId := range staffIds {
+		if staffId == "" || seen[staffId] {
+			continue
+		}
+		seen[staffId] = true
+		logs = append(logs, model.AccessLog{
+			LogId:         RandomID("access"),
+			RequestId:     resource.CurrentRequestId(c),
+			OperatorId:    resource.CurrentStaffId(c),
+			UserType:      resource.CurrentUserType(c),
+			TargetStaffId: staffId,
+			Resource:      accessResource,
+			Fields:        fieldsByStaff[staffId],
+			Path:          c.Request.URL.Path,
+			ClientIp:      c.ClientIP(),
+		})
+	}
+	if err := db.CreateInBatches(&logs, accessLogBatch).Error; err != nil {
+		log.Printf("recordAccess err = %v", err)
+	}
+}
+
+// 访问日志仅超级管理员可查看
+func accessLogDB(c *gin.Context) (*gorm.DB, error) {
+	db := resource.HrmsDB(c)
+	if db == nil {
+		return nil, resource.ErrUnauthorized
+	}
+	if resource.CurrentUserType(c) != "supersys" {
+		return nil, resource.ErrForbidden
+	}
+	return db, nil
+}
+
+func accessLogQuery(db *gorm.DB, q *model.AccessLogQuery) (*gorm.DB, error) {
+	query := db.Model(&model.AccessLog{})
+	if q.OperatorId != "" {
+		query = query.Where("operator_id = ?", q.OperatorId)
+	}
+	if q.TargetStaffId != "" {
+		query = query.Where("target_staff_id = ?", q.TargetStaffId)
+	}
+	if q.Resource != "" {
+		query = query.Where("resource = ?", q.Resource)
+	}
+	if q.RequestId != "" {
+		query = query.Where("request_id = ?", q.RequestId)
+	}
+	if q.DateFrom != "" {
+		from, err := ParseDepDate(q.DateFrom)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("created_at >= ?", from)
+	}
+	if q.DateTo != "" {
+		to, err := ParseDepDate(q.DateTo)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("created_at < ?", to.AddDate(0, 0, 1))
+	}
+	// 查询条件在列表及总数查询中复用
+	return query.Session(&gorm.Session{}), nil
+}
+
+// 查询访问日志，按时间倒序
+func GetAccessLogs(c *gin.Context, q *model.AccessLogQuery, start int, limit int) ([]*model.AccessLog, int64, error) {
+	db, err := accessLogDB(c)
+	if err != nil {
+		return nil, 0, err
+	}
+	query, err := accessLogQuery(db, q)
+	if err != nil {
+		return nil, 0, err
+	}
+	var logs []*model.AccessLog
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	list := query.Order("id desc")
+	if start != -1 || limit != -1 {
+		list = list.Offset(start).Limit(limit)
+	}
+	if err := list.Find(&logs).Error; err != nil {
+		log.Printf("GetAccessLogs err = %v", err)
+		return nil, 0, err
+	}
+	return logs, total, nil
+}
+
+// 校验导出权限及查询条件，开始写出文件后无法再返回错误信息
+func CheckAccessLogExport(c *gin.Context, q *model.AccessLogQuery) error {
+	db, err := accessLogDB(c)
+	if err != nil {
+		return err
+	}
+	_, err = accessLogQuery(db, q)
+	return err
+}
+
+// 以 CSV 格式分批导出访问日志，带 UTF-8 BOM 以便 Excel 正确识别中文
+func WriteAccessLogCSV(c *gin.Context, q *model.AccessLogQuery, w io.Writer) error {
+	db, err := accessLogDB(c)
+	if err != nil {
+		return err
+	}
+	query, err := accessLogQuery(db, q)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write([]byte("\xEF\xBB\xBF")); err != nil {
+		return err
+	}
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"访问时间", "请求ID", "访问人", "用户类型", "被访问员工", "访问类型", "明文字段", "访问路径", "来源IP"}); err != nil {
+		return err
+	}
+	var logs []*model.AccessLog
+	err = query.Order("id").FindInBatches(&logs, accessLogBatch, func(tx *gorm.DB, batch int) error {
+		for _, l := range logs {
+			if err := writer.Write([]string{
+				l.CreatedAt.Local().Format("2006-01-02 15:04:05"), l.RequestId, l.OperatorId, UserTypeName(l.UserType),
+				l.TargetStaffId, l.Resource, l.Fields, l.Path, l.ClientIp,
+			}); err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	}).Error
+	if err != nil {
+		return err
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// 清理超出保留期限的访问日志，返回清理条数
+func PurgeAccessLogs(db *gorm.DB, retentionDays int64, now time.Time) (int64, error) {
+	if retentionDays <= 0 {
+		return 0, nil
+	}
+	before := now.AddDate(0, 0, -int(retentionDays))
+	// 访问日志的删除钩子禁止删除，清理时跳过钩子且不保留软删除记录
+	result := db.Session(&gorm.Session{SkipHooks: true}).Unscoped().
+		Where("created_at < ?", before).Delete(&model.AccessLog{})
+	return result.RowsAffected, result.Error
+}
+
+// 启动访问日志定时清理，启动时及此后每天清理一次各分公司数据库
+func StartAccessLogPurge(retentionDays int64) {
+	if retentionDays <= 0 {
+		return
+	}
+	runDaily("PurgeAccessLogs", func(dbName string, db *gorm.DB, now time.Time) error {
+		count, err := PurgeAccessLogs(db, retentionDays, now)
+		if err != nil {
+			return err
+		}
+		log.Printf("[PurgeAccessLogs] %v 清理访问日志%v条", dbName, count)
+		return nil
+	})
+}
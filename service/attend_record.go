@@ -209,92 +209,15 @@ func Compute(c *gin.Context, attendId string) error {
 		if err != nil {
 			return err
 		}
-		// 获取员工工号及当月出勤天数、缺勤天数、加班天数及月份
-		staffId := attendInfo.StaffId
-		workDays := attendInfo.WorkDays
-		leaveDays := attendInfo.LeaveDays
-		overtimeDays := attendInfo.OvertimeDays
-		month := attendInfo.Date
 		// 获取该员工薪资套账
-		salaryInfo, err := getSalaryInfoByStaffId(tx, staffId)
+		salaryInfo, err := getSalaryInfoByStaffId(tx, attendInfo.StaffId)
 		if err != nil {
 			return err
 		}
-		// 获员工姓名、基本薪资、住房补贴、绩效奖金、提成薪资、其他薪资、是否缴纳五险一金
-		staffName := salaryInfo.StaffName
-		base := salaryInfo.Base
-		subsidy := salaryInfo.Subsidy
-		bonus := salaryInfo.Bonus
-		commission := salaryInfo.Commission
-		other := salaryInfo.Other
-		fund := salaryInfo.Fund
-		// 按出勤天数更新基本工资
-		base = int64((float64(base) / getCurMonthWorkdays()) * float64(workDays))
-		// 更新绩效奖金,每缺勤一天扣1/5
-		if leaveDays > 5 {
-			bonus = 0
-		}
-		x := float64(5-leaveDays) / 5.0
-		bonus = int64(float64(bonus) * x)
-		// 更新加班工资，按国家法定节假日2倍加班费计算
-		overtimeSalary := int64((float64(base) / getCurMonthWorkdays()) * 2.0 * float64(overtimeDays))
-		// 判断是否交五险一金，不交的话不计算三险
-		salaryRecord := model.SalaryRecord{}
-		amount := float64(overtimeSalary + base + subsidy + bonus + commission + other)
-		if fund == 1 {
-			// 缴纳五险一金，计算个人需缴纳养老保险、失业保险和医疗保险及住房公积金
-			//养老保险金：   800.00 (8%)   1900.00    (19%)
-			//医疗保险金：   200.00 (2%)   1000.00    (10%)
-			//失业保险金：   20.00  (0.2%) 80.00  (0.8%)
-			//基本住房公积金： 1200  (12%)  1200    (12%)
-			//补充住房公积金： 0.00   (0%)   0.00   (0%)
-			//工伤保险金：       0         40.00  (0.4%)
-			///生育保险金：      0         80.00  (0.8%)
-			salaryRecord.PensionInsurance = amount * 0.08
-			salaryRecord.MedicalInsurance = amount * 0.02
-			salaryRecord.UnemploymentInsurance = amount * 0.002
-			salaryRecord.HousingFund = amount * 0.12
-		}
-		// 计算扣除三险及住房公积金后薪资，并以此计算扣税金额
-		amount = amount - salaryRecord.PensionInsurance - salaryRecord.MedicalInsurance -
-			salaryRecord.UnemploymentInsurance - salaryRecord.HousingFund
-		// 以最新税法，起征点5000元计算，七级扣税
-		var tax float64 = 0
-		if amount > 5000 {
-			total := amount - 5000
-			// 按法定税率扣税
-			if total <= 3000 {
-				tax = total * 0.03
-			} else if total <= 12000 {
-				tax = total*0.10 - 210
-			} else if total <= 25000 {
-				tax = total*0.20 - 1410
-			} else if total <= 35000 {
-				tax = total*0.25 - 2660
-			} else if total <= 55000 {
-				tax = total*0.30 - 4410
-			} else if total <= 80000 {
-				tax = total*0.35 - 7160
-			} else if total > 80000 {
-				tax = total*0.45 - 15160
-			}
-		}
-		// 计算税后工资
-		total := amount - tax
-		// 创建薪资记录用于发放
+		salaryRecord := ComputeSalaryRecord(attendInfo, salaryInfo)
 		salaryRecord.SalaryRecordId = RandomID("salary_record")
-		salaryRecord.StaffId = staffId
-		salaryRecord.StaffName = staffName
-		salaryRecord.Base = base
-		salaryRecord.Subsidy = subsidy
-		salaryRecord.Bonus = bonus
-		salaryRecord.Commission = commission
-		salaryRecord.Overtime = overtimeSalary
-		salaryRecord.Other = other
-		salaryRecord.Tax = tax
-		salaryRecord.Total = total
-		salaryRecord.IsPay = 1
-		salaryRecord.SalaryDate = month
+		staffId := salaryRecord.StaffId
+		month := salaryRecord.SalaryDate
 		// 创建或更新薪资记录
 		affected := tx.Where("staff_id = ? and salary_date = ?", staffId, month).Updates(&salaryRecord).RowsAffected
 		if affected != 0 {
@@ -309,6 +232,91 @@ func Compute(c *gin.Context, attendId string) error {
 	return err
 }
 
+// 按考勤信息及薪资套账计算当月薪资详情，得到五险一金税后薪资（不含薪资记录ID）
+func ComputeSalaryRecord(attendInfo *model.AttendanceRecord, salaryInfo *model.Salary) model.SalaryRecord {
+	// 获取员工工号及当月出勤天数、缺勤天数、加班天数及月份
+	staffId := attendInfo.StaffId
+	workDays := attendInfo.WorkDays
+	leaveDays := attendInfo.LeaveDays
+	overtimeDays := attendInfo.OvertimeDays
+	month := attendInfo.Date
+	// 获员工姓名、基本薪资、住房补贴、绩效奖金、提成薪资、其他薪资、是否缴纳五险一金
+	staffName := salaryInfo.StaffName
+	base := salaryInfo.Base
+	subsidy := salaryInfo.Subsidy
+	bonus := salaryInfo.Bonus
+	commission := salaryInfo.Commission
+	other := salaryInfo.Other
+	fund := salaryInfo.Fund
+	// 按出勤天数更新基本工资
+	base = int64((float64(base) / getCurMonthWorkdays()) * float64(workDays))
+	// 更新绩效奖金,每缺勤一天扣1/5
+	if leaveDays > 5 {
+		bonus = 0
+	}
+	x := float64(5-leaveDays) / 5.0
+	bonus = int64(float64(bonus) * x)
+	// 更新加班工资，按国家法定节假日2倍加班费计算
+	overtimeSalary := int64((float64(base) / getCurMonthWorkdays()) * 2.0 * float64(overtimeDays))
+	// 判断是否交五险一金，不交的话不计算三险
+	salaryRecord := model.SalaryRecord{}
+	amount := float64(overtimeSalary + base + subsidy + bonus + commission + other)
+	if fund == 1 {
+		// 缴纳五险一金，计算个人需缴纳养老保险、失业保险和医疗保险及住房公积金
+		//养老保险金：   800.00 (8%)   1900.00    (19%)
+		//医疗保险金：   200.00 (2%)   1000.00    (10%)
+		//失业保险金：   20.00  (0.2%) 80.00  (0.8%)
+		//基本住房公积金： 1200  (12%)  1200    (12%)
+		//补充住房公积金： 0.00   (0%)   0.00   (0%)
+		//工伤保险金：       0         40.00  (0.4%)
+		///生育保险金：      0         80.00  (0.8%)
+		salaryRecord.PensionInsurance = amount * 0.08
+		salaryRecord.MedicalInsurance = amount * 0.02
+		salaryRecord.UnemploymentInsurance = amount * 0.002
+		salaryRecord.HousingFund = amount * 0.12
+	}
+	// 计算扣除三险及住房公积金后薪资，并以此计算扣税金额
+	amount = amount - salaryRecord.PensionInsurance - salaryRecord.MedicalInsurance -
+		salaryRecord.UnemploymentInsurance - salaryRecord.HousingFund
+	// 以最新税法，起征点5000元计算，七级扣税
+	var tax float64 = 0
+	if amount > 5000 {
+		total := amount - 5000
+		// 按法定税率扣税
+		if total <= 3000 {
+			tax = total * 0.03
+		} else if total <= 12000 {
+			tax = total*0.10 - 210
+		} else if total <= 25000 {
+			tax = total*0.20 - 1410
+		} else if total <= 35000 {
+			tax = total*0.25 - 2660
+		} else if total <= 55000 {
+			tax = total*0.30 - 4410
+		} else if total <= 80000 {
+			tax = total*0.35 - 7160
+		} else if total > 80000 {
+			tax = total*0.45 - 15160
+		}
+	}
+	// 计算税后工资
+	total := amount - tax
+	// 组装薪资记录
+	salaryRecord.StaffId = staffId
+	salaryRecord.StaffName = staffName
+	salaryRecord.Base = base
+	salaryRecord.Subsidy = subsidy
+	salaryRecord.Bonus = bonus
+	salaryRecord.Commission = commission
+	salaryRecord.Overtime = overtimeSalary
+	salaryRecord.Other = other
+	salaryRecord.Tax = tax
+	salaryRecord.Total = total
+	salaryRecord.IsPay = 1
+	salaryRecord.SalaryDate = month
+	return salaryRecord
+}
+
 func getCurMonthWorkdays() float64 {
 	return 22.25
 }
@@ -8,7 +8,6 @@ import (
 	"hrms/model"
 	"hrms/resource"
 	"log"
-	"math/rand"
 	"strconv"
 	"time"
 
@@ -33,17 +32,6 @@ func AcceptPage(c *gin.Context) (int, int) {
 	return startIndex, limit
 }
 
-func RandomID(pre string) string {
-	rand.Seed(time.Now().Unix())
-	return fmt.Sprintf("%v_%v", pre, rand.Uint32())
-}
-
-func RandomStaffId() string {
-	rand.Seed(time.Now().UnixNano())
-	randStaffStr := fmt.Sprintf("H%v", rand.Uint32())
-	return randStaffStr[0:6]
-}
-
 func Str2Time(timeStr string, typ int) time.Time {
 	var curTime time.Time
 	var err error
@@ -120,12 +108,12 @@ func GetRankNameRankDepId(c *gin.Context, rankId string) string {
 func Transfer(from, to interface{}) error {
 	bytes, err := json.Marshal(&from)
 	if err != nil {
-		log.Println("Transfer json err = %v", err)
+		log.Printf("Transfer json err = %v", err)
 		return err
 	}
 	err = json.Unmarshal(bytes, &to)
 	if err != nil {
-		log.Println("Transfer json err = %v", err)
+		log.Printf("Transfer json err = %v", err)
 		return err
 	}
 	return nil
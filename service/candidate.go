@@ -46,6 +46,15 @@ func UpdateCandidateById(c *gin.Context, dto *model.CandidateEditDTO) error {
 		log.Printf("UpdateCandidateById: 数据库连接为空，鉴权失败")
 		return resource.ErrUnauthorized // 返回鉴权失败错误
 	}
+	// 查询结果中的邮箱已脱敏，原样提交时视为未修改
+	var old model.Candidate
+	if err := db.Where("id = ?", candidate.ID).Find(&old).Error; err != nil {
+		log.Printf("UpdateCandidateById err = %v", err)
+		return err
+	}
+	if UnmaskSubmitted(model.SensitiveEmail, candidate.Email, old.Email) == old.Email {
+		candidate.Email = ""
+	}
 	if err := db.Model(&model.Candidate{}).Where("id = ?", candidate.ID).
 		Updates(&candidate).Error; err != nil {
 		log.Printf("UpdateCandidateById err = %v", err)
@@ -0,0 +1,289 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"hrms/model"
+	"hrms/resource"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 部门调整计划，预览与执行使用同一份计划
+type depRestructurePlan struct {
+	preview       *model.DepRestructurePreview
+	staffs        []model.Staff
+	effectiveDate time.Time
+}
+
+// 调整立即执行，生效日期可以补录之前的日期，但不能晚于当天
+func parseRestructureDate(dateStr string) (time.Time, error) {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	if dateStr == "" {
+		return today, nil
+	}
+	date, err := ParseDepDate(dateStr)
+	if err != nil {
+		return date, err
+	}
+	if date.After(today) {
+		return date, errors.New("生效日期不能晚于当天")
+	}
+	return date, nil
+}
+
+func checkDepNameUnused(db *gorm.DB, depName string) error {
+	if depName == "" {
+		return errors.New("新部门名称不能为空")
+	}
+	var count int64
+	if err := db.Model(&model.Department{}).Where("dep_name = ?", depName).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("部门名称已存在: %v", depName)
+	}
+	return nil
+}
+
+// 校验调整参数并计算受影响的员工及部门
+func planDepRestructure(db *gorm.DB, dto *model.DepRestructureDTO) (*depRestructurePlan, error) {
+	effectiveDate, err := parseRestructureDate(dto.EffectiveDateStr)
+	if err != nil {
+		return nil, err
+	}
+	var source model.Department
+	if err := db.Where("dep_id = ?", dto.SourceDepId).First(&source).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("源部门不存在: %v", dto.SourceDepId)
+		}
+		return nil, err
+	}
+	plan := &depRestructurePlan{
+		preview: &model.DepRestructurePreview{
+			Type:      dto.Type,
+			SourceDep: source,
+			Staffs:    []model.DepRestructureStaff{},
+			ChildDeps: []model.Department{},
+		},
+		effectiveDate: effectiveDate,
+	}
+	switch dto.Type {
+	case model.DepRestructureMerge:
+		if dto.TargetDepId == "" || dto.TargetDepId == source.DepId {
+			return nil, errors.New("合并需指定与源部门不同的目标部门")
+		}
+		var target model.Department
+		if err := db.Where("dep_id = ?", dto.TargetDepId).First(&target).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, fmt.Errorf("目标部门不存在: %v", dto.TargetDepId)
+			}
+			return nil, err
+		}
+		if !target.IsActive(effectiveDate) {
+			return nil, fmt.Errorf("目标部门在 %v 已失效", effectiveDate.Format("2006-01-02"))
+		}
+		// 目标部门为源部门的下级部门时，改挂下级部门会形成环路
+		parents, err := loadDepParents(db)
+		if err != nil {
+			return nil, err
+		}
+		seen := make(map[string]bool)
+		for cur := target.ParentDepId; cur != "" && !seen[cur]; cur = parents[cur] {
+			if cur == source.DepId {
+				return nil, errors.New("目标部门是源部门的下级部门，不能合并")
+			}
+			seen[cur] = true
+		}
+		plan.preview.TargetDep = target
+		if err := db.Where("dep_id = ?", source.DepId).Order("staff_id").Find(&plan.staffs).Error; err != nil {
+			return nil, err
+		}
+		if err := db.Where("parent_dep_id = ?", source.DepId).Find(&plan.preview.ChildDeps).Error; err != nil {
+			return nil, err
+		}
+	case model.DepRestructureSplit:
+		if err := checkDepNameUnused(db, dto.NewDepName); err != nil {
+			return nil, err
+		}
+		staffIds := uniqueStrings(dto.StaffIds)
+		if len(staffIds) == 0 {
+			return nil, errors.New("拆分需指定调动的员工")
+		}
+		if err := db.Where("staff_id in ? and dep_id = ?", staffIds, source.DepId).Order("staff_id").Find(&plan.staffs).Error; err != nil {
+			return nil, err
+		}
+		if len(plan.staffs) != len(staffIds) {
+			found := make(map[string]bool)
+			for _, staff := range plan.staffs {
+				found[staff.StaffId] = true
+			}
+			var missing []string
+			for _, staffId := range staffIds {
+				if !found[staffId] {
+					missing = append(missing, staffId)
+				}
+			}
+			return nil, fmt.Errorf("以下员工不属于源部门: %v", strings.Join(missing, ","))
+		}
+		describe := dto.NewDepDescribe
+		if describe == "" {
+			describe = source.DepDescribe
+		}
+		// 新部门与源部门同级
+		plan.preview.TargetDep = model.Department{
+			DepName:     dto.NewDepName,
+			DepDescribe: describe,
+			ParentDepId: source.ParentDepId,
+			CostCenter:  source.CostCenter,
+			ValidFrom:   effectiveDate,
+		}
+	case model.DepRestructureRename:
+		if dto.NewDepName == source.DepName {
+			return nil, errors.New("新部门名称与原名称相同")
+		}
+		if err := checkDepNameUnused(db, dto.NewDepName); err != nil {
+			return nil, err
+		}
+		plan.preview.TargetDep = source
+		plan.preview.TargetDep.DepName = dto.NewDepName
+	default:
+		return nil, fmt.Errorf("不支持的调整类型: %v", dto.Type)
+	}
+	for _, staff := range plan.staffs {
+		plan.preview.Staffs = append(plan.preview.Staffs, model.DepRestructureStaff{
+			StaffId:   staff.StaffId,
+			StaffName: staff.StaffName,
+			FromDepId: staff.DepId,
+			ToDepId:   plan.preview.TargetDep.DepId,
+		})
+	}
+	return plan, nil
+}
+
+func uniqueStrings(list []string) []string {
+	var result []string
+	seen := make(map[string]bool)
+	for _, s := range list {
+		if s == "" || seen[s] {
+			continue
+		}
+		seen[s] = true
+		result = append(result, s)
+	}
+	return result
+}
+
+// 预览部门调整影响的员工及部门，不做修改
+func PreviewDepRestructure(c *gin.Context, dto *model.DepRestructureDTO) (*model.DepRestructurePreview, error) {
+	db := resource.HrmsDB(c)
+	if db == nil {
+		log.Printf("PreviewDepRestructure: 数据库连接为空，鉴权失败")
+		return nil, resource.ErrUnauthorized
+	}
+	if !resource.IsAdmin(c) {
+		return nil, resource.ErrForbidden
+	}
+	plan, err := planDepRestructure(db, dto)
+	if err != nil {
+		return nil, err
+	}
+	return plan.preview, nil
+}
+
+// 在同一事务中执行部门调整，记录员工变更及调整记录
+func ExecuteDepRestructure(c *gin.Context, dto *model.DepRestructureDTO) (*model.DepRestructure, error) {
+	db := resource.HrmsDB(c)
+	if db == nil {
+		log.Printf("ExecuteDepRestructure: 数据库连接为空，鉴权失败")
+		return nil, resource.ErrUnauthorized
+	}
+	if !resource.IsAdmin(c) {
+		return nil, resource.ErrForbidden
+	}
+	var record *model.DepRestructure
+	err := db.Transaction(func(tx *gorm.DB) error {
+		plan, err := planDepRestructure(tx, dto)
+		if err != nil {
+			return err
+		}
+		source, target := plan.preview.SourceDep, plan.preview.TargetDep
+		switch dto.Type {
+		case model.DepRestructureMerge:
+			// 下级部门改挂到目标部门，源部门在生效日期前一天失效
+			if err := tx.Model(&model.Department{}).Where("parent_dep_id = ?", source.DepId).
+				Update("parent_dep_id", target.DepId).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&model.Department{}).Where("id = ?", source.ID).
+				Update("valid_to", plan.effectiveDate.AddDate(0, 0, -1)).Error; err != nil {
+				return err
+			}
+		case model.DepRestructureSplit:
+			target.DepId = RandomID("dep")
+			if err := tx.Create(&target).Error; err != nil {
+				return err
+			}
+		case model.DepRestructureRename:
+			if err := tx.Model(&model.Department{}).Where("id = ?", source.ID).
+				Update("dep_name", target.DepName).Error; err != nil {
+				return err
+			}
+		}
+		staffIds := make([]string, 0, len(plan.staffs))
+		for _, old := range plan.staffs {
+			if err := tx.Model(&model.Staff{}).Where("id = ?", old.ID).Update("dep_id", target.DepId).Error; err != nil {
+				return err
+			}
+			cur := old
+			cur.DepId = target.DepId
+			if err := RecordStaffHistory(tx, c, model.StaffActionRestructure, &old, &cur); err != nil {
+				return err
+			}
+			staffIds = append(staffIds, old.StaffId)
+		}
+		record = &model.DepRestructure{
+			RestructureId: RandomID("restructure"),
+			Type:          dto.Type,
+			SourceDepId:   source.DepId,
+			SourceDepName: source.DepName,
+			TargetDepId:   target.DepId,
+			TargetDepName: target.DepName,
+			StaffIds:      strings.Join(staffIds, ","),
+			StaffCount:    int64(len(staffIds)),
+			EffectiveDate: plan.effectiveDate,
+			Reason:        dto.Reason,
+			OperatorId:    resource.CurrentStaffId(c),
+		}
+		return tx.Create(record).Error
+	})
+	if err != nil {
+		log.Printf("ExecuteDepRestructure err = %v", err)
+		return nil, err
+	}
+	return record, nil
+}
+
+// 查询部门作为源部门或目标部门的调整记录，按时间倒序
+func GetDepRestructureByDepId(c *gin.Context, depId string) ([]*model.DepRestructure, error) {
+	var records []*model.DepRestructure
+	db := resource.HrmsDB(c)
+	if db == nil {
+		log.Printf("GetDepRestructureByDepId: 数据库连接为空，鉴权失败")
+		return nil, resource.ErrUnauthorized
+	}
+	query := db.Order("id desc")
+	if depId != "all" {
+		query = query.Where("source_dep_id = ? or target_dep_id = ?", depId, depId)
+	}
+	if err := query.Find(&records).Error; err != nil {
+		log.Printf("GetDepRestructureByDepId err = %v", err)
+		return nil, err
+	}
+	return records, nil
+}
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"hrms/model"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 解析部门生效、失效日期，空字符串表示不限
+func ParseDepDate(dateStr string) (time.Time, error) {
+	if dateStr == "" {
+		return time.Time{}, nil
+	}
+	date, err := time.ParseInLocation("2006-01-02", dateStr, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("日期格式错误，应为 YYYY-MM-DD: %v", dateStr)
+	}
+	return date, nil
+}
+
+// 校验部门的上级部门、负责人及有效期
+func ValidateDepartment(db *gorm.DB, dep *model.Department) error {
+	if !dep.ValidFrom.IsZero() && !dep.ValidTo.IsZero() && dep.ValidTo.Before(dep.ValidFrom) {
+		return errors.New("失效日期不能早于生效日期")
+	}
+	if dep.HeadStaffId != "" {
+		var count int64
+		if err := db.Model(&model.Staff{}).Where("staff_id = ? and staff_id != 'root' and staff_id != 'admin'", dep.HeadStaffId).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return fmt.Errorf("部门负责人不存在: %v", dep.HeadStaffId)
+		}
+	}
+	if dep.ParentDepId == "" {
+		return nil
+	}
+	if dep.ParentDepId == dep.DepId {
+		return errors.New("不能将本部门设为上级部门")
+	}
+	parents, err := loadDepParents(db)
+	if err != nil {
+		return err
+	}
+	if _, ok := parents[dep.ParentDepId]; !ok {
+		return fmt.Errorf("上级部门不存在: %v", dep.ParentDepId)
+	}
+	// 从新的上级部门向上查找，遇到本部门说明会形成环路
+	seen := make(map[string]bool)
+	for cur := dep.ParentDepId; cur != "" && !seen[cur]; cur = parents[cur] {
+		if cur == dep.DepId {
+			return fmt.Errorf("%v 是本部门的下级部门，不能设为上级部门", dep.ParentDepId)
+		}
+		seen[cur] = true
+	}
+	return nil
+}
+
+// 部门编号到上级部门编号的映射
+func loadDepParents(db *gorm.DB) (map[string]string, error) {
+	var deps []*model.Department
+	if err := db.Select("dep_id", "parent_dep_id").Find(&deps).Error; err != nil {
+		return nil, err
+	}
+	parents := make(map[string]string, len(deps))
+	for _, dep := range deps {
+		parents[dep.DepId] = dep.ParentDepId
+	}
+	return parents, nil
+}
+
+// 部门仍有员工（含已离职员工）或下级部门时不允许删除，已撤销的部门应设置失效日期
+func CheckDepartmentDeletable(db *gorm.DB, depId string) error {
+	var staffCount, childCount int64
+	if err := db.Model(&model.Staff{}).Where("dep_id = ? and staff_id != 'root' and staff_id != 'admin'", depId).
+		Count(&staffCount).Error; err != nil {
+		return err
+	}
+	if staffCount > 0 {
+		return fmt.Errorf("该部门仍有%v名员工，不能删除", staffCount)
+	}
+	if err := db.Model(&model.Department{}).Where("parent_dep_id = ?", depId).Count(&childCount).Error; err != nil {
+		return err
+	}
+	if childCount > 0 {
+		return fmt.Errorf("该部门仍有%v个下级部门，不能删除", childCount)
+	}
+	return nil
+}
+
+// 汇总各部门人数及薪资，下级部门的数据计入全部上级部门
+func ConvertDepartment2VO(db *gorm.DB, deps []model.Department) ([]model.DepartmentVO, error) {
+	var allDeps []*model.Department
+	if err := db.Find(&allDeps).Error; err != nil {
+		log.Printf("ConvertDepartment2VO err = %v", err)
+		return nil, err
+	}
+	var staffs []*model.Staff
+	if err := db.Where("staff_id != 'root' and staff_id != 'admin'").Find(&staffs).Error; err != nil {
+		log.Printf("ConvertDepartment2VO err = %v", err)
+		return nil, err
+	}
+	var salaries []*model.Salary
+	if err := db.Find(&salaries).Error; err != nil {
+		log.Printf("ConvertDepartment2VO err = %v", err)
+		return nil, err
+	}
+	payrollMap := make(map[string]int64)
+	for _, salary := range salaries {
+		payrollMap[salary.StaffId] += salary.Base + salary.Subsidy + salary.Bonus + salary.Commission + salary.Other
+	}
+	now := time.Now()
+	headcount := make(map[string]int64)
+	payroll := make(map[string]int64)
+	staffNames := make(map[string]string)
+	for _, staff := range staffs {
+		staffNames[staff.StaffId] = staff.StaffName
+		if staff.HasLeft(now) {
+			continue
+		}
+		headcount[staff.DepId]++
+		payroll[staff.DepId] += payrollMap[staff.StaffId]
+	}
+	depNames := make(map[string]string)
+	children := make(map[string][]string)
+	for _, dep := range allDeps {
+		depNames[dep.DepId] = dep.DepName
+		if dep.ParentDepId != "" && dep.ParentDepId != dep.DepId {
+			children[dep.ParentDepId] = append(children[dep.ParentDepId], dep.DepId)
+		}
+	}
+	totalHeadcount := make(map[string]int64)
+	totalPayroll := make(map[string]int64)
+	var rollup func(depId string, seen map[string]bool)
+	rollup = func(depId string, seen map[string]bool) {
+		if _, ok := totalHeadcount[depId]; ok {
+			return
+		}
+		seen[depId] = true
+		count, sum := headcount[depId], payroll[depId]
+		for _, child := range children[depId] {
+			// 历史数据中存在环路时不重复计算
+			if seen[child] {
+				continue
+			}
+			rollup(child, seen)
+			count += totalHeadcount[child]
+			sum += totalPayroll[child]
+		}
+		totalHeadcount[depId] = count
+		totalPayroll[depId] = sum
+	}
+	voList := make([]model.DepartmentVO, 0, len(deps))
+	for _, dep := range deps {
+		rollup(dep.DepId, make(map[string]bool))
+		voList = append(voList, model.DepartmentVO{
+			Department:     dep,
+			ParentDepName:  depNames[dep.ParentDepId],
+			HeadStaffName:  staffNames[dep.HeadStaffId],
+			Active:         dep.IsActive(now),
+			Headcount:      headcount[dep.DepId],
+			TotalHeadcount: totalHeadcount[dep.DepId],
+			Payroll:        payroll[dep.DepId],
+			TotalPayroll:   totalPayroll[dep.DepId],
+		})
+	}
+	return voList, nil
+}
+
+// 部门及其全部下级部门的编号
+func DepSubtree(db *gorm.DB, depIds []string) ([]string, error) {
+	parents, err := loadDepParents(db)
+	if err != nil {
+		return nil, err
+	}
+	children := make(map[string][]string)
+	for depId, parentId := range parents {
+		if parentId != "" {
+			children[parentId] = append(children[parentId], depId)
+		}
+	}
+	var result []string
+	seen := make(map[string]bool)
+	queue := append([]string{}, depIds...)
+	for len(queue) > 0 {
+		depId := queue[0]
+		queue = queue[1:]
+		if seen[depId] {
+			continue
+		}
+		seen[depId] = true
+		result = append(result, depId)
+		queue = append(queue, children[depId]...)
+	}
+	return result, nil
+}
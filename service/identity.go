@@ -0,0 +1,132 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// 身份证号前17位加权因子及校验码，见 GB 11643-1999
+var (
+	identityWeights = []int{7, 9, 10, 5, 8, 4, 2, 1, 6, 3, 7, 9, 10, 5, 8, 4, 2}
+	identityCheck   = []byte("10X98765432")
+)
+
+// 省级行政区划代码
+var IdentityProvinces = []string{
+	"11", "12", "13", "14", "15",
+	"21", "22", "23",
+	"31", "32", "33", "34", "35", "36", "37",
+	"41", "42", "43", "44", "45", "46",
+	"50", "51", "52", "53", "54",
+	"61", "62", "63", "64", "65",
+	"71", "81", "82",
+}
+
+var identityProvinces = func() map[string]bool {
+	provinces := make(map[string]bool, len(IdentityProvinces))
+	for _, code := range IdentityProvinces {
+		provinces[code] = true
+	}
+	return provinces
+}()
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// 计算18位身份证号的校验码，prefix 为前17位数字
+func IdentityCheckCode(prefix string) byte {
+	sum := 0
+	for i := 0; i < 17; i++ {
+		sum += int(prefix[i]-'0') * identityWeights[i]
+	}
+	return identityCheck[sum%11]
+}
+
+// 规范化身份证号，去除空白并将校验码 x 转为大写
+func NormalizeIdentityNum(identityNum string) string {
+	return strings.ToUpper(strings.TrimSpace(identityNum))
+}
+
+// 校验身份证号的地区码、出生日期及校验码，返回出生日期及性别
+// 兼容一代15位身份证号，其出生年份为19xx且没有校验码
+func ParseIdentityNum(identityNum string) (time.Time, int64, error) {
+	var dateStr string
+	var seq byte
+	switch len(identityNum) {
+	case 18:
+		if !isDigits(identityNum[:17]) {
+			return time.Time{}, 0, errors.New("身份证号前17位应为数字")
+		}
+		if identityNum[17] != IdentityCheckCode(identityNum) {
+			return time.Time{}, 0, errors.New("身份证号校验码错误")
+		}
+		dateStr, seq = identityNum[6:14], identityNum[16]
+	case 15:
+		if !isDigits(identityNum) {
+			return time.Time{}, 0, errors.New("15位身份证号应全部为数字")
+		}
+		dateStr, seq = "19"+identityNum[6:12], identityNum[14]
+	default:
+		return time.Time{}, 0, errors.New("身份证号应为18位或15位")
+	}
+	if !identityProvinces[identityNum[:2]] {
+		return time.Time{}, 0, errors.New("身份证号地区码错误")
+	}
+	birthday, err := time.ParseInLocation("20060102", dateStr, time.Local)
+	if err != nil || birthday.Year() < 1900 || birthday.After(time.Now()) {
+		return time.Time{}, 0, errors.New("身份证号中的出生日期错误")
+	}
+	// 顺序码奇数为男性，偶数为女性
+	var sex int64 = 2
+	if (seq-'0')%2 == 1 {
+		sex = 1
+	}
+	return birthday, sex, nil
+}
+
+// 由身份证号补全未填写的出生日期及性别，分别返回二者与身份证号不一致的错误
+func resolveIdentity(identityNum string, birthdayStr, sexStr *string) (birthdayErr error, sexErr error, err error) {
+	birthday, sex, err := ParseIdentityNum(identityNum)
+	if err != nil {
+		return nil, nil, err
+	}
+	if *birthdayStr == "" {
+		*birthdayStr = birthday.Format("2006-01-02")
+	} else if t, err := time.ParseInLocation("2006-01-02", *birthdayStr, time.Local); err != nil {
+		birthdayErr = errors.New("出生日期格式错误，应为 YYYY-MM-DD")
+	} else if !t.Equal(birthday) {
+		birthdayErr = fmt.Errorf("出生日期与身份证号不一致，身份证号对应%v", birthday.Format("2006-01-02"))
+	}
+	if *sexStr == "" {
+		*sexStr = SexInt2Str(sex)
+	} else if SexStr2Int64(*sexStr) != sex {
+		sexErr = fmt.Errorf("性别与身份证号不一致，身份证号对应%v", SexInt2Str(sex))
+	}
+	return birthdayErr, sexErr, nil
+}
+
+// 校验身份证号，并由其补全或核对出生日期及性别
+func ResolveIdentity(identityNum string, birthdayStr, sexStr *string) error {
+	birthdayErr, sexErr, err := resolveIdentity(identityNum, birthdayStr, sexStr)
+	if err != nil {
+		return err
+	}
+	var msgs []string
+	for _, e := range []error{birthdayErr, sexErr} {
+		if e != nil {
+			msgs = append(msgs, e.Error())
+		}
+	}
+	if len(msgs) > 0 {
+		return errors.New(strings.Join(msgs, "；"))
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIdentityCheckCode(t *testing.T) {
+	cases := []struct {
+		prefix string
+		want   byte
+	}{
+		{"11010519491231002", 'X'},
+		{"44052418800101001", '4'},
+		{"11010119900307001", '1'},
+		{"11010119900307002", 'X'},
+	}
+	for _, tc := range cases {
+		if got := IdentityCheckCode(tc.prefix); got != tc.want {
+			t.Errorf("IdentityCheckCode(%q) = %c, want %c", tc.prefix, got, tc.want)
+		}
+	}
+}
+
+func TestParseIdentityNum(t *testing.T) {
+	cases := []struct {
+		identityNum string
+		birthday    string
+		sex         int64
+		wantErr     bool
+	}{
+		{"11010519491231002X", "1949-12-31", 2, false},
+		{"110101199003070011", "1990-03-07", 1, false},
+		// 小写校验码需先经 NormalizeIdentityNum 处理
+		{"11010119900307002x", "", 0, true},
+		{"110101900307001", "1990-03-07", 1, false},
+		{"110101900307002", "1990-03-07", 2, false},
+		// 校验码错误
+		{"110105194912310021", "", 0, true},
+		// 地区码错误
+		{"990101199003070014", "", 0, true},
+		// 出生日期错误
+		{"110101199002300014", "", 0, true},
+		{"440524188001010014", "", 0, true},
+		{"11010119900307001A", "", 0, true},
+		{"1101011990030700", "", 0, true},
+		{"", "", 0, true},
+	}
+	for _, tc := range cases {
+		birthday, sex, err := ParseIdentityNum(tc.identityNum)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("ParseIdentityNum(%q) 应返回错误", tc.identityNum)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseIdentityNum(%q) err = %v", tc.identityNum, err)
+			continue
+		}
+		if got := birthday.Format("2006-01-02"); got != tc.birthday || sex != tc.sex {
+			t.Errorf("ParseIdentityNum(%q) = %v, %v, want %v, %v", tc.identityNum, got, sex, tc.birthday, tc.sex)
+		}
+	}
+
+	// 出生日期晚于当前日期
+	future := time.Now().AddDate(1, 0, 0).Format("20060102")
+	prefix := "110101" + future + "001"
+	if _, _, err := ParseIdentityNum(prefix + string(IdentityCheckCode(prefix))); err == nil {
+		t.Errorf("出生日期晚于当前日期时应返回错误")
+	}
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"hrms/model"
+	"hrms/resource"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ULID 使用的 Crockford Base32 字母表
+const ulidAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// 生成 ULID，前48位为毫秒时间戳，后80位为随机数，字符串按生成时间排序
+func NewULID() string {
+	var b [16]byte
+	ms := uint64(time.Now().UnixMilli())
+	for i := 0; i < 6; i++ {
+		b[i] = byte(ms >> (40 - 8*i))
+	}
+	if _, err := rand.Read(b[6:]); err != nil {
+		panic(err)
+	}
+	hi, lo := uint64(0), uint64(0)
+	for i := 0; i < 8; i++ {
+		hi = hi<<8 | uint64(b[i])
+		lo = lo<<8 | uint64(b[i+8])
+	}
+	// 128位按5位一组编码为26个字符，最高位补0
+	var out [26]byte
+	for i := 25; i >= 0; i-- {
+		out[i] = ulidAlphabet[lo&31]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out[:])
+}
+
+// 生成带前缀的记录ID，如 dep_01J9Z3K6ZQ0W8V5Y7T2N4M6P8R
+func RandomID(pre string) string {
+	return fmt.Sprintf("%v_%v", pre, NewULID())
+}
+
+// 工号格式中的占位符，{seq:N} 表示补零到N位的序号
+var idPatternToken = regexp.MustCompile(`\{(branch|yyyy|yy|mm|seq(?::(\d+))?)\}`)
+
+// 按格式生成编号的序列范围及序号格式化函数
+func renderIdPattern(pattern, branchId string, now time.Time) (string, func(int64) string, error) {
+	if strings.Contains(pattern, "_") {
+		// 登录 cookie 以下划线分隔，工号中不能包含下划线
+		return "", nil, fmt.Errorf("编号格式 %v 不能包含下划线", pattern)
+	}
+	seqCount, width := 0, 0
+	scope := idPatternToken.ReplaceAllStringFunc(pattern, func(token string) string {
+		m := idPatternToken.FindStringSubmatch(token)
+		switch m[1] {
+		case "branch":
+			return branchId
+		case "yyyy":
+			return now.Format("2006")
+		case "yy":
+			return now.Format("06")
+		case "mm":
+			return now.Format("01")
+		}
+		seqCount++
+		width, _ = strconv.Atoi(m[2])
+		return "{seq}"
+	})
+	if seqCount != 1 {
+		return "", nil, fmt.Errorf("编号格式 %v 必须包含一个序号占位符 {seq}", pattern)
+	}
+	format := func(n int64) string {
+		return strings.Replace(scope, "{seq}", fmt.Sprintf("%0*d", width, n), 1)
+	}
+	return scope, format, nil
+}
+
+// 递增并返回指定范围的序号，需在调用方事务中执行以保证并发安全
+func nextSequence(tx *gorm.DB, name, scope string) (int64, error) {
+	seq := model.IdSequence{Name: name, Scope: scope}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&seq).Error; err != nil {
+		return 0, err
+	}
+	query := tx.Model(&model.IdSequence{}).Where("name = ? and scope = ?", name, scope).Session(&gorm.Session{})
+	if err := query.UpdateColumn("value", gorm.Expr("value + 1")).Error; err != nil {
+		return 0, err
+	}
+	if err := query.Select("value").Scan(&seq.Value).Error; err != nil {
+		return 0, err
+	}
+	return seq.Value, nil
+}
+
+// 按分公司配置的工号格式生成下一个工号，跳过历史数据中已使用的工号
+func NextStaffId(tx *gorm.DB, c *gin.Context) (string, error) {
+	branchId := resource.CurrentBranchId(c)
+	scope, format, err := renderIdPattern(resource.StaffIdPattern(branchId), branchId, time.Now())
+	if err != nil {
+		return "", err
+	}
+	for i := 0; i < 1000; i++ {
+		n, err := nextSequence(tx, "staff", scope)
+		if err != nil {
+			return "", err
+		}
+		staffId := format(n)
+		var exist int64
+		if err := tx.Unscoped().Model(&model.Staff{}).Where("staff_id = ?", staffId).Count(&exist).Error; err != nil {
+			return "", err
+		}
+		if exist == 0 {
+			return staffId, nil
+		}
+	}
+	return "", errors.New("生成工号失败，请检查工号格式配置")
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderIdPattern(t *testing.T) {
+	now := time.Date(2024, 3, 5, 10, 0, 0, 0, time.Local)
+	cases := []struct {
+		pattern string
+		scope   string
+		seq     int64
+		want    string
+		wantErr bool
+	}{
+		{"H{seq:5}", "H{seq}", 42, "H00042", false},
+		{"{branch}-{yyyy}-{seq:5}", "C001-2024-{seq}", 7, "C001-2024-00007", false},
+		{"{branch}{yy}{mm}{seq:3}", "C0012403{seq}", 12, "C0012403012", false},
+		{"E{seq}", "E{seq}", 123, "E123", false},
+		// 序号超出位数时不截断
+		{"H{seq:2}", "H{seq}", 1234, "H1234", false},
+		{"H{seq:5}_{branch}", "", 0, "", true},
+		{"H{yyyy}", "", 0, "", true},
+		{"H{seq}{seq}", "", 0, "", true},
+		{"H{seq:x}", "", 0, "", true},
+	}
+	for _, tc := range cases {
+		scope, format, err := renderIdPattern(tc.pattern, "C001", now)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("renderIdPattern(%q) 应返回错误", tc.pattern)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("renderIdPattern(%q) err = %v", tc.pattern, err)
+			continue
+		}
+		if scope != tc.scope {
+			t.Errorf("renderIdPattern(%q) scope = %q, want %q", tc.pattern, scope, tc.scope)
+		}
+		if got := format(tc.seq); got != tc.want {
+			t.Errorf("renderIdPattern(%q) format(%d) = %q, want %q", tc.pattern, tc.seq, got, tc.want)
+		}
+	}
+}
+
+func TestNewULID(t *testing.T) {
+	prev := ""
+	for i := 0; i < 100; i++ {
+		id := NewULID()
+		if len(id) != 26 {
+			t.Fatalf("NewULID() = %q, 长度应为26", id)
+		}
+		for _, r := range id {
+			if !strings.ContainsRune(ulidAlphabet, r) {
+				t.Fatalf("NewULID() = %q, 包含非法字符 %c", id, r)
+			}
+		}
+		if id == prev {
+			t.Fatalf("NewULID() 生成了重复的编号 %q", id)
+		}
+		// 前10位为时间戳，按生成时间递增
+		if prev != "" && id[:10] < prev[:10] {
+			t.Fatalf("NewULID() 时间戳未递增: %q < %q", id, prev)
+		}
+		prev = id
+	}
+	if id := RandomID("dep"); !strings.HasPrefix(id, "dep_") || len(id) != 30 {
+		t.Errorf("RandomID(%q) = %q", "dep", id)
+	}
+}
@@ -0,0 +1,300 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"hrms/model"
+	"hrms/resource"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var ErrRevealRecordNotExist = errors.New("记录不存在")
+
+// 员工信息中需要脱敏的字段
+var StaffSensitiveFields = []string{model.SensitiveIdentityNum, model.SensitiveCardNum, model.SensitivePhone, model.SensitiveAddress}
+
+func isStaffSensitiveField(field string) bool {
+	for _, f := range StaffSensitiveFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// 保留首尾若干位，中间替换为 *，长度不足时全部替换
+func maskMiddle(value string, head, tail int) string {
+	runes := []rune(value)
+	if len(runes) <= head+tail {
+		return strings.Repeat("*", len(runes))
+	}
+	return string(runes[:head]) + strings.Repeat("*", len(runes)-head-tail) + string(runes[len(runes)-tail:])
+}
+
+// 敏感字段脱敏，身份证号、银行卡号保留前4位及后4位，如 4600**********5518，
+// 手机号保留前3位及后4位，邮箱只保留用户名首字符及域名，地址只保留前6个字
+func MaskSensitive(field, value string) string {
+	if value == "" {
+		return ""
+	}
+	switch field {
+	case model.SensitiveIdentityNum, model.SensitiveCardNum:
+		return maskMiddle(value, 4, 4)
+	case model.SensitivePhone:
+		return maskMiddle(value, 3, 4)
+	case model.SensitiveEmail:
+		at := strings.LastIndex(value, "@")
+		if at < 0 {
+			return maskMiddle(value, 1, 0)
+		}
+		return maskMiddle(value[:at], 1, 0) + value[at:]
+	case model.SensitiveAddress:
+		return maskMiddle(value, 6, 0)
+	}
+	return value
+}
+
+// 编辑时提交的值与原值脱敏结果相同，说明未修改，返回原值
+func UnmaskSubmitted(field, submitted, stored string) string {
+	if submitted != "" && submitted != stored && submitted == MaskSensitive(field, stored) {
+		return stored
+	}
+	return submitted
+}
+
+// 敏感字段查看权限，按查看人角色及与记录的关系判断：
+// 本人可查看自己的全部信息，上级（含间接上级）可查看下属手机号，
+// 其余一律脱敏；管理员可通过查看明文接口查看，查看记录落表
+type MaskPolicy struct {
+	db       *gorm.DB
+	staffId  string
+	userType string
+	// 组织架构按需加载，一次请求内复用
+	graph *orgGraph
+}
+
+func NewMaskPolicy(c *gin.Context) (*MaskPolicy, error) {
+	db := resource.HrmsDB(c)
+	if db == nil {
+		return nil, resource.ErrUnauthorized
+	}
+	return &MaskPolicy{
+		db:       db,
+		staffId:  resource.CurrentStaffId(c),
+		userType: resource.CurrentUserType(c),
+	}, nil
+}
+
+func (p *MaskPolicy) isAdmin() bool {
+	return p.userType == "supersys" || p.userType == "sys"
+}
+
+// 是否可查看明文，仅超级管理员及系统管理员
+func (p *MaskPolicy) CanReveal() bool {
+	return p.isAdmin()
+}
+
+// 上级可查看的下属档案文件类型
+var leaderVisibleDocTypes = map[string]bool{
+	model.DocTypeCertificate: true,
+}
+
+// 档案文件的查看范围与敏感字段一致：管理员及本人可查看全部类型，上级只能查看证书
+func (p *MaskPolicy) CanAccessDocument(staffId, docType string) bool {
+	if p.isAdmin() || (p.staffId != "" && staffId == p.staffId) {
+		return true
+	}
+	return leaderVisibleDocTypes[docType] && p.isLeaderOf(staffId)
+}
+
+// 管理员及本人可上传档案文件，仅管理员可删除
+func (p *MaskPolicy) CanUploadDocument(staffId string) bool {
+	return p.isAdmin() || (p.staffId != "" && staffId == p.staffId)
+}
+
+// 当前用户是否为该员工的上级（含间接上级）
+func (p *MaskPolicy) isLeaderOf(staffId string) bool {
+	if p.staffId == "" {
+		return false
+	}
+	if p.graph == nil {
+		graph, err := loadOrgGraph(p.db)
+		if err != nil {
+			log.Printf("MaskPolicy loadOrgGraph err = %v", err)
+			graph = &orgGraph{staffs: make(map[string]*model.Staff)}
+		}
+		p.graph = graph
+	}
+	// 汇报关系可能存在环路，遇到已访问的节点即停止
+	seen := make(map[string]bool)
+	for leader := p.graph.leader(staffId); leader != "" && !seen[leader]; leader = p.graph.leader(leader) {
+		if leader == p.staffId {
+			return true
+		}
+		seen[leader] = true
+	}
+	return false
+}
+
+// 该员工的字段是否无需脱敏
+func (p *MaskPolicy) Visible(staffId, field string) bool {
+	if p.staffId != "" && staffId == p.staffId {
+		return true
+	}
+	return field == model.SensitivePhone && p.isLeaderOf(staffId)
+}
+
+// 按权限对员工信息脱敏
+func (p *MaskPolicy) MaskStaff(vo *model.StaffVO) {
+	vo.PhoneStr = ""
+	if vo.Phone != 0 {
+		vo.PhoneStr = strconv.FormatInt(vo.Phone, 10)
+	}
+	vo.MaskedFields = nil
+	for _, field := range StaffSensitiveFields {
+		if p.Visible(vo.StaffId, field) {
+			continue
+		}
+		switch field {
+		case model.SensitiveIdentityNum:
+			if vo.IdentityNum == "" {
+				continue
+			}
+			vo.IdentityNum = MaskSensitive(field, vo.IdentityNum)
+		case model.SensitiveCardNum:
+			if vo.CardNum == "" {
+				continue
+			}
+			vo.CardNum = MaskSensitive(field, vo.CardNum)
+		case model.SensitivePhone:
+			if vo.PhoneStr == "" {
+				continue
+			}
+			vo.PhoneStr = MaskSensitive(field, vo.PhoneStr)
+			vo.Phone = 0
+		case model.SensitiveAddress:
+			if vo.Address == "" {
+				continue
+			}
+			vo.Address = MaskSensitive(field, vo.Address)
+		}
+		vo.MaskedFields = append(vo.MaskedFields, field)
+	}
+}
+
+// 按权限对员工信息变更记录中的敏感字段脱敏，返回是否以明文返回了敏感字段
+func (p *MaskPolicy) MaskStaffHistory(history *model.StaffHistory) bool {
+	if !isStaffSensitiveField(history.Field) {
+		return false
+	}
+	if p.Visible(history.StaffId, history.Field) {
+		return true
+	}
+	history.OldValue = MaskSensitive(history.Field, history.OldValue)
+	history.NewValue = MaskSensitive(history.Field, history.NewValue)
+	return false
+}
+
+// 候选人邮箱仅负责面试的员工可直接查看
+func (p *MaskPolicy) MaskCandidate(candidate *model.Candidate) {
+	if p.staffId != "" && candidate.StaffId == p.staffId {
+		return
+	}
+	candidate.Email = MaskSensitive(model.SensitiveEmail, candidate.Email)
+}
+
+// 记录明文查看，记录失败时不允许查看
+func (p *MaskPolicy) logReveal(c *gin.Context, recordType, recordId string, fields []string) error {
+	for _, field := range fields {
+		entry := model.SensitiveRevealLog{
+			LogId:      RandomID("reveal"),
+			OperatorId: p.staffId,
+			UserType:   p.userType,
+			RecordType: recordType,
+			RecordId:   recordId,
+			Field:      field,
+			ClientIp:   c.ClientIP(),
+		}
+		if err := p.db.Create(&entry).Error; err != nil {
+			log.Printf("logReveal err = %v", err)
+			return err
+		}
+	}
+	log.Printf("[Reveal] operator = %v, %v %v, fields = %v", p.staffId, recordType, recordId, fields)
+	return nil
+}
+
+// 查看员工敏感字段明文，fields 为空时返回全部敏感字段
+func RevealStaffFields(c *gin.Context, staffId string, fields []string) (map[string]string, error) {
+	p, err := NewMaskPolicy(c)
+	if err != nil {
+		return nil, err
+	}
+	if !p.CanReveal() {
+		return nil, resource.ErrForbidden
+	}
+	if len(fields) == 0 {
+		fields = StaffSensitiveFields
+	}
+	for _, field := range fields {
+		if !isStaffSensitiveField(field) {
+			return nil, fmt.Errorf("不支持查看字段: %v", field)
+		}
+	}
+	var staffs []model.Staff
+	if err := p.db.Where("staff_id = ? and staff_id != 'root' and staff_id != 'admin'", staffId).Find(&staffs).Error; err != nil {
+		return nil, err
+	}
+	if len(staffs) == 0 {
+		return nil, ErrRevealRecordNotExist
+	}
+	staff := staffs[0]
+	values := make(map[string]string, len(fields))
+	for _, field := range fields {
+		switch field {
+		case model.SensitiveIdentityNum:
+			values[field] = staff.IdentityNum
+		case model.SensitiveCardNum:
+			values[field] = staff.CardNum
+		case model.SensitivePhone:
+			values[field] = ""
+			if staff.Phone != 0 {
+				values[field] = strconv.FormatInt(staff.Phone, 10)
+			}
+		case model.SensitiveAddress:
+			values[field] = staff.Address
+		}
+	}
+	if err := p.logReveal(c, model.RevealRecordStaff, staffId, fields); err != nil {
+		return nil, err
+	}
+	RecordAccess(c, model.AccessResourceStaffReveal, strings.Join(fields, ","), staffId)
+	return values, nil
+}
+
+// 查看候选人邮箱明文
+func RevealCandidateEmail(c *gin.Context, candidateId string) (string, error) {
+	p, err := NewMaskPolicy(c)
+	if err != nil {
+		return "", err
+	}
+	if !p.CanReveal() {
+		return "", resource.ErrForbidden
+	}
+	var candidates []model.Candidate
+	if err := p.db.Where("candidate_id = ?", candidateId).Find(&candidates).Error; err != nil {
+		return "", err
+	}
+	if len(candidates) == 0 {
+		return "", ErrRevealRecordNotExist
+	}
+	if err := p.logReveal(c, model.RevealRecordCandidate, candidateId, []string{model.SensitiveEmail}); err != nil {
+		return "", err
+	}
+	return candidates[0].Email, nil
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"hrms/model"
+	"testing"
+)
+
+func TestMaskSensitive(t *testing.T) {
+	cases := []struct {
+		field string
+		value string
+		want  string
+	}{
+		{model.SensitiveIdentityNum, "460034199905215518", "4600**********5518"},
+		{model.SensitiveCardNum, "6222000011112222", "6222********2222"},
+		{model.SensitivePhone, "13912345678", "139****5678"},
+		{model.SensitiveEmail, "zhangsan@example.com", "z*******@example.com"},
+		{model.SensitiveEmail, "zhangsan", "z*******"},
+		{model.SensitiveAddress, "北京市海淀区中关村大街1号", "北京市海淀区*******"},
+		// 长度不足时全部替换
+		{model.SensitivePhone, "1234567", "*******"},
+		{model.SensitiveAddress, "海南省", "***"},
+		{model.SensitiveIdentityNum, "", ""},
+		// 非敏感字段原样返回
+		{"staff_name", "张三", "张三"},
+	}
+	for _, tc := range cases {
+		if got := MaskSensitive(tc.field, tc.value); got != tc.want {
+			t.Errorf("MaskSensitive(%q, %q) = %q, want %q", tc.field, tc.value, got, tc.want)
+		}
+	}
+}
+
+func TestUnmaskSubmitted(t *testing.T) {
+	cases := []struct {
+		field     string
+		submitted string
+		stored    string
+		want      string
+	}{
+		// 提交的是脱敏值，说明未修改
+		{model.SensitivePhone, "139****5678", "13912345678", "13912345678"},
+		{model.SensitiveIdentityNum, "4600**********5518", "460034199905215518", "460034199905215518"},
+		// 提交了新值
+		{model.SensitivePhone, "13800000000", "13912345678", "13800000000"},
+		// 与其他值的脱敏结果相同时不替换
+		{model.SensitivePhone, "139****0000", "13912345678", "139****0000"},
+		{model.SensitivePhone, "", "13912345678", ""},
+		{model.SensitivePhone, "13912345678", "13912345678", "13912345678"},
+		// 原值为空时脱敏结果也为空
+		{model.SensitiveAddress, "北京市", "", "北京市"},
+	}
+	for _, tc := range cases {
+		if got := UnmaskSubmitted(tc.field, tc.submitted, tc.stored); got != tc.want {
+			t.Errorf("UnmaskSubmitted(%q, %q, %q) = %q, want %q", tc.field, tc.submitted, tc.stored, got, tc.want)
+		}
+	}
+}
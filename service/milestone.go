@@ -0,0 +1,321 @@
+package service
+
+import (
+	"fmt"
+	"hrms/model"
+	"hrms/resource"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 员工纪念日通知的类型
+const (
+	NoticeTypeBirthday    = "生日祝福"
+	NoticeTypeAnniversary = "司龄纪念"
+	NoticeTypeRetirement  = "退休提醒"
+)
+
+// 生日及司龄纪念日的通知方式
+const (
+	MilestoneModeBranch   = "branch"   // 分公司全员可见的通知
+	MilestoneModePersonal = "personal" // 仅员工本人可见的个人消息
+)
+
+// 纪念日通知类型的展示顺序
+var milestoneKinds = []string{model.MilestoneBirthday, model.MilestoneAnniversary, model.MilestoneRetirement}
+
+var defaultAnniversaryYears = []int64{1, 3, 5, 10}
+
+// 服务停止期间错过的生日及司龄纪念日，恢复后最多补发的天数
+const milestoneCatchUpDays = 7
+
+var defaultMilestoneTemplates = resource.MilestoneTemplates{
+	Birthday: resource.MilestoneTemplate{
+		Title:   "祝{name}生日快乐",
+		Content: "今天是{dep_name}{name}的生日，祝生日快乐、工作顺利！",
+	},
+	Anniversary: resource.MilestoneTemplate{
+		Title:   "{name}入职{years}周年",
+		Content: "{date}是{dep_name}{name}加入公司{years}周年的日子，感谢一路同行！",
+	},
+	Retirement: resource.MilestoneTemplate{
+		Title:   "{name}（{staff_id}）即将到达退休年龄",
+		Content: "员工{name}（{staff_id}）将于{date}到达退休年龄，请提前做好工作交接及退休手续办理。",
+	},
+}
+
+// 未配置的项使用默认值
+func milestoneConfig(conf resource.Milestone) resource.Milestone {
+	if conf.Mode != MilestoneModeBranch {
+		conf.Mode = MilestoneModePersonal
+	}
+	if len(conf.AnniversaryYears) == 0 {
+		conf.AnniversaryYears = defaultAnniversaryYears
+	}
+	if conf.RetirementAgeMale <= 0 {
+		conf.RetirementAgeMale = 60
+	}
+	if conf.RetirementAgeFemale <= 0 {
+		conf.RetirementAgeFemale = 55
+	}
+	for _, tpl := range []struct {
+		conf, def *resource.MilestoneTemplate
+	}{
+		{&conf.Templates.Birthday, &defaultMilestoneTemplates.Birthday},
+		{&conf.Templates.Anniversary, &defaultMilestoneTemplates.Anniversary},
+		{&conf.Templates.Retirement, &defaultMilestoneTemplates.Retirement},
+	} {
+		if tpl.conf.Title == "" {
+			tpl.conf.Title = tpl.def.Title
+		}
+		if tpl.conf.Content == "" {
+			tpl.conf.Content = tpl.def.Content
+		}
+	}
+	return conf
+}
+
+// 指定年份中与 date 同月同日的日期，2月29日在非闰年按2月28日计
+func anniversaryOf(date time.Time, year int) time.Time {
+	day := date.Day()
+	if date.Month() == time.February && day == 29 && time.Date(year, time.February, 29, 0, 0, 0, 0, time.Local).Day() != 29 {
+		day = 28
+	}
+	return time.Date(year, date.Month(), day, 0, 0, 0, 0, time.Local)
+}
+
+func renderMilestone(tpl resource.MilestoneTemplate, staff *model.Staff, depName string, years int, date time.Time) (string, string) {
+	r := strings.NewReplacer("{name}", staff.StaffName, "{staff_id}", staff.StaffId, "{dep_name}", depName,
+		"{years}", strconv.Itoa(years), "{date}", date.Format("2006-01-02"))
+	return r.Replace(tpl.Title), r.Replace(tpl.Content)
+}
+
+// 退休年龄，性别未知时为0
+func retirementAge(conf resource.Milestone, sex int64) int {
+	switch sex {
+	case 1:
+		return int(conf.RetirementAgeMale)
+	case 2:
+		return int(conf.RetirementAgeFemale)
+	}
+	return 0
+}
+
+// 生成当天的生日、司龄纪念日通知及即将到达退休年龄的提醒，
+// 按 SourceKey 去重，已退订的员工不再收到对应通知，返回新生成的条数；
+// 自上次生成生日、司龄纪念日通知后错过的日期一并补发，通知日期为纪念日当天
+func CreateMilestoneNotices(db *gorm.DB, conf resource.Milestone, now time.Time) (int64, error) {
+	conf = milestoneConfig(conf)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	var staffs []model.Staff
+	if err := db.Where("staff_id != 'root' and staff_id != 'admin'").Find(&staffs).Error; err != nil {
+		return 0, err
+	}
+	var deps []model.Department
+	if err := db.Find(&deps).Error; err != nil {
+		return 0, err
+	}
+	depNames := make(map[string]string, len(deps))
+	for _, dep := range deps {
+		depNames[dep.DepId] = dep.DepName
+	}
+	var optOuts []model.MilestoneOptOut
+	if err := db.Find(&optOuts).Error; err != nil {
+		return 0, err
+	}
+	optedOut := make(map[string]bool, len(optOuts))
+	for _, o := range optOuts {
+		optedOut[o.StaffId+":"+o.Kind] = true
+	}
+	since, err := milestoneCatchUpSince(db, today)
+	if err != nil {
+		return 0, err
+	}
+	// 生日及司龄纪念日按配置的方式通知
+	greeting := func(staff *model.Staff, title, content, noticeType, sourceKey string, date time.Time) model.Notification {
+		notice := model.Notification{
+			NoticeTitle:   title,
+			NoticeContent: content,
+			Type:          noticeType,
+			Date:          date,
+			Audience:      model.NoticeAudienceAll,
+			SourceKey:     noticeSourceKey(sourceKey),
+		}
+		if conf.Mode == MilestoneModePersonal {
+			notice.Audience = model.NoticeAudienceStaff
+			notice.StaffId = staff.StaffId
+		}
+		return notice
+	}
+	var notices []model.Notification
+	for i := range staffs {
+		staff := &staffs[i]
+		if staff.HasLeft(now) {
+			continue
+		}
+		depName := depNames[staff.DepId]
+		for day := since; !day.After(today); day = day.AddDate(0, 0, 1) {
+			if !staff.Birthday.IsZero() && !optedOut[staff.StaffId+":"+model.MilestoneBirthday] &&
+				anniversaryOf(staff.Birthday, day.Year()).Equal(day) {
+				title, content := renderMilestone(conf.Templates.Birthday, staff, depName, day.Year()-staff.Birthday.Year(), day)
+				notices = append(notices, greeting(staff, title, content, NoticeTypeBirthday,
+					fmt.Sprintf("birthday:%v:%v", staff.StaffId, day.Year()), day))
+			}
+			if !staff.EntryDate.IsZero() && !optedOut[staff.StaffId+":"+model.MilestoneAnniversary] &&
+				anniversaryOf(staff.EntryDate, day.Year()).Equal(day) {
+				years := day.Year() - staff.EntryDate.Year()
+				for _, y := range conf.AnniversaryYears {
+					if int(y) == years {
+						title, content := renderMilestone(conf.Templates.Anniversary, staff, depName, years, day)
+						notices = append(notices, greeting(staff, title, content, NoticeTypeAnniversary,
+							fmt.Sprintf("anniversary:%v:%v", staff.StaffId, years), day))
+						break
+					}
+				}
+			}
+		}
+		age := retirementAge(conf, staff.Sex)
+		if conf.RetirementReminderDays <= 0 || staff.Birthday.IsZero() || age == 0 {
+			continue
+		}
+		retireDate := anniversaryOf(staff.Birthday, staff.Birthday.Year()+age)
+		if !withinDays(retireDate, conf.RetirementReminderDays, now) {
+			continue
+		}
+		// 退休提醒始终通知人事，员工本人可退订
+		title, content := renderMilestone(conf.Templates.Retirement, staff, depName, age, retireDate)
+		notices = append(notices, model.Notification{
+			NoticeTitle:   title,
+			NoticeContent: content,
+			Type:          NoticeTypeRetirement,
+			Audience:      model.NoticeAudienceAdmin,
+			SourceKey:     noticeSourceKey("retirement:" + staff.StaffId),
+		})
+		if !optedOut[staff.StaffId+":"+model.MilestoneRetirement] {
+			notices = append(notices, model.Notification{
+				NoticeTitle:   title,
+				NoticeContent: content,
+				Type:          NoticeTypeRetirement,
+				Audience:      model.NoticeAudienceStaff,
+				StaffId:       staff.StaffId,
+				SourceKey:     noticeSourceKey("retirement:" + staff.StaffId + ":staff"),
+			})
+		}
+	}
+	var created int64
+	for i := range notices {
+		if notices[i].Date.IsZero() || notices[i].Date.Equal(today) {
+			notices[i].Date = now
+		}
+		ok, err := createSourceNotice(db, &notices[i])
+		if err != nil {
+			return created, err
+		}
+		if ok {
+			created++
+		}
+	}
+	return created, nil
+}
+
+// 补发的起始日期：上次生成生日、司龄纪念日通知的次日，最多补发 milestoneCatchUpDays 天；
+// 从未生成过时只生成当天的，避免首次启用时补发
+func milestoneCatchUpSince(db *gorm.DB, today time.Time) (time.Time, error) {
+	var last []model.Notification
+	if err := db.Unscoped().Where("type in ? and source_key is not null", []string{NoticeTypeBirthday, NoticeTypeAnniversary}).
+		Order("date desc").Limit(1).Find(&last).Error; err != nil {
+		return today, err
+	}
+	if len(last) == 0 {
+		return today, nil
+	}
+	d := last[0].Date.In(time.Local)
+	since := time.Date(d.Year(), d.Month(), d.Day()+1, 0, 0, 0, 0, time.Local)
+	if earliest := today.AddDate(0, 0, -milestoneCatchUpDays); since.Before(earliest) {
+		since = earliest
+	}
+	if since.After(today) {
+		since = today
+	}
+	return since, nil
+}
+
+// 启动员工纪念日通知，启动时及此后每天检查一次各分公司数据库
+func StartMilestoneNotice(conf resource.Milestone) {
+	if !conf.Enabled {
+		return
+	}
+	runDaily("MilestoneNotice", func(dbName string, db *gorm.DB, now time.Time) error {
+		count, err := CreateMilestoneNotices(db, conf, now)
+		if err != nil {
+			return err
+		}
+		log.Printf("[MilestoneNotice] %v 生成纪念日通知%v条", dbName, count)
+		return nil
+	})
+}
+
+// 查询本人各类纪念日通知的退订情况
+func GetMilestoneOptOuts(c *gin.Context) ([]model.MilestoneOptOutVO, error) {
+	db := resource.HrmsDB(c)
+	if db == nil {
+		return nil, resource.ErrUnauthorized
+	}
+	var optOuts []model.MilestoneOptOut
+	if err := db.Where("staff_id = ?", resource.CurrentStaffId(c)).Find(&optOuts).Error; err != nil {
+		log.Printf("GetMilestoneOptOuts err = %v", err)
+		return nil, err
+	}
+	optedOut := make(map[string]bool, len(optOuts))
+	for _, o := range optOuts {
+		optedOut[o.Kind] = true
+	}
+	vos := make([]model.MilestoneOptOutVO, 0, len(milestoneKinds))
+	for _, kind := range milestoneKinds {
+		vos = append(vos, model.MilestoneOptOutVO{
+			Kind:   kind,
+			Name:   model.MilestoneNames[kind],
+			OptOut: optedOut[kind],
+		})
+	}
+	return vos, nil
+}
+
+// 设置本人退订的纪念日通知类型，覆盖原有设置
+func SetMilestoneOptOuts(c *gin.Context, dto *model.MilestoneOptOutDTO) error {
+	db := resource.HrmsDB(c)
+	if db == nil {
+		return resource.ErrUnauthorized
+	}
+	staffId := resource.CurrentStaffId(c)
+	optOuts := make([]model.MilestoneOptOut, 0, len(dto.Kinds))
+	seen := make(map[string]bool, len(dto.Kinds))
+	for _, kind := range dto.Kinds {
+		if _, ok := model.MilestoneNames[kind]; !ok {
+			return fmt.Errorf("不支持的通知类型: %v", kind)
+		}
+		if seen[kind] {
+			continue
+		}
+		seen[kind] = true
+		optOuts = append(optOuts, model.MilestoneOptOut{StaffId: staffId, Kind: kind})
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("staff_id = ?", staffId).Delete(&model.MilestoneOptOut{}).Error; err != nil {
+			return err
+		}
+		if len(optOuts) == 0 {
+			return nil
+		}
+		if err := tx.Create(&optOuts).Error; err != nil {
+			log.Printf("SetMilestoneOptOuts err = %v", err)
+			return err
+		}
+		return nil
+	})
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"hrms/model"
+	"hrms/resource"
+	"testing"
+	"time"
+)
+
+func TestAnniversaryOf(t *testing.T) {
+	date := func(s string) time.Time {
+		d, err := time.ParseInLocation("2006-01-02", s, time.Local)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	cases := []struct {
+		date string
+		year int
+		want string
+	}{
+		{"1990-05-17", 2024, "2024-05-17"},
+		{"2000-02-29", 2024, "2024-02-29"},
+		// 闰日在平年按2月28日计算
+		{"2000-02-29", 2023, "2023-02-28"},
+		{"2000-02-29", 2100, "2100-02-28"},
+		{"1996-02-28", 2023, "2023-02-28"},
+		{"1996-03-01", 2023, "2023-03-01"},
+		{"1990-12-31", 2024, "2024-12-31"},
+	}
+	for _, tc := range cases {
+		if got := anniversaryOf(date(tc.date), tc.year).Format("2006-01-02"); got != tc.want {
+			t.Errorf("anniversaryOf(%v, %v) = %v, want %v", tc.date, tc.year, got, tc.want)
+		}
+	}
+}
+
+func TestCreateMilestoneNoticesCatchUp(t *testing.T) {
+	db := newTestDB(t, &model.Staff{}, &model.Department{}, &model.MilestoneOptOut{}, &model.Notification{})
+	birthday := func(day int) time.Time { return time.Date(1990, 6, day, 0, 0, 0, 0, time.Local) }
+	staffs := []model.Staff{
+		{StaffId: "A", StaffName: "甲", Sex: 1, Birthday: birthday(12)},
+		{StaffId: "B", StaffName: "乙", Sex: 1, Birthday: birthday(5)},
+		{StaffId: "C", StaffName: "丙", Sex: 1, Birthday: birthday(15)},
+		{StaffId: "D", StaffName: "丁", Sex: 1, Birthday: birthday(10)},
+	}
+	if err := db.Create(&staffs).Error; err != nil {
+		t.Fatal(err)
+	}
+	run := func(now time.Time, want int64) {
+		t.Helper()
+		created, err := CreateMilestoneNotices(db, resource.Milestone{}, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if created != want {
+			t.Errorf("%v: created = %v, want %v", now.Format("2006-01-02"), created, want)
+		}
+	}
+	// 首次执行只生成当天的通知
+	run(time.Date(2026, 6, 10, 9, 0, 0, 0, time.Local), 1)
+	// 停止期间错过的 A 在恢复后补发，B 早于上次执行不补发
+	run(time.Date(2026, 6, 15, 9, 0, 0, 0, time.Local), 2)
+	var notice model.Notification
+	if err := db.Where("source_key = ?", "birthday:A:2026").First(&notice).Error; err != nil {
+		t.Fatal(err)
+	}
+	if !notice.Date.Equal(birthday(12).AddDate(36, 0, 0)) {
+		t.Errorf("补发通知的日期 = %v，应为纪念日当天", notice.Date)
+	}
+	// 已生成及已删除的通知均不再生成
+	if err := db.Where("source_key = ?", "birthday:C:2026").Delete(&model.Notification{}).Error; err != nil {
+		t.Fatal(err)
+	}
+	run(time.Date(2026, 6, 15, 18, 0, 0, 0, time.Local), 0)
+}
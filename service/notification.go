@@ -6,8 +6,34 @@ import (
 	"log"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
+// 定时任务生成的通知的去重标识
+func noticeSourceKey(key string) *string {
+	return &key
+}
+
+// 按 SourceKey 生成定时任务的通知，已生成过的（含已删除的）不再生成，返回是否新生成
+func createSourceNotice(db *gorm.DB, notice *model.Notification) (bool, error) {
+	var count int64
+	if err := db.Unscoped().Model(&model.Notification{}).Where("source_key = ?", notice.SourceKey).Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return false, nil
+	}
+	notice.NoticeId = RandomID("notice")
+	if err := db.Create(notice).Error; err != nil {
+		// 多个实例同时生成时由唯一索引兜底
+		if isUniqueViolation(err, "source_key") {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
 func GetNotificationByTitle(c *gin.Context, noticeTitle string, start int, limit int) ([]*model.Notification, int64, error) {
 	var notifications []*model.Notification
 	var err error
@@ -16,6 +42,13 @@ func GetNotificationByTitle(c *gin.Context, noticeTitle string, start int, limit
 		log.Printf("GetNotificationByTitle: 数据库连接为空，鉴权失败")
 		return nil, 0, resource.ErrUnauthorized // 返回鉴权失败错误
 	}
+	// 合同到期提醒等仅面向人事的通知，普通员工不可见；个人消息仅接收人可见
+	audiences := []string{model.NoticeAudienceAll}
+	if resource.IsAdmin(c) {
+		audiences = append(audiences, model.NoticeAudienceAdmin)
+	}
+	db = db.Where("audience in ? or (audience = ? and staff_id = ?)", audiences, model.NoticeAudienceStaff, resource.CurrentStaffId(c))
+	db = db.Session(&gorm.Session{})
 	if start == -1 && limit == -1 {
 		// 不加分页
 		if noticeTitle != "all" {
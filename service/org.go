@@ -0,0 +1,383 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"hrms/model"
+	"hrms/resource"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var ErrOrgStaffNotExist = errors.New("员工不存在或已离职")
+
+// 由员工上级关系构成的组织架构图，不含内置账号及已离职员工
+type orgGraph struct {
+	staffs   map[string]*model.Staff
+	children map[string][]string
+	depNames map[string]string
+	totals   map[string]int
+}
+
+func loadOrgGraph(db *gorm.DB) (*orgGraph, error) {
+	var staffs []*model.Staff
+	if err := db.Where("staff_id != 'root' and staff_id != 'admin'").Order("staff_id").Find(&staffs).Error; err != nil {
+		return nil, err
+	}
+	var deps []*model.Department
+	if err := db.Find(&deps).Error; err != nil {
+		return nil, err
+	}
+	g := &orgGraph{
+		staffs:   make(map[string]*model.Staff),
+		children: make(map[string][]string),
+		depNames: make(map[string]string),
+		totals:   make(map[string]int),
+	}
+	for _, dep := range deps {
+		g.depNames[dep.DepId] = dep.DepName
+	}
+	now := time.Now()
+	for _, staff := range staffs {
+		if !staff.HasLeft(now) {
+			g.staffs[staff.StaffId] = staff
+		}
+	}
+	for _, staff := range staffs {
+		if leader := g.leader(staff.StaffId); leader != "" {
+			g.children[leader] = append(g.children[leader], staff.StaffId)
+		}
+	}
+	return g, nil
+}
+
+func loadOrgGraphByContext(c *gin.Context) (*orgGraph, error) {
+	db := resource.HrmsDB(c)
+	if db == nil {
+		return nil, resource.ErrUnauthorized
+	}
+	g, err := loadOrgGraph(db)
+	if err != nil {
+		log.Printf("loadOrgGraph err = %v", err)
+	}
+	return g, err
+}
+
+// 上级工号，上级不存在、已离职或为本人时返回空
+func (g *orgGraph) leader(staffId string) string {
+	staff, ok := g.staffs[staffId]
+	if !ok {
+		return ""
+	}
+	leader := staff.LeaderStaffId
+	if leader == staffId {
+		return ""
+	}
+	if _, ok := g.staffs[leader]; !ok {
+		return ""
+	}
+	return leader
+}
+
+// 汇报关系中的所有环路，每个环路按从环中最小工号开始的上级方向排列
+func (g *orgGraph) cycles() [][]string {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int)
+	var cycles [][]string
+	ids := make([]string, 0, len(g.staffs))
+	for id := range g.staffs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		var path []string
+		cur := id
+		for cur != "" && state[cur] == unvisited {
+			state[cur] = visiting
+			path = append(path, cur)
+			cur = g.leader(cur)
+		}
+		if cur != "" && state[cur] == visiting {
+			// 从 cur 开始的路径构成环
+			for i, pid := range path {
+				if pid == cur {
+					cycles = append(cycles, rotateMin(path[i:]))
+					break
+				}
+			}
+		}
+		for _, pid := range path {
+			state[pid] = done
+		}
+	}
+	return cycles
+}
+
+func rotateMin(cycle []string) []string {
+	min := 0
+	for i, id := range cycle {
+		if id < cycle[min] {
+			min = i
+		}
+	}
+	return append(append([]string{}, cycle[min:]...), cycle[:min]...)
+}
+
+// 最高负责人，即没有有效上级的员工；环路中的员工无最高负责人，取环中最小工号作为入口
+func (g *orgGraph) roots() []string {
+	var roots []string
+	for id := range g.staffs {
+		if g.leader(id) == "" {
+			roots = append(roots, id)
+		}
+	}
+	for _, cycle := range g.cycles() {
+		roots = append(roots, cycle[0])
+	}
+	sort.Strings(roots)
+	return roots
+}
+
+// 全部下属人数，环路中的员工只计算一次
+func (g *orgGraph) totalReports(staffId string, seen map[string]bool) int {
+	if total, ok := g.totals[staffId]; ok && seen == nil {
+		return total
+	}
+	top := seen == nil
+	if top {
+		seen = map[string]bool{staffId: true}
+	}
+	total := 0
+	for _, child := range g.children[staffId] {
+		if seen[child] {
+			continue
+		}
+		seen[child] = true
+		total += 1 + g.totalReports(child, seen)
+	}
+	if top {
+		g.totals[staffId] = total
+	}
+	return total
+}
+
+// 构建以 staffId 为根的子树，maxDepth 小于等于0时不限层数
+func (g *orgGraph) node(staffId string, depth, maxDepth int, inCycle map[string]bool, path map[string]bool) *model.OrgNode {
+	staff := g.staffs[staffId]
+	node := &model.OrgNode{
+		StaffId:       staff.StaffId,
+		StaffName:     staff.StaffName,
+		LeaderStaffId: g.leader(staffId),
+		DepId:         staff.DepId,
+		DepName:       g.depNames[staff.DepId],
+		RankId:        staff.RankId,
+		DirectReports: len(g.children[staffId]),
+		TotalReports:  g.totalReports(staffId, nil),
+		InCycle:       inCycle[staffId],
+	}
+	if maxDepth > 0 && depth >= maxDepth {
+		return node
+	}
+	path[staffId] = true
+	for _, child := range g.children[staffId] {
+		// 环路中回到祖先节点时停止展开
+		if path[child] {
+			continue
+		}
+		node.Children = append(node.Children, g.node(child, depth+1, maxDepth, inCycle, path))
+	}
+	delete(path, staffId)
+	return node
+}
+
+func (g *orgGraph) cycleMembers() map[string]bool {
+	members := make(map[string]bool)
+	for _, cycle := range g.cycles() {
+		for _, id := range cycle {
+			members[id] = true
+		}
+	}
+	return members
+}
+
+// 查询组织架构树，staffId 为 all 时返回整个组织
+func GetOrgTree(c *gin.Context, staffId string, maxDepth int) ([]*model.OrgNode, error) {
+	g, err := loadOrgGraphByContext(c)
+	if err != nil {
+		return nil, err
+	}
+	roots := []string{staffId}
+	if staffId == "all" {
+		roots = g.roots()
+	} else if _, ok := g.staffs[staffId]; !ok {
+		return nil, ErrOrgStaffNotExist
+	}
+	inCycle := g.cycleMembers()
+	nodes := make([]*model.OrgNode, 0, len(roots))
+	for _, root := range roots {
+		nodes = append(nodes, g.node(root, 1, maxDepth, inCycle, make(map[string]bool)))
+	}
+	return nodes, nil
+}
+
+// 查询员工到最高负责人的汇报链，第一个为员工本人
+func GetManagementChain(c *gin.Context, staffId string) ([]*model.OrgNode, error) {
+	g, err := loadOrgGraphByContext(c)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := g.staffs[staffId]; !ok {
+		return nil, ErrOrgStaffNotExist
+	}
+	var chain []*model.OrgNode
+	seen := make(map[string]bool)
+	for cur := staffId; cur != ""; cur = g.leader(cur) {
+		if seen[cur] {
+			return chain, fmt.Errorf("汇报关系存在环路，在 %v 处重复", cur)
+		}
+		seen[cur] = true
+		staff := g.staffs[cur]
+		chain = append(chain, &model.OrgNode{
+			StaffId:       staff.StaffId,
+			StaffName:     staff.StaffName,
+			LeaderStaffId: g.leader(cur),
+			DepId:         staff.DepId,
+			DepName:       g.depNames[staff.DepId],
+			RankId:        staff.RankId,
+			DirectReports: len(g.children[cur]),
+			TotalReports:  g.totalReports(cur, nil),
+		})
+	}
+	return chain, nil
+}
+
+// 统计管理幅度，staffId 为 all 时统计整个组织
+func GetOrgSpanStats(c *gin.Context, staffId string) (*model.OrgSpanStats, error) {
+	nodes, err := GetOrgTree(c, staffId, 0)
+	if err != nil {
+		return nil, err
+	}
+	stats := &model.OrgSpanStats{Spans: []model.OrgSpan{}}
+	totalSpan := 0
+	var walk func(node *model.OrgNode, level int)
+	walk = func(node *model.OrgNode, level int) {
+		stats.Headcount++
+		if level > stats.MaxDepth {
+			stats.MaxDepth = level
+		}
+		if node.DirectReports > 0 {
+			stats.Managers++
+			totalSpan += node.DirectReports
+			if node.DirectReports > stats.MaxSpan {
+				stats.MaxSpan = node.DirectReports
+			}
+			stats.Spans = append(stats.Spans, model.OrgSpan{
+				StaffId:       node.StaffId,
+				StaffName:     node.StaffName,
+				DirectReports: node.DirectReports,
+				TotalReports:  node.TotalReports,
+				Level:         level,
+			})
+		}
+		for _, child := range node.Children {
+			walk(child, level+1)
+		}
+	}
+	for _, node := range nodes {
+		walk(node, 1)
+	}
+	if stats.Managers > 0 {
+		stats.AvgSpan = float64(totalSpan) / float64(stats.Managers)
+	}
+	// 按直接下属人数倒序
+	sort.SliceStable(stats.Spans, func(i, j int) bool {
+		return stats.Spans[i].DirectReports > stats.Spans[j].DirectReports
+	})
+	return stats, nil
+}
+
+// 查询汇报关系中的所有环路
+func GetOrgCycles(c *gin.Context) ([][]string, error) {
+	g, err := loadOrgGraphByContext(c)
+	if err != nil {
+		return nil, err
+	}
+	return g.cycles(), nil
+}
+
+var ErrInvalidLeader = errors.New("上级设置错误")
+
+// 检查将 staffId 的上级改为 leaderStaffId 后是否会形成环路
+func CheckLeaderChange(db *gorm.DB, staffId, leaderStaffId string) error {
+	if leaderStaffId == "" {
+		return nil
+	}
+	if leaderStaffId == staffId {
+		return fmt.Errorf("%w: 不能将本人设为上级", ErrInvalidLeader)
+	}
+	g, err := loadOrgGraph(db)
+	if err != nil {
+		return err
+	}
+	seen := make(map[string]bool)
+	for cur := leaderStaffId; cur != ""; cur = g.leader(cur) {
+		if cur == staffId {
+			return fmt.Errorf("%w: %v 是 %v 的下属，不能设为其上级", ErrInvalidLeader, leaderStaffId, staffId)
+		}
+		// 已有环路与本次修改无关，不再继续查找
+		if seen[cur] {
+			break
+		}
+		seen[cur] = true
+	}
+	return nil
+}
+
+// 将组织架构树导出为 Graphviz DOT 格式
+func OrgTreeToDot(nodes []*model.OrgNode) string {
+	var b strings.Builder
+	b.WriteString("digraph org {\n")
+	b.WriteString("  rankdir=TB;\n")
+	b.WriteString("  node [shape=box, fontname=\"sans-serif\"];\n")
+	written := make(map[string]bool)
+	edges := make(map[[2]string]bool)
+	var cycleNodes []*model.OrgNode
+	var walk func(node *model.OrgNode)
+	walk = func(node *model.OrgNode) {
+		if !written[node.StaffId] {
+			written[node.StaffId] = true
+			attrs := ""
+			if node.InCycle {
+				attrs = ", color=red"
+				cycleNodes = append(cycleNodes, node)
+			}
+			fmt.Fprintf(&b, "  %q [label=%q%s];\n", node.StaffId,
+				fmt.Sprintf("%v\n%v\n%v", node.StaffName, node.StaffId, node.DepName), attrs)
+		}
+		for _, child := range node.Children {
+			walk(child)
+			edges[[2]string{node.StaffId, child.StaffId}] = true
+			fmt.Fprintf(&b, "  %q -> %q;\n", node.StaffId, child.StaffId)
+		}
+	}
+	for _, node := range nodes {
+		walk(node)
+	}
+	// 树中省略了环路的回边，单独补上
+	for _, node := range cycleNodes {
+		if written[node.LeaderStaffId] && !edges[[2]string{node.LeaderStaffId, node.StaffId}] {
+			fmt.Fprintf(&b, "  %q -> %q [color=red];\n", node.LeaderStaffId, node.StaffId)
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
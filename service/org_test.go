@@ -0,0 +1,105 @@
+package service
+
+import (
+	"errors"
+	"hrms/model"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+	_ "modernc.org/sqlite"
+)
+
+// 创建测试用的 SQLite 数据库并迁移指定模型
+func newTestDB(t *testing.T, models ...interface{}) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Dialector{
+		DriverName: "sqlite",
+		DSN:        filepath.Join(t.TempDir(), "test.db"),
+	}, &gorm.Config{
+		NamingStrategy: schema.NamingStrategy{SingularTable: true},
+		Logger:         logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("打开数据库失败: %v", err)
+	}
+	if err := db.AutoMigrate(models...); err != nil {
+		t.Fatalf("迁移模型失败: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+func TestCheckLeaderChange(t *testing.T) {
+	db := newTestDB(t, &model.Staff{}, &model.Department{})
+	// A <- B <- C <- D，E <- F，X 与 Y 互为上级，G 已离职
+	staffs := []model.Staff{
+		{StaffId: "A"},
+		{StaffId: "B", LeaderStaffId: "A"},
+		{StaffId: "C", LeaderStaffId: "B"},
+		{StaffId: "D", LeaderStaffId: "C"},
+		{StaffId: "E"},
+		{StaffId: "F", LeaderStaffId: "E"},
+		{StaffId: "X", LeaderStaffId: "Y"},
+		{StaffId: "Y", LeaderStaffId: "X"},
+		{StaffId: "G", LeaderStaffId: "D", Status: model.StaffStatusResigned, StatusDate: time.Now().AddDate(0, -1, 0)},
+	}
+	if err := db.Create(&staffs).Error; err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		staffId       string
+		leaderStaffId string
+		wantErr       bool
+	}{
+		{"B", "", false},
+		{"B", "B", true},
+		{"B", "C", true},
+		{"B", "D", true},
+		{"A", "D", true},
+		{"D", "A", false},
+		{"A", "F", false},
+		{"F", "D", false},
+		// 已有环路不影响其他员工修改上级
+		{"C", "X", false},
+		{"X", "Y", true},
+		// 已离职员工不在汇报关系中
+		{"D", "G", false},
+		{"A", "unknown", false},
+	}
+	for _, tc := range cases {
+		err := CheckLeaderChange(db, tc.staffId, tc.leaderStaffId)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("CheckLeaderChange(%q, %q) err = %v, wantErr %v", tc.staffId, tc.leaderStaffId, err, tc.wantErr)
+		}
+		if err != nil && !errors.Is(err, ErrInvalidLeader) {
+			t.Errorf("CheckLeaderChange(%q, %q) err = %v, 应为 ErrInvalidLeader", tc.staffId, tc.leaderStaffId, err)
+		}
+	}
+}
+
+func TestUpdateStaffRejectsLeaderCycle(t *testing.T) {
+	db, c := newStaffTestContext(t)
+	if err := db.Create(&[]model.Staff{{StaffId: "A"}, {StaffId: "B", LeaderStaffId: "A"}}).Error; err != nil {
+		t.Fatal(err)
+	}
+	err := UpdateStaff(c, &model.Staff{StaffId: "A", LeaderStaffId: "B"})
+	if !errors.Is(err, ErrInvalidLeader) {
+		t.Fatalf("err = %v, want ErrInvalidLeader", err)
+	}
+	var staff model.Staff
+	if err := db.Where("staff_id = ?", "A").First(&staff).Error; err != nil {
+		t.Fatal(err)
+	}
+	if staff.LeaderStaffId != "" {
+		t.Errorf("上级不应被修改, leader = %v", staff.LeaderStaffId)
+	}
+}
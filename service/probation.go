@@ -0,0 +1,326 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"hrms/model"
+	"hrms/resource"
+	"log"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var ErrStaffProbationNotExist = errors.New("试用期考核记录不存在")
+
+// 未配置时的默认试用期月数
+const defaultProbationMonths = 3
+
+// 以试用期入职时创建试用期考核记录，试用期从入职日期起算
+func createStaffProbation(tx *gorm.DB, staff *model.Staff, months int64) error {
+	if months == 0 && resource.HrmsConf != nil {
+		months = resource.HrmsConf.Contract.ProbationMonths
+	}
+	if months == 0 {
+		months = defaultProbationMonths
+	}
+	if months < 0 || months > maxProbationMonths {
+		return fmt.Errorf("试用期应为1至%v个月", maxProbationMonths)
+	}
+	endDate := staff.EntryDate.AddDate(0, int(months), 0)
+	return tx.Create(&model.StaffProbation{
+		ProbationId:     RandomID("probation"),
+		StaffId:         staff.StaffId,
+		StaffName:       staff.StaffName,
+		StartDate:       staff.EntryDate,
+		EndDate:         endDate,
+		OriginalEndDate: endDate,
+		Status:          model.ProbationStatusPending,
+	}).Error
+}
+
+// 员工进行中的试用期考核，没有时返回 nil
+func openStaffProbation(db *gorm.DB, staffId string) (*model.StaffProbation, error) {
+	var probations []model.StaffProbation
+	if err := db.Where("staff_id = ? and status in ?", staffId,
+		[]string{model.ProbationStatusPending, model.ProbationStatusEvaluated}).Find(&probations).Error; err != nil {
+		return nil, err
+	}
+	if len(probations) == 0 {
+		return nil, nil
+	}
+	return &probations[0], nil
+}
+
+// 试用期内离职时结束考核
+func closeStaffProbation(tx *gorm.DB, c *gin.Context, probation *model.StaffProbation, reason string) error {
+	return tx.Model(&model.StaffProbation{}).Where("id = ?", probation.ID).Updates(map[string]interface{}{
+		"status":          model.ProbationStatusTerminated,
+		"decision_remark": reason,
+		"decider_id":      resource.CurrentStaffId(c),
+		"decision_date":   time.Now(),
+	}).Error
+}
+
+func getStaffProbation(db *gorm.DB, probationId string) (*model.StaffProbation, error) {
+	var probations []model.StaffProbation
+	if err := db.Where("probation_id = ?", probationId).Find(&probations).Error; err != nil {
+		return nil, err
+	}
+	if len(probations) == 0 {
+		return nil, ErrStaffProbationNotExist
+	}
+	return &probations[0], nil
+}
+
+func getProbationStaff(db *gorm.DB, staffId string) (*model.Staff, error) {
+	var staff model.Staff
+	if err := db.Where("staff_id = ?", staffId).First(&staff).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("员工 %v 不存在", staffId)
+		}
+		return nil, err
+	}
+	return &staff, nil
+}
+
+// 上级填写试用期评估，人事确认前可重新评估
+func EvaluateProbation(c *gin.Context, dto *model.ProbationEvaluateDTO) error {
+	db := resource.HrmsDB(c)
+	if db == nil {
+		return resource.ErrUnauthorized
+	}
+	if _, ok := model.ProbationDecisionNames[dto.Recommendation]; !ok {
+		return fmt.Errorf("无效的评估建议: %v", dto.Recommendation)
+	}
+	if dto.EvalScore < 0 || dto.EvalScore > 100 {
+		return errors.New("评分应在0至100之间")
+	}
+	probation, err := getStaffProbation(db, dto.ProbationId)
+	if err != nil {
+		return err
+	}
+	staff, err := getProbationStaff(db, probation.StaffId)
+	if err != nil {
+		return err
+	}
+	// 仅直属上级可评估
+	if staff.LeaderStaffId == "" || staff.LeaderStaffId != resource.CurrentStaffId(c) {
+		return resource.ErrForbidden
+	}
+	result := db.Model(&model.StaffProbation{}).
+		Where("id = ? and status in ?", probation.ID, []string{model.ProbationStatusPending, model.ProbationStatusEvaluated}).
+		Updates(map[string]interface{}{
+			"status":         model.ProbationStatusEvaluated,
+			"evaluator_id":   resource.CurrentStaffId(c),
+			"eval_score":     dto.EvalScore,
+			"eval_comment":   dto.EvalComment,
+			"recommendation": dto.Recommendation,
+			"eval_date":      time.Now(),
+		})
+	if result.Error != nil {
+		log.Printf("EvaluateProbation err = %v", result.Error)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("试用期考核状态为%v，不能评估", model.ProbationStatusNames[probation.Status])
+	}
+	return nil
+}
+
+// 人事确认转正、延长试用期或不予录用；员工没有上级时可直接确认
+func DecideProbation(c *gin.Context, dto *model.ProbationDecideDTO) error {
+	db := resource.HrmsDB(c)
+	if db == nil {
+		return resource.ErrUnauthorized
+	}
+	if !resource.IsAdmin(c) {
+		return resource.ErrForbidden
+	}
+	if _, ok := model.ProbationDecisionNames[dto.Decision]; !ok {
+		return fmt.Errorf("无效的确认结果: %v", dto.Decision)
+	}
+	if dto.Salary != nil && dto.Decision != model.ProbationDecisionConvert {
+		return errors.New("仅转正时可调整薪资")
+	}
+	effectiveDate, err := ParseDepDate(dto.EffectiveDate)
+	if err != nil {
+		return err
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		probation, err := getStaffProbation(tx, dto.ProbationId)
+		if err != nil {
+			return err
+		}
+		staff, err := getProbationStaff(tx, probation.StaffId)
+		if err != nil {
+			return err
+		}
+		switch {
+		case probation.Status == model.ProbationStatusPending && staff.LeaderStaffId != "":
+			return errors.New("上级尚未完成评估，不能确认")
+		case probation.Status != model.ProbationStatusPending && probation.Status != model.ProbationStatusEvaluated:
+			return fmt.Errorf("试用期考核状态为%v，不能确认", model.ProbationStatusNames[probation.Status])
+		}
+		updates := map[string]interface{}{
+			"decision":        dto.Decision,
+			"decision_remark": dto.Remark,
+			"decider_id":      resource.CurrentStaffId(c),
+			"decision_date":   time.Now(),
+		}
+		switch dto.Decision {
+		case model.ProbationDecisionConvert:
+			if effectiveDate.IsZero() {
+				effectiveDate = probation.EndDate
+			}
+			if staff.Status != model.StaffStatusProbation {
+				return fmt.Errorf("员工当前状态为%v，不能转正", StaffStatusName(staff.Status))
+			}
+			if err := transferStaffStatus(tx, c, staff, model.StaffStatusActive, effectiveDate, "试用期转正"); err != nil {
+				return err
+			}
+			if dto.Salary != nil {
+				before, err := convertStaffSalary(tx, c, staff.StaffId, dto.Salary)
+				if err != nil {
+					return err
+				}
+				updates["salary_base_before"] = before
+				updates["salary_base_after"] = dto.Salary.Base
+			}
+			updates["status"] = model.ProbationStatusConverted
+		case model.ProbationDecisionExtend:
+			if dto.ExtendMonths <= 0 {
+				return errors.New("请填写延长的月数")
+			}
+			endDate := probation.EndDate.AddDate(0, int(dto.ExtendMonths), 0)
+			if endDate.After(probation.StartDate.AddDate(0, maxProbationMonths, 0)) {
+				return fmt.Errorf("延长后试用期不能超过%v个月", maxProbationMonths)
+			}
+			// 劳动合同中的试用期截止日期同步延长，试用期到期提醒按合同生成
+			if err := tx.Model(&model.StaffContract{}).Where("staff_id = ? and status = ?", staff.StaffId, model.ContractStatusActive).
+				Update("probation_end_date", endDate).Error; err != nil {
+				return err
+			}
+			// 延长后需上级重新评估
+			updates["end_date"] = endDate
+			updates["extend_count"] = probation.ExtendCount + 1
+			updates["status"] = model.ProbationStatusPending
+		case model.ProbationDecisionTerminate:
+			if effectiveDate.IsZero() {
+				effectiveDate = time.Now()
+			}
+			if err := transferStaffStatus(tx, c, staff, model.StaffStatusTerminated, effectiveDate, "试用期考核未通过"); err != nil {
+				return err
+			}
+			updates["status"] = model.ProbationStatusTerminated
+		}
+		result := tx.Model(&model.StaffProbation{}).Where("id = ? and status = ?", probation.ID, probation.Status).Updates(updates)
+		if result.Error != nil {
+			log.Printf("DecideProbation err = %v", result.Error)
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("试用期考核已被其他人处理，请刷新后重试")
+		}
+		return nil
+	})
+}
+
+// 转正时按新薪资更新员工的薪资模板及基本工资，返回调整前的基本工资
+func convertStaffSalary(tx *gorm.DB, c *gin.Context, staffId string, dto *model.ProbationSalaryDTO) (int64, error) {
+	var staff model.Staff
+	if err := tx.Where("staff_id = ?", staffId).First(&staff).Error; err != nil {
+		return 0, err
+	}
+	var salaries []model.Salary
+	if err := tx.Where("staff_id = ?", staffId).Find(&salaries).Error; err != nil {
+		return 0, err
+	}
+	before := staff.BaseSalary
+	if len(salaries) == 0 {
+		salary := model.Salary{
+			SalaryId:   RandomID("salary"),
+			StaffId:    staffId,
+			StaffName:  staff.StaffName,
+			Base:       dto.Base,
+			Subsidy:    dto.Subsidy,
+			Bonus:      dto.Bonus,
+			Commission: dto.Commission,
+			Other:      dto.Other,
+			Fund:       dto.Fund,
+		}
+		if err := tx.Create(&salary).Error; err != nil {
+			return 0, err
+		}
+	} else {
+		before = salaries[0].Base
+		if err := tx.Model(&model.Salary{}).Where("id = ?", salaries[0].ID).Updates(map[string]interface{}{
+			"base":       dto.Base,
+			"subsidy":    dto.Subsidy,
+			"bonus":      dto.Bonus,
+			"commission": dto.Commission,
+			"other":      dto.Other,
+			"fund":       dto.Fund,
+		}).Error; err != nil {
+			return 0, err
+		}
+	}
+	if err := tx.Model(&model.Staff{}).Where("id = ?", staff.ID).Update("base_salary", dto.Base).Error; err != nil {
+		return 0, err
+	}
+	cur := staff
+	cur.BaseSalary = dto.Base
+	return before, RecordStaffHistory(tx, c, model.StaffActionConvert, &staff, &cur)
+}
+
+func staffProbationVOs(probations []model.StaffProbation) []model.StaffProbationVO {
+	vos := make([]model.StaffProbationVO, 0, len(probations))
+	for _, p := range probations {
+		vos = append(vos, model.StaffProbationVO{
+			StaffProbation:     p,
+			StatusName:         model.ProbationStatusNames[p.Status],
+			RecommendationName: model.ProbationDecisionNames[p.Recommendation],
+			DecisionName:       model.ProbationDecisionNames[p.Decision],
+		})
+	}
+	return vos
+}
+
+// 查询员工的试用期考核记录，管理员、本人及上级可查看
+func GetStaffProbations(c *gin.Context, staffId string) ([]model.StaffProbationVO, error) {
+	policy, err := NewMaskPolicy(c)
+	if err != nil {
+		return nil, err
+	}
+	if !policy.isAdmin() && staffId != policy.staffId && !policy.isLeaderOf(staffId) {
+		return nil, resource.ErrForbidden
+	}
+	var probations []model.StaffProbation
+	if err := policy.db.Where("staff_id = ?", staffId).Order("id desc").Find(&probations).Error; err != nil {
+		log.Printf("GetStaffProbations err = %v", err)
+		return nil, err
+	}
+	return staffProbationVOs(probations), nil
+}
+
+// 待处理的试用期考核：管理员查看全部进行中的考核，上级查看待自己评估的直属下级
+func GetPendingProbations(c *gin.Context) ([]model.StaffProbationVO, error) {
+	db := resource.HrmsDB(c)
+	if db == nil {
+		return nil, resource.ErrUnauthorized
+	}
+	query := db.Model(&model.StaffProbation{})
+	if resource.IsAdmin(c) {
+		query = query.Where("status in ?", []string{model.ProbationStatusPending, model.ProbationStatusEvaluated})
+	} else {
+		query = query.Where("status = ? and staff_id in (?)", model.ProbationStatusPending,
+			db.Model(&model.Staff{}).Select("staff_id").Where("leader_staff_id = ?", resource.CurrentStaffId(c)))
+	}
+	var probations []model.StaffProbation
+	if err := query.Order("end_date").Find(&probations).Error; err != nil {
+		log.Printf("GetPendingProbations err = %v", err)
+		return nil, err
+	}
+	return staffProbationVOs(probations), nil
+}
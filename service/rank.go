@@ -0,0 +1,240 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"hrms/model"
+	"hrms/resource"
+	"log"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 校验职级等级及薪资带宽，未填写带宽中位值时取上下限的中间值
+func ValidateRank(rank *model.Rank) error {
+	if rank.Level < 0 {
+		return errors.New("职级等级不能为负数")
+	}
+	if rank.SalaryMin == 0 && rank.SalaryMid == 0 && rank.SalaryMax == 0 {
+		return nil
+	}
+	if rank.SalaryMin <= 0 || rank.SalaryMax < rank.SalaryMin {
+		return errors.New("薪资带宽下限应大于0且不高于上限")
+	}
+	if rank.SalaryMid == 0 {
+		rank.SalaryMid = (rank.SalaryMin + rank.SalaryMax) / 2
+	}
+	if rank.SalaryMid < rank.SalaryMin || rank.SalaryMid > rank.SalaryMax {
+		return errors.New("薪资带宽中位值应在上下限之间")
+	}
+	return nil
+}
+
+func rankChangeType(fromLevel, toLevel int64) string {
+	switch {
+	case toLevel > fromLevel:
+		return model.RankChangePromotion
+	case toLevel < fromLevel:
+		return model.RankChangeDemotion
+	default:
+		return model.RankChangeLateral
+	}
+}
+
+// 在事务中记录员工的职级变动，staff 为变动前的员工信息
+func recordRankChange(tx *gorm.DB, c *gin.Context, staff *model.Staff, toRankId string, effectiveDate time.Time, reason string) error {
+	var ranks []model.Rank
+	if err := tx.Where("rank_id in ?", []string{staff.RankId, toRankId}).Find(&ranks).Error; err != nil {
+		return err
+	}
+	var from, to model.Rank
+	for _, rank := range ranks {
+		if rank.RankId == staff.RankId {
+			from = rank
+		}
+		if rank.RankId == toRankId {
+			to = rank
+		}
+	}
+	if to.RankId == "" {
+		return fmt.Errorf("职级 %v 不存在", toRankId)
+	}
+	return tx.Create(&model.RankChange{
+		ChangeId:      RandomID("rank_change"),
+		StaffId:       staff.StaffId,
+		StaffName:     staff.StaffName,
+		FromRankId:    staff.RankId,
+		FromRankName:  from.RankName,
+		FromLevel:     from.Level,
+		ToRankId:      to.RankId,
+		ToRankName:    to.RankName,
+		ToLevel:       to.Level,
+		ChangeType:    rankChangeType(from.Level, to.Level),
+		EffectiveDate: effectiveDate,
+		Reason:        reason,
+		OperatorId:    resource.CurrentStaffId(c),
+	}).Error
+}
+
+// 调整员工职级并记录变动，生效日期不能晚于当天
+func ChangeStaffRank(c *gin.Context, dto *model.RankChangeDTO) error {
+	db := resource.HrmsDB(c)
+	if db == nil {
+		return resource.ErrUnauthorized
+	}
+	if !resource.IsAdmin(c) {
+		return resource.ErrForbidden
+	}
+	effectiveDate, err := ParseDepDate(dto.EffectiveDate)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	if effectiveDate.IsZero() {
+		effectiveDate = today
+	}
+	if effectiveDate.After(today) {
+		return errors.New("生效日期不能晚于今天")
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		var old model.Staff
+		if err := tx.Where("staff_id = ? and staff_id != 'root' and staff_id != 'admin'", dto.StaffId).First(&old).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("员工不存在")
+			}
+			return err
+		}
+		if old.RankId == dto.RankId {
+			return errors.New("新职级与当前职级相同")
+		}
+		if err := recordRankChange(tx, c, &old, dto.RankId, effectiveDate, dto.Reason); err != nil {
+			return err
+		}
+		if err := tx.Model(&model.Staff{}).Where("id = ?", old.ID).Update("rank_id", dto.RankId).Error; err != nil {
+			log.Printf("ChangeStaffRank err = %v", err)
+			return err
+		}
+		cur := old
+		cur.RankId = dto.RankId
+		return RecordStaffHistory(tx, c, model.StaffActionRank, &old, &cur)
+	})
+}
+
+// 查询员工的职级变动记录，按生效日期倒序，管理员及本人可查看
+func GetRankChanges(c *gin.Context, staffId string) ([]model.RankChangeVO, error) {
+	db := resource.HrmsDB(c)
+	if db == nil {
+		return nil, resource.ErrUnauthorized
+	}
+	if !resource.IsAdmin(c) && staffId != resource.CurrentStaffId(c) {
+		return nil, resource.ErrForbidden
+	}
+	var changes []model.RankChange
+	if err := db.Where("staff_id = ?", staffId).Order("effective_date desc, id desc").Find(&changes).Error; err != nil {
+		log.Printf("GetRankChanges err = %v", err)
+		return nil, err
+	}
+	vos := make([]model.RankChangeVO, 0, len(changes))
+	for _, change := range changes {
+		vos = append(vos, model.RankChangeVO{
+			RankChange:     change,
+			ChangeTypeName: model.RankChangeNames[change.ChangeType],
+		})
+	}
+	return vos, nil
+}
+
+// 基本工资相对职级带宽的偏离，未设带宽或在带宽内时为空
+func salaryBandDeviation(rank *model.Rank, base int64) string {
+	if rank == nil || rank.SalaryMax == 0 {
+		return ""
+	}
+	if base < rank.SalaryMin {
+		return "below"
+	}
+	if base > rank.SalaryMax {
+		return "above"
+	}
+	return ""
+}
+
+// 薪资模板的基本工资超出员工职级带宽时返回提示，不影响保存
+func SalaryBandWarning(c *gin.Context, staffId string, base int64) string {
+	db := resource.HrmsDB(c)
+	if db == nil {
+		return ""
+	}
+	var rank model.Rank
+	if err := db.Where("rank_id = (?)", db.Model(&model.Staff{}).Select("rank_id").Where("staff_id = ?", staffId)).
+		Limit(1).Find(&rank).Error; err != nil {
+		log.Printf("SalaryBandWarning err = %v", err)
+		return ""
+	}
+	switch salaryBandDeviation(&rank, base) {
+	case "below":
+		return fmt.Sprintf("基本工资低于职级 %v 的薪资带宽下限 %v", rank.RankName, rank.SalaryMin)
+	case "above":
+		return fmt.Sprintf("基本工资高于职级 %v 的薪资带宽上限 %v", rank.RankName, rank.SalaryMax)
+	}
+	return ""
+}
+
+// 查询基本工资超出员工职级带宽的薪资模板
+func CheckSalaryBands(c *gin.Context) ([]model.SalaryBandCheck, error) {
+	db := resource.HrmsDB(c)
+	if db == nil {
+		return nil, resource.ErrUnauthorized
+	}
+	if !resource.IsAdmin(c) {
+		return nil, resource.ErrForbidden
+	}
+	var ranks []model.Rank
+	if err := db.Where("salary_max > 0").Find(&ranks).Error; err != nil {
+		return nil, err
+	}
+	rankById := make(map[string]*model.Rank, len(ranks))
+	for i := range ranks {
+		rankById[ranks[i].RankId] = &ranks[i]
+	}
+	var staffs []model.Staff
+	if err := db.Select("staff_id", "rank_id").Where("staff_id != 'root' and staff_id != 'admin'").Find(&staffs).Error; err != nil {
+		return nil, err
+	}
+	staffRank := make(map[string]string, len(staffs))
+	for _, staff := range staffs {
+		staffRank[staff.StaffId] = staff.RankId
+	}
+	var salaries []model.Salary
+	if err := db.Order("staff_id").Find(&salaries).Error; err != nil {
+		log.Printf("CheckSalaryBands err = %v", err)
+		return nil, err
+	}
+	checks := make([]model.SalaryBandCheck, 0)
+	var staffIds []string
+	for _, salary := range salaries {
+		rank := rankById[staffRank[salary.StaffId]]
+		deviation := salaryBandDeviation(rank, salary.Base)
+		if deviation == "" {
+			continue
+		}
+		checks = append(checks, model.SalaryBandCheck{
+			SalaryId:   salary.SalaryId,
+			StaffId:    salary.StaffId,
+			StaffName:  salary.StaffName,
+			RankId:     rank.RankId,
+			RankName:   rank.RankName,
+			Base:       salary.Base,
+			SalaryMin:  rank.SalaryMin,
+			SalaryMid:  rank.SalaryMid,
+			SalaryMax:  rank.SalaryMax,
+			Deviation:  deviation,
+			CompaRatio: float64(salary.Base) / float64(rank.SalaryMid),
+		})
+		staffIds = append(staffIds, salary.StaffId)
+	}
+	RecordAccess(c, model.AccessResourceSalary, "", staffIds...)
+	return checks, nil
+}
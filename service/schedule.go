@@ -0,0 +1,30 @@
+package service
+
+import (
+	"hrms/resource"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 后台定时任务，启动时及此后每天零点过后对各分公司数据库各执行一次，
+// 按自然日执行，避免按启动时间间隔执行时跨日漂移；单个分公司执行失败只打印日志，不影响其他分公司
+func runDaily(name string, job func(dbName string, db *gorm.DB, now time.Time) error) {
+	run := func() {
+		for dbName, db := range resource.DbMapper {
+			if err := job(dbName, db, time.Now()); err != nil {
+				log.Printf("[%v] %v err = %v", name, dbName, err)
+			}
+		}
+	}
+	go func() {
+		run()
+		for {
+			now := time.Now()
+			next := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 1, 0, 0, time.Local)
+			time.Sleep(next.Sub(now))
+			run()
+		}
+	}()
+}
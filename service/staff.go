@@ -0,0 +1,120 @@
+package service
+
+import (
+	"errors"
+	"hrms/model"
+	"hrms/resource"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var ErrStaffIdentityExist = errors.New("已经存在该员工")
+
+// 在事务中创建员工及登录账号，并记录变更
+func CreateStaff(tx *gorm.DB, c *gin.Context, staffCreateDto model.StaffCreateDTO) (model.Staff, error) {
+	// 校验身份证号，并由其补全出生日期及性别
+	staffCreateDto.IdentityNum = NormalizeIdentityNum(staffCreateDto.IdentityNum)
+	if err := ResolveIdentity(staffCreateDto.IdentityNum, &staffCreateDto.BirthdayStr, &staffCreateDto.SexStr); err != nil {
+		return model.Staff{}, err
+	}
+	staffID, err := NextStaffId(tx, c)
+	if err != nil {
+		return model.Staff{}, err
+	}
+	staff := model.Staff{
+		StaffId:       staffID,
+		StaffName:     staffCreateDto.StaffName,
+		LeaderStaffId: staffCreateDto.LeaderStaffId,
+		Phone:         staffCreateDto.Phone,
+		Birthday:      Str2Time(staffCreateDto.BirthdayStr, 0),
+		IdentityNum:   staffCreateDto.IdentityNum,
+		Sex:           SexStr2Int64(staffCreateDto.SexStr),
+		Nation:        staffCreateDto.Nation,
+		School:        staffCreateDto.School,
+		Major:         staffCreateDto.Major,
+		EduLevel:      staffCreateDto.EduLevel,
+		BaseSalary:    staffCreateDto.BaseSalary,
+		CardNum:       staffCreateDto.CardNum,
+		RankId:        staffCreateDto.RankId,
+		DepId:         staffCreateDto.DepId,
+		Email:         staffCreateDto.Email,
+		EntryDate:     Str2Time(staffCreateDto.EntryDateStr, 0),
+		Status:        staffCreateDto.Status,
+		Address:       staffCreateDto.Address,
+	}
+	// 新员工只能以试用期或在职状态入职
+	if staff.Status == 0 {
+		staff.Status = model.StaffStatusActive
+	}
+	if staff.Status != model.StaffStatusProbation && staff.Status != model.StaffStatusActive {
+		return staff, errors.New("新员工只能为试用期或在职状态")
+	}
+	staff.StatusDate = staff.EntryDate
+	identLen := len(staff.IdentityNum)
+	// 已删除的员工同样参与身份证号唯一性校验，与盲索引的唯一索引一致
+	var exist int64
+	if err := WhereIdentityNum(tx.Unscoped().Model(&model.Staff{}), staffCreateDto.IdentityNum).Count(&exist).Error; err != nil {
+		return staff, err
+	}
+	if exist != 0 {
+		return staff, ErrStaffIdentityExist
+	}
+	// 查询leader名称
+	var leader model.Staff
+	tx.Where("staff_id = ?", staffCreateDto.LeaderStaffId).Find(&leader)
+	staff.LeaderName = leader.StaffName
+	// 创建登陆信息，密码为身份证后六位
+	login := model.Authority{
+		AuthorityId:  RandomID("auth"),
+		StaffId:      staffID,
+		UserPassword: MD5(staff.IdentityNum[identLen-6 : identLen]),
+		//Aval:         1,
+		UserType: "normal", // 暂时只能创建普通员工
+	}
+	if err := tx.Create(&staff).Error; err != nil {
+		// 并发创建时由唯一索引兜底
+		if isUniqueViolation(err, "identity_hash") {
+			return staff, ErrStaffIdentityExist
+		}
+		return staff, err
+	}
+	if err := tx.Create(&login).Error; err != nil {
+		return staff, err
+	}
+	if staff.Status == model.StaffStatusProbation {
+		if err := createStaffProbation(tx, &staff, staffCreateDto.ProbationMonths); err != nil {
+			return staff, err
+		}
+	}
+	return staff, RecordStaffHistory(tx, c, model.StaffActionCreate, nil, &staff)
+}
+
+// 创建员工，单独使用一个事务
+func CreateStaffWithTx(c *gin.Context, staffCreateDto model.StaffCreateDTO) (model.Staff, error) {
+	db := resource.HrmsDB(c)
+	if db == nil {
+		return model.Staff{}, resource.ErrUnauthorized
+	}
+	var staff model.Staff
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		staff, err = CreateStaff(tx, c, staffCreateDto)
+		return err
+	})
+	return staff, err
+}
+
+// 唯一索引冲突，兼容 MySQL 及 SQLite 的错误信息
+func isUniqueViolation(err error, column string) bool {
+	msg := err.Error()
+	return (strings.Contains(msg, "Duplicate entry") || strings.Contains(msg, "UNIQUE constraint failed")) &&
+		strings.Contains(msg, column)
+}
+
+// 按身份证号查询，兼容尚未生成盲索引的历史明文数据
+func WhereIdentityNum(db *gorm.DB, identityNum string) *gorm.DB {
+	return db.Where("identity_hash = ? or ((identity_hash is null or identity_hash = '') and identity_num = ?)",
+		model.BlindIndex(identityNum), identityNum)
+}
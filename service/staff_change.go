@@ -0,0 +1,340 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hrms/model"
+	"hrms/resource"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var ErrStaffChangeNotExist = errors.New("修改申请不存在")
+
+var ErrStaffChangeContactsOutdated = errors.New("提交申请后紧急联系人已被修改，请驳回后由员工重新提交")
+
+// 银行卡号为12至19位数字
+func validCardNum(cardNum string) bool {
+	if len(cardNum) < 12 || len(cardNum) > 19 {
+		return false
+	}
+	for _, r := range cardNum {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// 校验申请修改的紧急联系人，修改已有联系人时只能修改本人的
+func checkChangeContacts(db *gorm.DB, staff *model.Staff, contacts []model.StaffContactDTO) error {
+	k, err := getStaffRecordKind(model.StaffRecordContact)
+	if err != nil {
+		return err
+	}
+	primary := 0
+	for i := range contacts {
+		contact := &contacts[i]
+		contact.StaffId = staff.StaffId
+		if contact.RecordId != "" {
+			record, err := findStaffRecord(db, k, contact.RecordId)
+			if err != nil {
+				return err
+			}
+			if record.Base().StaffId != staff.StaffId {
+				return ErrStaffRecordNotExist
+			}
+		}
+		if _, err := k.build(staff, contact); err != nil {
+			return fmt.Errorf("紧急联系人 %v: %v", contact.Name, err)
+		}
+		if contact.IsPrimary {
+			primary++
+		}
+	}
+	if primary > 1 {
+		return errors.New("首选联系人只能有一位")
+	}
+	return nil
+}
+
+// 员工提交个人信息修改申请，与当前信息相同的内容不计入申请，同一时间只能有一份待审批的申请
+func SubmitStaffChange(c *gin.Context, dto *model.StaffChangeSubmitDTO) (*model.StaffChangeRequest, error) {
+	db := resource.HrmsDB(c)
+	if db == nil {
+		return nil, resource.ErrUnauthorized
+	}
+	var staffs []model.Staff
+	if err := db.Where("staff_id = ? and staff_id != 'root' and staff_id != 'admin'", resource.CurrentStaffId(c)).Find(&staffs).Error; err != nil {
+		return nil, err
+	}
+	if len(staffs) == 0 {
+		return nil, resource.ErrForbidden
+	}
+	staff := staffs[0]
+	var pending int64
+	if err := db.Model(&model.StaffChangeRequest{}).
+		Where("staff_id = ? and status = ?", staff.StaffId, model.StaffChangePending).Count(&pending).Error; err != nil {
+		return nil, err
+	}
+	if pending > 0 {
+		return nil, errors.New("已有待审批的修改申请，请等待审批或撤回后再提交")
+	}
+	request := model.StaffChangeRequest{
+		RequestId: RandomID("staff_change"),
+		StaffId:   staff.StaffId,
+		StaffName: staff.StaffName,
+		Reason:    dto.Reason,
+		Status:    model.StaffChangePending,
+	}
+	var fields []string
+	if dto.Phone != 0 && dto.Phone != staff.Phone {
+		if len(strconv.FormatInt(dto.Phone, 10)) != 11 {
+			return nil, errors.New("手机号应为11位数字")
+		}
+		request.Phone = dto.Phone
+		fields = append(fields, model.SensitivePhone)
+	}
+	if email := strings.TrimSpace(dto.Email); email != "" && email != staff.Email {
+		if !strings.Contains(email, "@") {
+			return nil, errors.New("电子邮箱格式错误")
+		}
+		request.Email = email
+		fields = append(fields, model.SensitiveEmail)
+	}
+	if cardNum := strings.ReplaceAll(dto.CardNum, " ", ""); cardNum != "" && cardNum != staff.CardNum {
+		if !validCardNum(cardNum) {
+			return nil, errors.New("银行卡号应为12至19位数字")
+		}
+		request.CardNum = cardNum
+		fields = append(fields, model.SensitiveCardNum)
+	}
+	if address := strings.TrimSpace(dto.Address); address != "" && address != staff.Address {
+		request.Address = address
+		fields = append(fields, model.SensitiveAddress)
+	}
+	if len(dto.Contacts) > 0 {
+		if err := checkChangeContacts(db, &staff, dto.Contacts); err != nil {
+			return nil, err
+		}
+		contacts, err := json.Marshal(dto.Contacts)
+		if err != nil {
+			return nil, err
+		}
+		request.Contacts = string(contacts)
+		fields = append(fields, model.StaffChangeFieldContacts)
+	}
+	if len(fields) == 0 {
+		return nil, errors.New("未修改任何信息")
+	}
+	request.Fields = strings.Join(fields, ",")
+	if err := db.Create(&request).Error; err != nil {
+		log.Printf("SubmitStaffChange err = %v", err)
+		return nil, err
+	}
+	return &request, nil
+}
+
+// 员工撤回本人待审批的申请
+func CancelStaffChange(c *gin.Context, requestId string) error {
+	db := resource.HrmsDB(c)
+	if db == nil {
+		return resource.ErrUnauthorized
+	}
+	result := db.Model(&model.StaffChangeRequest{}).
+		Where("request_id = ? and staff_id = ? and status = ?", requestId, resource.CurrentStaffId(c), model.StaffChangePending).
+		Update("status", model.StaffChangeCancelled)
+	if result.Error != nil {
+		log.Printf("CancelStaffChange err = %v", result.Error)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrStaffChangeNotExist
+	}
+	return nil
+}
+
+// 申请中为修改后的全部联系人，提交后联系人又被修改或删除时不能按申请覆盖
+func checkContactsUnchanged(tx *gorm.DB, staffId string, since time.Time) error {
+	var count int64
+	if err := tx.Unscoped().Model(&model.StaffContact{}).
+		Where("staff_id = ? and (updated_at > ? or deleted_at > ?)", staffId, since, since).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrStaffChangeContactsOutdated
+	}
+	return nil
+}
+
+// 按申请替换员工的紧急联系人，申请中未保留的联系人删除
+func syncStaffContacts(tx *gorm.DB, c *gin.Context, staffId string, contacts []model.StaffContactDTO) error {
+	recordIds := make([]string, 0, len(contacts))
+	for i := range contacts {
+		contacts[i].StaffId = staffId
+		record, err := saveStaffRecord(tx, c, model.StaffRecordContact, &contacts[i])
+		if err != nil {
+			return err
+		}
+		recordIds = append(recordIds, record.Base().RecordId)
+	}
+	return tx.Where("staff_id = ? and record_id not in ?", staffId, recordIds).Delete(&model.StaffContact{}).Error
+}
+
+// 人事审批修改申请，通过时写入员工信息并记录变更
+func ReviewStaffChange(c *gin.Context, dto *model.StaffChangeReviewDTO) error {
+	db := resource.HrmsDB(c)
+	if db == nil {
+		return resource.ErrUnauthorized
+	}
+	if !resource.IsAdmin(c) {
+		return resource.ErrForbidden
+	}
+	status := model.StaffChangeApproved
+	switch dto.Decision {
+	case model.StaffChangeDecisionApprove:
+	case model.StaffChangeDecisionReject:
+		if strings.TrimSpace(dto.Comment) == "" {
+			return errors.New("驳回时请填写审批意见")
+		}
+		status = model.StaffChangeRejected
+	default:
+		return fmt.Errorf("不支持的审批结果: %v", dto.Decision)
+	}
+	var request model.StaffChangeRequest
+	if err := db.Where("request_id = ?", dto.RequestId).First(&request).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrStaffChangeNotExist
+		}
+		return err
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// 以状态为条件更新，避免重复审批
+		result := tx.Model(&model.StaffChangeRequest{}).Where("id = ? and status = ?", request.ID, model.StaffChangePending).
+			Updates(map[string]interface{}{
+				"status":         status,
+				"reviewer_id":    resource.CurrentStaffId(c),
+				"review_comment": dto.Comment,
+				"review_date":    time.Now(),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("该申请已处理")
+		}
+		if status == model.StaffChangeRejected {
+			return nil
+		}
+		var old, cur model.Staff
+		if err := tx.Where("staff_id = ?", request.StaffId).First(&old).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("员工不存在")
+			}
+			return err
+		}
+		var changes model.Staff
+		for _, field := range strings.Split(request.Fields, ",") {
+			switch field {
+			case model.SensitivePhone:
+				changes.Phone = request.Phone
+			case model.SensitiveEmail:
+				changes.Email = request.Email
+			case model.SensitiveCardNum:
+				changes.CardNum = request.CardNum
+			case model.SensitiveAddress:
+				changes.Address = request.Address
+			case model.StaffChangeFieldContacts:
+				var contacts []model.StaffContactDTO
+				if err := json.Unmarshal([]byte(request.Contacts), &contacts); err != nil {
+					return err
+				}
+				if err := checkContactsUnchanged(tx, request.StaffId, request.CreatedAt); err != nil {
+					return err
+				}
+				if err := syncStaffContacts(tx, c, request.StaffId, contacts); err != nil {
+					return err
+				}
+			}
+		}
+		if err := tx.Model(&model.Staff{}).Where("id = ?", old.ID).Updates(&changes).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("id = ?", old.ID).First(&cur).Error; err != nil {
+			return err
+		}
+		return RecordStaffHistory(tx, c, model.StaffActionSelfService, &old, &cur)
+	})
+	if err != nil {
+		log.Printf("ReviewStaffChange err = %v", err)
+	}
+	return err
+}
+
+// 申请中的修改内容以明文返回，按员工记录访问日志
+func staffChangeVOs(c *gin.Context, requests []model.StaffChangeRequest) []model.StaffChangeRequestVO {
+	vos := make([]model.StaffChangeRequestVO, 0, len(requests))
+	staffIds := make([]string, 0, len(requests))
+	fieldsByStaff := make(map[string]string, len(requests))
+	for _, request := range requests {
+		vo := model.StaffChangeRequestVO{
+			StaffChangeRequest: request,
+			StatusName:         model.StaffChangeStatusNames[request.Status],
+		}
+		for _, field := range strings.Split(request.Fields, ",") {
+			vo.FieldNames = append(vo.FieldNames, model.StaffChangeFieldNames[field])
+		}
+		if request.Contacts != "" {
+			if err := json.Unmarshal([]byte(request.Contacts), &vo.ContactList); err != nil {
+				log.Printf("staffChangeVOs 解析紧急联系人失败: %v", err)
+			}
+		}
+		vos = append(vos, vo)
+		staffIds = append(staffIds, request.StaffId)
+		if fieldsByStaff[request.StaffId] == "" {
+			fieldsByStaff[request.StaffId] = request.Fields
+		}
+	}
+	recordAccess(c, model.AccessResourceStaffChange, staffIds, fieldsByStaff)
+	return vos
+}
+
+// 查询员工的修改申请，按提交时间倒序，管理员及本人可查看
+func GetStaffChanges(c *gin.Context, staffId string) ([]model.StaffChangeRequestVO, error) {
+	db := resource.HrmsDB(c)
+	if db == nil {
+		return nil, resource.ErrUnauthorized
+	}
+	if !resource.IsAdmin(c) && staffId != resource.CurrentStaffId(c) {
+		return nil, resource.ErrForbidden
+	}
+	var requests []model.StaffChangeRequest
+	if err := db.Where("staff_id = ?", staffId).Order("id desc").Find(&requests).Error; err != nil {
+		log.Printf("GetStaffChanges err = %v", err)
+		return nil, err
+	}
+	return staffChangeVOs(c, requests), nil
+}
+
+// 待人事审批的修改申请，按提交时间排序
+func GetPendingStaffChanges(c *gin.Context) ([]model.StaffChangeRequestVO, error) {
+	db := resource.HrmsDB(c)
+	if db == nil {
+		return nil, resource.ErrUnauthorized
+	}
+	if !resource.IsAdmin(c) {
+		return nil, resource.ErrForbidden
+	}
+	var requests []model.StaffChangeRequest
+	if err := db.Where("status = ?", model.StaffChangePending).Order("id").Find(&requests).Error; err != nil {
+		log.Printf("GetPendingStaffChanges err = %v", err)
+		return nil, err
+	}
+	return staffChangeVOs(c, requests), nil
+}
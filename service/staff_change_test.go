@@ -0,0 +1,46 @@
+package service
+
+import (
+	"hrms/model"
+	"testing"
+	"time"
+)
+
+func TestCheckContactsUnchanged(t *testing.T) {
+	db := newTestDB(t, &model.StaffContact{})
+	submitted := time.Now()
+	contacts := []model.StaffContact{
+		{StaffRecordBase: model.StaffRecordBase{RecordId: "c1", StaffId: "A"}, Name: "甲"},
+		{StaffRecordBase: model.StaffRecordBase{RecordId: "c2", StaffId: "B"}, Name: "乙"},
+		{StaffRecordBase: model.StaffRecordBase{RecordId: "c3", StaffId: "C"}, Name: "丙"},
+	}
+	if err := db.Create(&contacts).Error; err != nil {
+		t.Fatal(err)
+	}
+	// 模拟申请提交前已有的联系人
+	if err := db.Model(&model.StaffContact{}).Where("1 = 1").
+		UpdateColumn("updated_at", submitted.Add(-time.Hour)).Error; err != nil {
+		t.Fatal(err)
+	}
+	// 提交后 B 的联系人被修改，C 的联系人被删除
+	if err := db.Model(&model.StaffContact{}).Where("record_id = ?", "c2").Update("name", "丁").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Where("record_id = ?", "c3").Delete(&model.StaffContact{}).Error; err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		staffId string
+		wantErr error
+	}{
+		{"A", nil},
+		{"B", ErrStaffChangeContactsOutdated},
+		{"C", ErrStaffChangeContactsOutdated},
+		{"D", nil},
+	}
+	for _, tc := range cases {
+		if err := checkContactsUnchanged(db, tc.staffId, submitted); err != tc.wantErr {
+			t.Errorf("checkContactsUnchanged(%v) = %v, want %v", tc.staffId, err, tc.wantErr)
+		}
+	}
+}
@@ -0,0 +1,376 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"hrms/model"
+	"hrms/resource"
+	"log"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var ErrStaffContractNotExist = errors.New("劳动合同不存在")
+
+// 试用期最长6个月
+const maxProbationMonths = 6
+
+// 到期提醒通知类型
+const (
+	NoticeTypeContractExpiry = "合同到期提醒"
+	NoticeTypeProbationEnd   = "试用期到期提醒"
+)
+
+// 劳动合同仅管理员可维护，员工本人可查看自己的合同
+func staffContractDB(c *gin.Context) (*gorm.DB, error) {
+	db := resource.HrmsDB(c)
+	if db == nil {
+		return nil, resource.ErrUnauthorized
+	}
+	if !resource.IsAdmin(c) {
+		return nil, resource.ErrForbidden
+	}
+	return db, nil
+}
+
+// 校验合同类型及起止日期，无固定期限合同不能有结束日期
+func validateStaffContract(contract *model.StaffContract) error {
+	if _, ok := model.ContractTypeNames[contract.ContractType]; !ok {
+		return fmt.Errorf("不支持的合同类型: %v", contract.ContractType)
+	}
+	if contract.ContractType == model.ContractTypeOpenEnded {
+		if !contract.EndDate.IsZero() {
+			return errors.New("无固定期限合同不能设置结束日期")
+		}
+	} else {
+		if contract.EndDate.IsZero() {
+			return fmt.Errorf("%v合同需设置结束日期", model.ContractTypeNames[contract.ContractType])
+		}
+		if !contract.EndDate.After(contract.StartDate) {
+			return errors.New("合同结束日期需晚于开始日期")
+		}
+	}
+	if !contract.ProbationEndDate.IsZero() {
+		if !contract.ProbationEndDate.After(contract.StartDate) {
+			return errors.New("试用期结束日期需晚于合同开始日期")
+		}
+		if !contract.EndDate.IsZero() && contract.ProbationEndDate.After(contract.EndDate) {
+			return errors.New("试用期结束日期不能晚于合同结束日期")
+		}
+		if contract.ProbationEndDate.After(contract.StartDate.AddDate(0, maxProbationMonths, 0)) {
+			return fmt.Errorf("试用期不能超过%v个月", maxProbationMonths)
+		}
+	}
+	return nil
+}
+
+func parseContractDates(start, end, probationEnd string) (startDate, endDate, probationEndDate time.Time, err error) {
+	if startDate, err = ParseDepDate(start); err != nil {
+		return
+	}
+	if endDate, err = ParseDepDate(end); err != nil {
+		return
+	}
+	probationEndDate, err = ParseDepDate(probationEnd)
+	return
+}
+
+func getStaffContract(db *gorm.DB, contractId string) (*model.StaffContract, error) {
+	var contracts []model.StaffContract
+	if err := db.Where("contract_id = ?", contractId).Find(&contracts).Error; err != nil {
+		return nil, err
+	}
+	if len(contracts) == 0 {
+		return nil, ErrStaffContractNotExist
+	}
+	return &contracts[0], nil
+}
+
+// 新签劳动合同，同一员工同时只能有一份履行中的合同
+func CreateStaffContract(c *gin.Context, dto *model.StaffContractCreateDTO) (*model.StaffContract, error) {
+	db, err := staffContractDB(c)
+	if err != nil {
+		return nil, err
+	}
+	startDate, endDate, probationEndDate, err := parseContractDates(dto.StartDate, dto.EndDate, dto.ProbationEndDate)
+	if err != nil {
+		return nil, err
+	}
+	var staffs []model.Staff
+	db.Where("staff_id = ? and staff_id != 'root' and staff_id != 'admin'", dto.StaffId).Find(&staffs)
+	if len(staffs) == 0 {
+		return nil, fmt.Errorf("员工 %v 不存在", dto.StaffId)
+	}
+	contract := &model.StaffContract{
+		ContractId:       RandomID("contract"),
+		StaffId:          dto.StaffId,
+		StaffName:        staffs[0].StaffName,
+		ContractType:     dto.ContractType,
+		StartDate:        startDate,
+		EndDate:          endDate,
+		ProbationEndDate: probationEndDate,
+		Status:           model.ContractStatusActive,
+		Seq:              1,
+		Remark:           dto.Remark,
+		OperatorId:       resource.CurrentStaffId(c),
+	}
+	if err := validateStaffContract(contract); err != nil {
+		return nil, err
+	}
+	var count int64
+	db.Model(&model.StaffContract{}).Where("staff_id = ? and status = ?", dto.StaffId, model.ContractStatusActive).Count(&count)
+	if count > 0 {
+		return nil, fmt.Errorf("员工 %v 已有履行中的合同，请续签或先终止原合同", dto.StaffId)
+	}
+	if err := db.Create(contract).Error; err != nil {
+		log.Printf("CreateStaffContract err = %v", err)
+		return nil, err
+	}
+	return contract, nil
+}
+
+// 续签劳动合同，原合同标记为已续签，续签合同不再约定试用期
+func RenewStaffContract(c *gin.Context, dto *model.StaffContractRenewDTO) (*model.StaffContract, error) {
+	db, err := staffContractDB(c)
+	if err != nil {
+		return nil, err
+	}
+	startDate, endDate, _, err := parseContractDates(dto.StartDate, dto.EndDate, "")
+	if err != nil {
+		return nil, err
+	}
+	var contract *model.StaffContract
+	err = db.Transaction(func(tx *gorm.DB) error {
+		previous, err := getStaffContract(tx, dto.PreviousContractId)
+		if err != nil {
+			return err
+		}
+		if previous.Status != model.ContractStatusActive {
+			return fmt.Errorf("合同 %v 状态为%v，不能续签", previous.ContractId, model.ContractStatusNames[previous.Status])
+		}
+		if !startDate.After(previous.StartDate) {
+			return errors.New("续签合同的开始日期需晚于原合同开始日期")
+		}
+		contract = &model.StaffContract{
+			ContractId:         RandomID("contract"),
+			StaffId:            previous.StaffId,
+			StaffName:          previous.StaffName,
+			ContractType:       dto.ContractType,
+			StartDate:          startDate,
+			EndDate:            endDate,
+			Status:             model.ContractStatusActive,
+			PreviousContractId: previous.ContractId,
+			Seq:                previous.Seq + 1,
+			Remark:             dto.Remark,
+			OperatorId:         resource.CurrentStaffId(c),
+		}
+		if err := validateStaffContract(contract); err != nil {
+			return err
+		}
+		// 以原状态为条件更新，避免同一合同被并发续签两次
+		result := tx.Model(&model.StaffContract{}).
+			Where("contract_id = ? and status = ?", previous.ContractId, model.ContractStatusActive).
+			Update("status", model.ContractStatusRenewed)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("合同 %v 已被续签或终止", previous.ContractId)
+		}
+		return tx.Create(contract).Error
+	})
+	if err != nil {
+		log.Printf("RenewStaffContract err = %v", err)
+		return nil, err
+	}
+	return contract, nil
+}
+
+// 提前终止劳动合同
+func TerminateStaffContract(c *gin.Context, dto *model.StaffContractTerminateDTO) error {
+	db, err := staffContractDB(c)
+	if err != nil {
+		return err
+	}
+	terminateDate, err := ParseDepDate(dto.TerminateDate)
+	if err != nil {
+		return err
+	}
+	contract, err := getStaffContract(db, dto.ContractId)
+	if err != nil {
+		return err
+	}
+	if contract.Status != model.ContractStatusActive {
+		return fmt.Errorf("合同 %v 状态为%v，不能终止", contract.ContractId, model.ContractStatusNames[contract.Status])
+	}
+	if terminateDate.Before(contract.StartDate) {
+		return errors.New("终止日期不能早于合同开始日期")
+	}
+	updates := map[string]interface{}{
+		"status":         model.ContractStatusTerminated,
+		"terminate_date": terminateDate,
+	}
+	if dto.Remark != "" {
+		updates["remark"] = dto.Remark
+	}
+	result := db.Model(&model.StaffContract{}).
+		Where("contract_id = ? and status = ?", contract.ContractId, model.ContractStatusActive).
+		Updates(updates)
+	if result.Error != nil {
+		log.Printf("TerminateStaffContract err = %v", result.Error)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("合同 %v 已被续签或终止", contract.ContractId)
+	}
+	return nil
+}
+
+func staffContractVOs(contracts []model.StaffContract) []model.StaffContractVO {
+	vos := make([]model.StaffContractVO, 0, len(contracts))
+	for _, contract := range contracts {
+		vos = append(vos, model.StaffContractVO{
+			StaffContract:    contract,
+			ContractTypeName: model.ContractTypeNames[contract.ContractType],
+			StatusName:       model.ContractStatusNames[contract.Status],
+		})
+	}
+	return vos
+}
+
+// 查询员工的合同及续签记录，按签订次序倒序；管理员可传 all 查询全部员工
+func GetStaffContracts(c *gin.Context, staffId string) ([]model.StaffContractVO, error) {
+	db := resource.HrmsDB(c)
+	if db == nil {
+		return nil, resource.ErrUnauthorized
+	}
+	if !resource.IsAdmin(c) && staffId != resource.CurrentStaffId(c) {
+		return nil, resource.ErrForbidden
+	}
+	query := db.Model(&model.StaffContract{})
+	if staffId != "all" {
+		query = query.Where("staff_id = ?", staffId)
+	}
+	var contracts []model.StaffContract
+	if err := query.Order("staff_id, seq desc").Find(&contracts).Error; err != nil {
+		log.Printf("GetStaffContracts err = %v", err)
+		return nil, err
+	}
+	return staffContractVOs(contracts), nil
+}
+
+// 履行中且合同或试用期在 days 天内结束的合同
+func expiringContracts(db *gorm.DB, days int64, probationDays int64, now time.Time) ([]model.StaffContract, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	query := db.Where("status = ?", model.ContractStatusActive)
+	var cond *gorm.DB
+	if days > 0 {
+		cond = db.Where("end_date >= ? and end_date < ?", today, today.AddDate(0, 0, int(days)+1))
+	}
+	if probationDays > 0 {
+		probationCond := db.Where("probation_end_date >= ? and probation_end_date < ?", today, today.AddDate(0, 0, int(probationDays)+1))
+		if cond == nil {
+			cond = probationCond
+		} else {
+			cond = cond.Or(probationCond)
+		}
+	}
+	if cond == nil {
+		return nil, nil
+	}
+	var contracts []model.StaffContract
+	err := query.Where(cond).Order("end_date").Find(&contracts).Error
+	return contracts, err
+}
+
+// 查询即将到期的合同及试用期，days 为0时使用配置的提醒天数
+func GetExpiringStaffContracts(c *gin.Context, days int64) ([]model.StaffContractVO, error) {
+	db, err := staffContractDB(c)
+	if err != nil {
+		return nil, err
+	}
+	reminderDays, probationDays := contractReminderDays()
+	if days > 0 {
+		reminderDays, probationDays = days, days
+	}
+	contracts, err := expiringContracts(db, reminderDays, probationDays, time.Now())
+	if err != nil {
+		log.Printf("GetExpiringStaffContracts err = %v", err)
+		return nil, err
+	}
+	return staffContractVOs(contracts), nil
+}
+
+func contractReminderDays() (int64, int64) {
+	if resource.HrmsConf == nil {
+		return 0, 0
+	}
+	return resource.HrmsConf.Contract.ReminderDays, resource.HrmsConf.Contract.ProbationReminderDays
+}
+
+// 为即将到期的合同及试用期生成仅人事可见的通知，同一合同的同类提醒只生成一次，返回生成条数
+func CreateContractReminders(db *gorm.DB, days int64, probationDays int64, now time.Time) (int64, error) {
+	contracts, err := expiringContracts(db, days, probationDays, now)
+	if err != nil {
+		return 0, err
+	}
+	var created int64
+	for _, contract := range contracts {
+		var notices []model.Notification
+		if days > 0 && !contract.EndDate.IsZero() && withinDays(contract.EndDate, days, now) {
+			notices = append(notices, model.Notification{
+				NoticeTitle: fmt.Sprintf("%v（%v）劳动合同即将到期", contract.StaffName, contract.StaffId),
+				NoticeContent: fmt.Sprintf("员工 %v（%v）的%v合同（%v）将于 %v 到期，请及时办理续签或终止手续。",
+					contract.StaffName, contract.StaffId, model.ContractTypeNames[contract.ContractType],
+					contract.ContractId, contract.EndDate.Format("2006-01-02")),
+				Type:      NoticeTypeContractExpiry,
+				SourceKey: noticeSourceKey("contract_expiry:" + contract.ContractId),
+			})
+		}
+		// 试用期延长后按新的截止日期再次提醒
+		if probationDays > 0 && !contract.ProbationEndDate.IsZero() && withinDays(contract.ProbationEndDate, probationDays, now) {
+			notices = append(notices, model.Notification{
+				NoticeTitle: fmt.Sprintf("%v（%v）试用期即将结束", contract.StaffName, contract.StaffId),
+				NoticeContent: fmt.Sprintf("员工 %v（%v）的试用期将于 %v 结束，请及时安排转正评估。",
+					contract.StaffName, contract.StaffId, contract.ProbationEndDate.Format("2006-01-02")),
+				Type:      NoticeTypeProbationEnd,
+				SourceKey: noticeSourceKey("probation_end:" + contract.ContractId + ":" + contract.ProbationEndDate.Format("20060102")),
+			})
+		}
+		for i := range notices {
+			notices[i].Date = now
+			notices[i].Audience = model.NoticeAudienceAdmin
+			ok, err := createSourceNotice(db, &notices[i])
+			if err != nil {
+				return created, err
+			}
+			if ok {
+				created++
+			}
+		}
+	}
+	return created, nil
+}
+
+// date 是否在今天起 days 天内（含当天）
+func withinDays(date time.Time, days int64, now time.Time) bool {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	return !date.Before(today) && date.Before(today.AddDate(0, 0, int(days)+1))
+}
+
+// 启动合同到期提醒，启动时及此后每天检查一次各分公司数据库
+func StartContractReminder(days int64, probationDays int64) {
+	if days <= 0 && probationDays <= 0 {
+		return
+	}
+	runDaily("ContractReminder", func(dbName string, db *gorm.DB, now time.Time) error {
+		count, err := CreateContractReminders(db, days, probationDays, now)
+		if err != nil {
+			return err
+		}
+		log.Printf("[ContractReminder] %v 生成到期提醒%v条", dbName, count)
+		return nil
+	})
+}
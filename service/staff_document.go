@@ -0,0 +1,197 @@
+package service
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hrms/model"
+	"hrms/resource"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+var ErrStaffDocumentNotExist = errors.New("档案文件不存在")
+
+// 未配置时单个文件的大小上限，单位MB
+const defaultDocumentMaxSizeMB = 20
+
+// 未配置时允许上传的文件类型
+var defaultDocumentMimeTypes = []string{"application/pdf", "image/jpeg", "image/png"}
+
+var documentStorage DocumentStorage
+
+// 按配置初始化档案文件存储，需在处理请求前调用
+func InitDocumentStorage(conf resource.Document) error {
+	storage, err := NewDocumentStorage(conf.Storage)
+	if err != nil {
+		return err
+	}
+	documentStorage = storage
+	return nil
+}
+
+// 单个文件的大小上限，单位字节
+func DocumentMaxSize() int64 {
+	if resource.HrmsConf != nil && resource.HrmsConf.Document.MaxSizeMB > 0 {
+		return resource.HrmsConf.Document.MaxSizeMB << 20
+	}
+	return defaultDocumentMaxSizeMB << 20
+}
+
+func documentMimeAllowed(mimeType string) bool {
+	allowed := defaultDocumentMimeTypes
+	if resource.HrmsConf != nil && len(resource.HrmsConf.Document.AllowedMimeTypes) > 0 {
+		allowed = resource.HrmsConf.Document.AllowedMimeTypes
+	}
+	for _, t := range allowed {
+		if strings.EqualFold(t, mimeType) {
+			return true
+		}
+	}
+	return false
+}
+
+// 上传员工档案文件，文件类型按内容识别，不信任客户端提供的类型
+func UploadStaffDocument(c *gin.Context, staffId, docType, remark string, file *multipart.FileHeader) (*model.StaffDocument, error) {
+	policy, err := NewMaskPolicy(c)
+	if err != nil {
+		return nil, err
+	}
+	if !policy.CanUploadDocument(staffId) {
+		return nil, resource.ErrForbidden
+	}
+	if _, ok := model.StaffDocumentTypeNames[docType]; !ok {
+		return nil, fmt.Errorf("不支持的档案类型: %v", docType)
+	}
+	var count int64
+	policy.db.Model(&model.Staff{}).Where("staff_id = ? and staff_id != 'root' and staff_id != 'admin'", staffId).Count(&count)
+	if count == 0 {
+		return nil, fmt.Errorf("员工 %v 不存在", staffId)
+	}
+	if file.Size == 0 {
+		return nil, errors.New("文件内容为空")
+	}
+	if file.Size > DocumentMaxSize() {
+		return nil, fmt.Errorf("文件大小不能超过%vMB", DocumentMaxSize()>>20)
+	}
+	src, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+	head := make([]byte, 512)
+	n, err := io.ReadFull(src, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	head = head[:n]
+	mimeType := strings.TrimSpace(strings.Split(http.DetectContentType(head), ";")[0])
+	if !documentMimeAllowed(mimeType) {
+		return nil, fmt.Errorf("不支持的文件格式: %v", mimeType)
+	}
+	doc := &model.StaffDocument{
+		DocumentId: RandomID("document"),
+		StaffId:    staffId,
+		DocType:    docType,
+		FileName:   filepath.Base(strings.ReplaceAll(file.Filename, "\\", "/")),
+		MimeType:   mimeType,
+		Size:       file.Size,
+		UploaderId: resource.CurrentStaffId(c),
+		Remark:     remark,
+	}
+	// 按分公司及员工分目录存放
+	doc.StorageKey = fmt.Sprintf("%v/%v/%v", resource.CurrentBranchId(c), staffId, doc.DocumentId)
+	hash := sha256.New()
+	body := io.TeeReader(io.MultiReader(bytes.NewReader(head), src), hash)
+	if err := documentStorage.Put(c.Request.Context(), doc.StorageKey, body, file.Size, mimeType); err != nil {
+		log.Printf("UploadStaffDocument err = %v", err)
+		return nil, err
+	}
+	doc.Sha256 = hex.EncodeToString(hash.Sum(nil))
+	if err := policy.db.Create(doc).Error; err != nil {
+		log.Printf("UploadStaffDocument err = %v", err)
+		// 记录写入失败时清理已上传的文件
+		if err := documentStorage.Delete(c.Request.Context(), doc.StorageKey); err != nil {
+			log.Printf("UploadStaffDocument delete err = %v", err)
+		}
+		return nil, err
+	}
+	return doc, nil
+}
+
+// 查询员工的档案文件，只返回当前用户可查看的类型
+func GetStaffDocuments(c *gin.Context, staffId string) ([]model.StaffDocumentVO, error) {
+	policy, err := NewMaskPolicy(c)
+	if err != nil {
+		return nil, err
+	}
+	var docs []model.StaffDocument
+	if err := policy.db.Where("staff_id = ?", staffId).Order("id desc").Find(&docs).Error; err != nil {
+		log.Printf("GetStaffDocuments err = %v", err)
+		return nil, err
+	}
+	vos := make([]model.StaffDocumentVO, 0, len(docs))
+	for _, doc := range docs {
+		if policy.CanAccessDocument(doc.StaffId, doc.DocType) {
+			vos = append(vos, model.StaffDocumentVO{
+				StaffDocument: doc,
+				DocTypeName:   model.StaffDocumentTypeNames[doc.DocType],
+			})
+		}
+	}
+	return vos, nil
+}
+
+// 打开档案文件用于下载，调用方负责关闭
+func OpenStaffDocument(c *gin.Context, documentId string) (*model.StaffDocument, io.ReadCloser, error) {
+	policy, err := NewMaskPolicy(c)
+	if err != nil {
+		return nil, nil, err
+	}
+	var docs []model.StaffDocument
+	if err := policy.db.Where("document_id = ?", documentId).Find(&docs).Error; err != nil {
+		return nil, nil, err
+	}
+	if len(docs) == 0 {
+		return nil, nil, ErrStaffDocumentNotExist
+	}
+	doc := &docs[0]
+	if !policy.CanAccessDocument(doc.StaffId, doc.DocType) {
+		return nil, nil, resource.ErrForbidden
+	}
+	r, err := documentStorage.Get(c.Request.Context(), doc.StorageKey)
+	if err != nil {
+		log.Printf("OpenStaffDocument err = %v", err)
+		return nil, nil, err
+	}
+	RecordAccess(c, model.AccessResourceDocument, doc.DocType, doc.StaffId)
+	return doc, r, nil
+}
+
+// 删除档案文件，仅做软删除，文件内容保留在存储中
+func DelStaffDocument(c *gin.Context, documentId string) error {
+	policy, err := NewMaskPolicy(c)
+	if err != nil {
+		return err
+	}
+	if !policy.isAdmin() {
+		return resource.ErrForbidden
+	}
+	result := policy.db.Where("document_id = ?", documentId).Delete(&model.StaffDocument{})
+	if result.Error != nil {
+		log.Printf("DelStaffDocument err = %v", result.Error)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrStaffDocumentNotExist
+	}
+	return nil
+}
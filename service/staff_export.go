@@ -0,0 +1,384 @@
+package service
+
+import (
+	"encoding/csv"
+	"fmt"
+	"hrms/model"
+	"hrms/resource"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tealeg/xlsx"
+	"gorm.io/gorm"
+)
+
+// 每批导出的员工数
+const staffExportBatch = 500
+
+// 导出列，表头与员工导入文件保持一致；身份证号、手机号默认脱敏，
+// 需以 reveal=true 导出明文后才能再次导入
+type staffExportColumn struct {
+	header string
+	value  func(vo *model.StaffVO) string
+	// xlsx 中写为数字
+	number bool
+	// 对应的敏感字段，明文导出时记录查看
+	sensitive string
+}
+
+func exportDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("2006-01-02")
+}
+
+var staffExportColumns = []staffExportColumn{
+	{header: "工号", value: func(vo *model.StaffVO) string { return vo.StaffId }},
+	{header: "员工姓名", value: func(vo *model.StaffVO) string { return vo.StaffName }},
+	{header: "指定上级", value: func(vo *model.StaffVO) string { return vo.LeaderName }},
+	{header: "上级工号", value: func(vo *model.StaffVO) string { return vo.LeaderStaffId }},
+	{header: "员工性别", value: func(vo *model.StaffVO) string { return SexInt2Str(vo.Sex) }},
+	{header: "身份证号", value: func(vo *model.StaffVO) string { return vo.IdentityNum }, sensitive: model.SensitiveIdentityNum},
+	{header: "出生日期", value: func(vo *model.StaffVO) string { return exportDate(vo.Birthday) }},
+	{header: "民族", value: func(vo *model.StaffVO) string { return vo.Nation }},
+	{header: "毕业院校", value: func(vo *model.StaffVO) string { return vo.School }},
+	{header: "毕业专业", value: func(vo *model.StaffVO) string { return vo.Major }},
+	{header: "最高学历", value: func(vo *model.StaffVO) string { return vo.EduLevel }},
+	{header: "基本薪资", value: func(vo *model.StaffVO) string { return strconv.FormatInt(vo.BaseSalary, 10) }, number: true},
+	{header: "银行卡号", value: func(vo *model.StaffVO) string { return vo.CardNum }, sensitive: model.SensitiveCardNum},
+	{header: "职位", value: func(vo *model.StaffVO) string { return vo.RankName }},
+	{header: "部门", value: func(vo *model.StaffVO) string { return vo.DepName }},
+	{header: "电子邮箱", value: func(vo *model.StaffVO) string { return vo.Email }},
+	{header: "手机号", value: func(vo *model.StaffVO) string { return vo.PhoneStr }, number: true, sensitive: model.SensitivePhone},
+	{header: "入职日期", value: func(vo *model.StaffVO) string { return exportDate(vo.EntryDate) }},
+	{header: "现居住地址", value: func(vo *model.StaffVO) string { return vo.Address }, sensitive: model.SensitiveAddress},
+	{header: "在职状态", value: func(vo *model.StaffVO) string { return vo.StatusName }},
+	{header: "用户类型", value: func(vo *model.StaffVO) string { return vo.UserTypeName }},
+}
+
+// 按逗号分隔的表头名称选择导出列，为空时导出全部列
+func selectStaffExportColumns(columns string) ([]staffExportColumn, error) {
+	if columns == "" {
+		return staffExportColumns, nil
+	}
+	var selected []staffExportColumn
+	for _, header := range strings.Split(columns, ",") {
+		header = strings.TrimSpace(header)
+		if header == "" {
+			continue
+		}
+		found := false
+		for _, column := range staffExportColumns {
+			if column.header == header {
+				selected = append(selected, column)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("不支持导出列: %v", header)
+		}
+	}
+	return selected, nil
+}
+
+// 用户类型名称
+func UserTypeName(userType string) string {
+	switch userType {
+	case "supersys":
+		return "超级管理员"
+	case "sys":
+		return "系统管理员"
+	case "normal":
+		return "普通员工"
+	default:
+		return "未知"
+	}
+}
+
+// 员工导出器，部门、职级及用户类型名称一次加载
+type StaffExporter struct {
+	columns   []staffExportColumn
+	depNames  map[string]string
+	rankNames map[string]string
+	userTypes map[string]string
+	policy    *MaskPolicy
+	// 是否导出敏感字段明文
+	reveal bool
+	db     *gorm.DB
+	// 一并导出的子记录类型，每类一个工作表
+	records []string
+}
+
+// columns 为逗号分隔的表头名称，为空时导出全部列；
+// 敏感字段默认按查看人权限脱敏，reveal 为 true 时导出明文，需具备查看明文权限，
+// 脱敏后的身份证号、手机号无法通过导入校验
+func NewStaffExporter(c *gin.Context, columns string, reveal bool) (*StaffExporter, error) {
+	db := resource.HrmsDB(c)
+	if db == nil {
+		return nil, resource.ErrUnauthorized
+	}
+	policy, err := NewMaskPolicy(c)
+	if err != nil {
+		return nil, err
+	}
+	if reveal && !policy.CanReveal() {
+		return nil, resource.ErrForbidden
+	}
+	e := &StaffExporter{
+		depNames:  make(map[string]string),
+		rankNames: make(map[string]string),
+		userTypes: make(map[string]string),
+		policy:    policy,
+		reveal:    reveal,
+		db:        db,
+	}
+	if e.columns, err = selectStaffExportColumns(columns); err != nil {
+		return nil, err
+	}
+	var deps []*model.Department
+	if err := db.Find(&deps).Error; err != nil {
+		return nil, err
+	}
+	for _, dep := range deps {
+		e.depNames[dep.DepId] = dep.DepName
+	}
+	var ranks []*model.Rank
+	if err := db.Find(&ranks).Error; err != nil {
+		return nil, err
+	}
+	for _, rank := range ranks {
+		e.rankNames[rank.RankId] = rank.RankName
+	}
+	var authorities []*model.Authority
+	if err := db.Find(&authorities).Error; err != nil {
+		return nil, err
+	}
+	for _, authority := range authorities {
+		e.userTypes[authority.StaffId] = UserTypeName(authority.UserType)
+	}
+	return e, nil
+}
+
+// 设置一并导出的子记录类型，逗号分隔，all 表示全部；子记录仅管理员可导出
+func (e *StaffExporter) SetRecords(records string) error {
+	if records == "" {
+		return nil
+	}
+	if !e.policy.isAdmin() {
+		return resource.ErrForbidden
+	}
+	selected := make(map[string]bool)
+	for _, kind := range strings.Split(records, ",") {
+		kind = strings.TrimSpace(kind)
+		if kind == "all" {
+			e.records = staffRecordExportKinds
+			return nil
+		}
+		if _, err := getStaffRecordKind(kind); err != nil {
+			return err
+		}
+		selected[kind] = true
+	}
+	e.records = nil
+	for _, kind := range staffRecordExportKinds {
+		if selected[kind] {
+			e.records = append(e.records, kind)
+		}
+	}
+	return nil
+}
+
+// 是否导出子记录，子记录仅支持 xlsx 格式
+func (e *StaffExporter) HasRecords() bool {
+	return len(e.records) > 0
+}
+
+// 子记录中的敏感字段按导出员工信息的方式脱敏
+func (e *StaffExporter) maskRecord(staffId, field, value string) string {
+	if e.reveal || staffId == e.policy.staffId {
+		return value
+	}
+	return MaskSensitive(field, value)
+}
+
+// 明文导出时记录导出的敏感字段，记录失败时不允许导出
+func (e *StaffExporter) LogReveal(c *gin.Context) error {
+	if !e.reveal {
+		return nil
+	}
+	var fields []string
+	for _, column := range e.columns {
+		if column.sensitive != "" {
+			fields = append(fields, column.sensitive)
+		}
+	}
+	for _, kind := range e.records {
+		switch kind {
+		case model.StaffRecordContact:
+			fields = append(fields, kind+"."+model.SensitivePhone)
+		case model.StaffRecordFamily:
+			fields = append(fields, kind+"."+model.SensitiveIdentityNum, kind+"."+model.SensitivePhone)
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return e.policy.logReveal(c, model.RevealRecordStaffExport, c.Request.URL.RawQuery, fields)
+}
+
+func (e *StaffExporter) headers() []string {
+	headers := make([]string, 0, len(e.columns))
+	for _, column := range e.columns {
+		headers = append(headers, column.header)
+	}
+	return headers
+}
+
+func (e *StaffExporter) row(staff model.Staff) []string {
+	vo := &model.StaffVO{
+		Staff:        staff,
+		DepName:      e.depNames[staff.DepId],
+		RankName:     e.rankNames[staff.RankId],
+		UserTypeName: e.userTypes[staff.StaffId],
+		StatusName:   StaffStatusName(staff.Status),
+	}
+	if e.reveal {
+		if staff.Phone != 0 {
+			vo.PhoneStr = strconv.FormatInt(staff.Phone, 10)
+		}
+	} else {
+		e.policy.MaskStaff(vo)
+	}
+	values := make([]string, 0, len(e.columns))
+	for _, column := range e.columns {
+		values = append(values, column.value(vo))
+	}
+	return values
+}
+
+// 记录导出的访问日志，按各员工以明文导出的敏感字段记录
+func (e *StaffExporter) recordAccess(c *gin.Context, staffs []model.Staff) {
+	staffIds := make([]string, 0, len(staffs))
+	fieldsByStaff := make(map[string]string, len(staffs))
+	for _, staff := range staffs {
+		var fields []string
+		for _, column := range e.columns {
+			if column.sensitive != "" && (e.reveal || e.policy.Visible(staff.StaffId, column.sensitive)) {
+				fields = append(fields, column.sensitive)
+			}
+		}
+		staffIds = append(staffIds, staff.StaffId)
+		fieldsByStaff[staff.StaffId] = strings.Join(fields, ",")
+	}
+	recordAccess(c, model.AccessResourceStaffExport, staffIds, fieldsByStaff)
+}
+
+// 按批次查询满足条件的员工
+func (e *StaffExporter) eachBatch(c *gin.Context, q *model.StaffSearchQuery, fn func([]model.Staff) error) error {
+	for start := 0; ; start += staffExportBatch {
+		staffs, _, err := SearchStaff(c, q, start, staffExportBatch)
+		if err != nil {
+			return err
+		}
+		if err := fn(staffs); err != nil {
+			return err
+		}
+		e.recordAccess(c, staffs)
+		if len(staffs) < staffExportBatch {
+			return nil
+		}
+	}
+}
+
+// 以 CSV 格式分批写出，带 UTF-8 BOM 以便 Excel 正确识别中文
+func (e *StaffExporter) WriteCSV(c *gin.Context, q *model.StaffSearchQuery, w io.Writer) error {
+	if _, err := w.Write([]byte("\xEF\xBB\xBF")); err != nil {
+		return err
+	}
+	writer := csv.NewWriter(w)
+	if err := writer.Write(e.headers()); err != nil {
+		return err
+	}
+	err := e.eachBatch(c, q, func(staffs []model.Staff) error {
+		for _, staff := range staffs {
+			if err := writer.Write(e.row(staff)); err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	})
+	if err != nil {
+		return err
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// 生成 xlsx 文件
+func (e *StaffExporter) BuildXlsx(c *gin.Context, q *model.StaffSearchQuery) (*xlsx.File, error) {
+	file := xlsx.NewFile()
+	sheet, err := file.AddSheet("员工信息")
+	if err != nil {
+		return nil, err
+	}
+	header := sheet.AddRow()
+	for _, h := range e.headers() {
+		header.AddCell().SetString(h)
+	}
+	recordSheets := make([]*xlsx.Sheet, 0, len(e.records))
+	for _, kind := range e.records {
+		recordSheet, err := file.AddSheet(model.StaffRecordNames[kind])
+		if err != nil {
+			return nil, err
+		}
+		recordHeader := recordSheet.AddRow()
+		for _, h := range staffRecordHeaders[kind] {
+			recordHeader.AddCell().SetString(h)
+		}
+		recordSheets = append(recordSheets, recordSheet)
+	}
+	err = e.eachBatch(c, q, func(staffs []model.Staff) error {
+		if len(e.records) > 0 && len(staffs) > 0 {
+			staffIds := make([]string, 0, len(staffs))
+			for _, staff := range staffs {
+				staffIds = append(staffIds, staff.StaffId)
+			}
+			for i, kind := range e.records {
+				rows, err := staffRecordRows(e.db, kind, staffIds, e.maskRecord)
+				if err != nil {
+					return err
+				}
+				for _, values := range rows {
+					row := recordSheets[i].AddRow()
+					for _, value := range values {
+						// 均按文本写入，避免电话、身份证号被转为数字
+						row.AddCell().SetString(value)
+					}
+				}
+			}
+		}
+		for _, staff := range staffs {
+			row := sheet.AddRow()
+			for i, value := range e.row(staff) {
+				cell := row.AddCell()
+				if n, err := strconv.ParseInt(value, 10, 64); err == nil && e.columns[i].number {
+					cell.SetInt64(n)
+				} else {
+					// 身份证号、银行卡号等按文本写入，避免被转为科学计数法
+					cell.SetString(value)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return file, nil
+}
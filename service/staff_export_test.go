@@ -0,0 +1,76 @@
+package service
+
+import (
+	"hrms/model"
+	"strings"
+	"testing"
+
+	"github.com/tealeg/xlsx"
+)
+
+func TestSelectStaffExportColumns(t *testing.T) {
+	cases := []struct {
+		columns string
+		want    []string
+		wantErr bool
+	}{
+		{"", nil, false},
+		{"员工姓名,部门", []string{"员工姓名", "部门"}, false},
+		{" 手机号 , 工号,", []string{"手机号", "工号"}, false},
+		{"员工姓名,密码", nil, true},
+	}
+	for _, tc := range cases {
+		columns, err := selectStaffExportColumns(tc.columns)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("selectStaffExportColumns(%q) err = %v", tc.columns, err)
+			continue
+		}
+		if tc.wantErr {
+			continue
+		}
+		var got []string
+		for _, column := range columns {
+			got = append(got, column.header)
+		}
+		if tc.want == nil {
+			if len(got) != len(staffExportColumns) {
+				t.Errorf("未指定导出列时应导出全部列, got %v", got)
+			}
+			continue
+		}
+		if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+			t.Errorf("selectStaffExportColumns(%q) = %v, want %v", tc.columns, got, tc.want)
+		}
+	}
+}
+
+// 导出文件须包含导入的全部必填列
+func TestStaffExportHeadersCoverImport(t *testing.T) {
+	headers := make(map[string]bool)
+	for _, column := range staffExportColumns {
+		headers[column.header] = true
+	}
+	for _, required := range staffImportRequired {
+		if !headers[required] {
+			t.Errorf("导出列缺少导入必填列 %v", required)
+		}
+	}
+}
+
+// 脱敏导出的身份证号、手机号无法再次导入，需以明文导出
+func TestMaskedExportNotImportable(t *testing.T) {
+	ctx := newStaffImportContext()
+	values := validImportRow(MaskSensitive(model.SensitiveIdentityNum, testIdentityNum("11010119900307001")))
+	values["手机号"] = MaskSensitive(model.SensitivePhone, "13912345678")
+	sheet, _ := xlsx.NewFile().AddSheet("员工")
+	row := &staffImportRow{sheet: "员工", row: 2}
+	xrow := addImportRow(sheet, values)
+	ctx.parseRow(row, staffImportHeaders, xrow.Cells)
+	var got []string
+	for _, e := range row.errors {
+		got = append(got, e.Column)
+	}
+	if strings.Join(got, ",") != "身份证号,手机号" {
+		t.Errorf("出错的列 = %v, errors = %+v", got, row.errors)
+	}
+}
@@ -0,0 +1,241 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"hrms/model"
+	"hrms/resource"
+	"log"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var ErrStaffNotExist = errors.New("该日期员工不存在")
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// 员工信息字段的列名，按结构体顺序，不含 gorm.Model 中的字段
+func staffColumns() []string {
+	var columns []string
+	t := reflect.TypeOf(model.Staff{})
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		// 盲索引等不对外输出的字段不记录变更
+		if field.Anonymous || field.Tag.Get("json") == "-" {
+			continue
+		}
+		for _, tag := range strings.Split(field.Tag.Get("gorm"), ";") {
+			if strings.HasPrefix(tag, "column:") {
+				columns = append(columns, strings.TrimPrefix(tag, "column:"))
+			}
+		}
+	}
+	return columns
+}
+
+// 按列名取员工字段
+func staffField(staff *model.Staff, column string) reflect.Value {
+	v := reflect.ValueOf(staff).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		for _, tag := range strings.Split(t.Field(i).Tag.Get("gorm"), ";") {
+			if tag == "column:"+column {
+				return v.Field(i)
+			}
+		}
+	}
+	return reflect.Value{}
+}
+
+// 字段值转为字符串，日期类字段只保留日期
+func formatStaffField(v reflect.Value) string {
+	if v.Type() == timeType {
+		t := v.Interface().(time.Time)
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format("2006-01-02")
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// 将字符串写回员工字段
+func setStaffField(staff *model.Staff, column, value string) error {
+	v := staffField(staff, column)
+	if !v.IsValid() {
+		return fmt.Errorf("未知字段: %v", column)
+	}
+	switch {
+	case v.Type() == timeType:
+		var t time.Time
+		if value != "" {
+			parsed, err := time.ParseInLocation("2006-01-02", value, time.Local)
+			if err != nil {
+				return err
+			}
+			t = parsed
+		}
+		v.Set(reflect.ValueOf(t))
+	case v.Kind() == reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	default:
+		v.SetString(value)
+	}
+	return nil
+}
+
+type fieldChange struct {
+	Field    string
+	OldValue string
+	NewValue string
+}
+
+// 比较修改前后的员工信息，old 为空表示新建
+func diffStaff(old, cur *model.Staff) []fieldChange {
+	if old == nil {
+		old = &model.Staff{}
+	}
+	var changes []fieldChange
+	for _, column := range staffColumns() {
+		before := formatStaffField(staffField(old, column))
+		after := formatStaffField(staffField(cur, column))
+		if before != after {
+			changes = append(changes, fieldChange{column, before, after})
+		}
+	}
+	return changes
+}
+
+// 在事务中记录员工信息变更，old 为空表示新建，cur 为空表示删除
+func RecordStaffHistory(tx *gorm.DB, c *gin.Context, action string, old, cur *model.Staff) error {
+	var staffId string
+	var changes []fieldChange
+	if cur == nil {
+		staffId = old.StaffId
+		changes = []fieldChange{{"deleted_at", "", time.Now().Format("2006-01-02 15:04:05")}}
+	} else {
+		staffId = cur.StaffId
+		changes = diffStaff(old, cur)
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	changeId := RandomID("change")
+	operatorId := resource.CurrentStaffId(c)
+	histories := make([]model.StaffHistory, 0, len(changes))
+	for _, change := range changes {
+		histories = append(histories, model.StaffHistory{
+			ChangeId:   changeId,
+			StaffId:    staffId,
+			Action:     action,
+			Field:      change.Field,
+			OldValue:   change.OldValue,
+			NewValue:   change.NewValue,
+			OperatorId: operatorId,
+		})
+	}
+	return tx.Create(&histories).Error
+}
+
+// 修改员工信息并记录变更，只更新非零值字段
+func UpdateStaff(c *gin.Context, staff *model.Staff) error {
+	db := resource.HrmsDB(c)
+	if db == nil {
+		log.Printf("UpdateStaff: 数据库连接为空，鉴权失败")
+		return resource.ErrUnauthorized
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		var old, cur model.Staff
+		if err := tx.Where("staff_id = ?", staff.StaffId).First(&old).Error; err != nil {
+			return err
+		}
+		// 修改上级时不允许形成汇报环路，在同一事务中检查，避免并发修改绕过检查
+		if err := CheckLeaderChange(tx, staff.StaffId, staff.LeaderStaffId); err != nil {
+			return err
+		}
+		// 修改员工信息时调整职级同样记录职级变动
+		if staff.RankId != "" && staff.RankId != old.RankId {
+			if err := recordRankChange(tx, c, &old, staff.RankId, time.Now(), "修改员工信息"); err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&model.Staff{}).Where("id = ?", old.ID).Updates(staff).Error; err != nil {
+			log.Printf("UpdateStaff err = %v", err)
+			return err
+		}
+		if err := tx.Where("id = ?", old.ID).First(&cur).Error; err != nil {
+			return err
+		}
+		return RecordStaffHistory(tx, c, model.StaffActionEdit, &old, &cur)
+	})
+}
+
+// 查询员工信息变更记录，按时间倒序
+func GetStaffHistoryByStaffId(c *gin.Context, staffId string, start int, limit int) ([]*model.StaffHistory, int64, error) {
+	var histories []*model.StaffHistory
+	var total int64
+	db := resource.HrmsDB(c)
+	if db == nil {
+		log.Printf("GetStaffHistoryByStaffId: 数据库连接为空，鉴权失败")
+		return nil, 0, resource.ErrUnauthorized
+	}
+	query := db.Model(&model.StaffHistory{}).Where("staff_id = ?", staffId).Session(&gorm.Session{})
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if start != -1 && limit != -1 {
+		query = query.Offset(start).Limit(limit)
+	}
+	if err := query.Order("id desc").Find(&histories).Error; err != nil {
+		log.Printf("GetStaffHistoryByStaffId err = %v", err)
+		return nil, 0, err
+	}
+	return histories, total, nil
+}
+
+// 按变更记录回溯员工在指定日期当天结束时的信息
+func GetStaffAsOf(c *gin.Context, staffId string, date time.Time) (*model.Staff, error) {
+	db := resource.HrmsDB(c)
+	if db == nil {
+		log.Printf("GetStaffAsOf: 数据库连接为空，鉴权失败")
+		return nil, resource.ErrUnauthorized
+	}
+	end := date.AddDate(0, 0, 1)
+	var staff model.Staff
+	if err := db.Unscoped().Where("staff_id = ?", staffId).Order("id desc").First(&staff).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrStaffNotExist
+		}
+		return nil, err
+	}
+	if !staff.CreatedAt.Before(end) || (staff.DeletedAt.Valid && staff.DeletedAt.Time.Before(end)) {
+		return nil, ErrStaffNotExist
+	}
+	var histories []*model.StaffHistory
+	if err := db.Where("staff_id = ?", staffId).Order("id desc").Find(&histories).Error; err != nil {
+		return nil, err
+	}
+	// 从最新的变更开始，撤销指定日期之后的变更
+	for _, history := range histories {
+		if history.CreatedAt.Before(end) {
+			break
+		}
+		if history.Action == model.StaffActionDelete {
+			continue
+		}
+		if err := setStaffField(&staff, history.Field, history.OldValue); err != nil {
+			log.Printf("GetStaffAsOf 回溯字段 %v 失败: %v", history.Field, err)
+		}
+	}
+	staff.DeletedAt = gorm.DeletedAt{}
+	return &staff, nil
+}
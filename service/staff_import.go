@@ -0,0 +1,449 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"hrms/model"
+	"hrms/resource"
+	"io/ioutil"
+	"log"
+	"mime/multipart"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tealeg/xlsx"
+	"gorm.io/gorm"
+)
+
+// 导入文件的必填列，出生日期及性别未填写时由身份证号推导
+var staffImportRequired = []string{"员工姓名", "身份证号", "基本薪资", "职位", "部门", "手机号", "入职日期"}
+
+// 标注文件中追加的校验结果列
+const staffImportResultColumn = "校验结果"
+
+// 导入文件中的一行员工数据
+type staffImportRow struct {
+	sheet  string
+	row    int
+	dto    model.StaffCreateDTO
+	errors []model.StaffImportError
+}
+
+func (r *staffImportRow) addError(column, value, format string, args ...interface{}) {
+	r.errors = append(r.errors, model.StaffImportError{
+		Sheet:   r.sheet,
+		Row:     r.row,
+		Column:  column,
+		Value:   value,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// 导入校验需要的基础数据，一次加载避免逐行查询
+type staffImportContext struct {
+	deps        map[string]*model.Department
+	rankIds     map[string]string
+	leaderNames map[string]string
+	identityNum map[string]bool
+	// 文件中已出现的身份证号及所在行
+	fileIdentity map[string]string
+}
+
+func loadStaffImportContext(db *gorm.DB) (*staffImportContext, error) {
+	ctx := &staffImportContext{
+		deps:         make(map[string]*model.Department),
+		rankIds:      make(map[string]string),
+		leaderNames:  make(map[string]string),
+		identityNum:  make(map[string]bool),
+		fileIdentity: make(map[string]string),
+	}
+	var deps []*model.Department
+	if err := db.Find(&deps).Error; err != nil {
+		return nil, err
+	}
+	for _, dep := range deps {
+		ctx.deps[dep.DepName] = dep
+	}
+	var ranks []*model.Rank
+	if err := db.Find(&ranks).Error; err != nil {
+		return nil, err
+	}
+	for _, rank := range ranks {
+		ctx.rankIds[rank.RankName] = rank.RankId
+	}
+	var staffs []*model.Staff
+	if err := db.Select("staff_id", "staff_name", "identity_num").Find(&staffs).Error; err != nil {
+		return nil, err
+	}
+	for _, staff := range staffs {
+		if staff.StaffId != "root" && staff.StaffId != "admin" {
+			ctx.leaderNames[staff.StaffId] = staff.StaffName
+		}
+		ctx.identityNum[staff.IdentityNum] = true
+	}
+	return ctx, nil
+}
+
+// 读取上传的 xlsx 文件
+func OpenStaffImportFile(file *multipart.FileHeader) (*xlsx.File, error) {
+	if strings.ToLower(filepath.Ext(file.Filename)) != ".xlsx" {
+		return nil, errors.New("只可上传xlsx格式文件")
+	}
+	fileOpen, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer fileOpen.Close()
+	bytes, err := ioutil.ReadAll(fileOpen)
+	if err != nil {
+		return nil, err
+	}
+	return xlsx.OpenBinary(bytes)
+}
+
+// 日期列支持文本 YYYY-MM-DD 及 Excel 日期格式
+func importCellDate(cell *xlsx.Cell) (string, bool) {
+	value := strings.TrimSpace(cell.String())
+	if _, err := time.Parse("2006-01-02", value); err == nil {
+		return value, true
+	}
+	if cell.Type() == xlsx.CellTypeNumeric || cell.Type() == xlsx.CellTypeDate {
+		if t, err := cell.GetTime(false); err == nil {
+			return t.Format("2006-01-02"), true
+		}
+	}
+	return value, false
+}
+
+// 解析并校验一行数据
+func (ctx *staffImportContext) parseRow(row *staffImportRow, headers []string, cells []*xlsx.Cell) {
+	values := make(map[string]string)
+	for i, header := range headers {
+		if i < len(cells) {
+			values[header] = strings.TrimSpace(cells[i].String())
+		}
+	}
+	for _, column := range staffImportRequired {
+		if values[column] == "" {
+			row.addError(column, "", "%v不能为空", column)
+		}
+	}
+	dto := &row.dto
+	for i, header := range headers {
+		if i >= len(cells) || values[header] == "" {
+			continue
+		}
+		cell, value := cells[i], values[header]
+		switch header {
+		case "员工姓名":
+			dto.StaffName = value
+		case "指定上级":
+			dto.LeaderName = value
+		case "上级工号":
+			leaderName, ok := ctx.leaderNames[value]
+			if !ok {
+				// 新员工的工号在导入时生成，上级只能是已有员工
+				row.addError(header, value, "上级工号不存在，上级须为已有员工，不能引用本文件中新增的员工")
+			} else if dto.LeaderName != "" && dto.LeaderName != leaderName {
+				row.addError(header, value, "上级工号对应的员工为%v，与指定上级不一致", leaderName)
+			}
+			dto.LeaderStaffId = value
+		case "员工性别":
+			if SexStr2Int64(value) == 0 {
+				row.addError(header, value, "员工性别只能为男或女")
+				break
+			}
+			dto.SexStr = value
+		case "身份证号":
+			value = NormalizeIdentityNum(value)
+			if _, _, err := ParseIdentityNum(value); err != nil {
+				row.addError(header, value, "%v", err.Error())
+			} else if ctx.identityNum[value] {
+				row.addError(header, value, "该身份证号的员工已存在")
+			} else if first, ok := ctx.fileIdentity[value]; ok {
+				row.addError(header, value, "与%v的身份证号重复", first)
+			} else {
+				ctx.fileIdentity[value] = fmt.Sprintf("%v第%v行", row.sheet, row.row)
+			}
+			dto.IdentityNum = value
+		case "出生日期", "入职日期":
+			date, ok := importCellDate(cell)
+			if !ok {
+				row.addError(header, value, "日期格式错误，应为 YYYY-MM-DD")
+			}
+			if header == "出生日期" {
+				if ok {
+					dto.BirthdayStr = date
+				}
+			} else {
+				dto.EntryDateStr = date
+			}
+		case "民族":
+			dto.Nation = value
+		case "毕业院校":
+			dto.School = value
+		case "毕业专业":
+			dto.Major = value
+		case "最高学历":
+			dto.EduLevel = value
+		case "基本薪资":
+			salary, err := cell.Int64()
+			if err != nil || salary < 0 {
+				row.addError(header, value, "基本薪资应为非负整数")
+			}
+			dto.BaseSalary = salary
+		case "银行卡号":
+			dto.CardNum = value
+		case "现居住地址":
+			dto.Address = value
+		case "职位":
+			rankId, ok := ctx.rankIds[value]
+			if !ok {
+				row.addError(header, value, "职位不存在")
+			}
+			dto.RankId = rankId
+		case "部门":
+			dep, ok := ctx.deps[value]
+			if !ok {
+				row.addError(header, value, "部门不存在")
+				break
+			}
+			if !dep.IsActive(time.Now()) {
+				row.addError(header, value, "部门已失效")
+			}
+			dto.DepId = dep.DepId
+		case "电子邮箱":
+			if !strings.Contains(value, "@") {
+				row.addError(header, value, "电子邮箱格式错误")
+			}
+			dto.Email = value
+		case "手机号":
+			phone, err := cell.Int64()
+			if err != nil || len(value) != 11 {
+				row.addError(header, value, "手机号应为11位数字")
+			}
+			dto.Phone = phone
+		}
+	}
+	// 核对出生日期及性别与身份证号是否一致，未填写时补全
+	birthdayErr, sexErr, err := resolveIdentity(dto.IdentityNum, &dto.BirthdayStr, &dto.SexStr)
+	if err != nil {
+		return
+	}
+	if birthdayErr != nil {
+		row.addError("出生日期", values["出生日期"], "%v", birthdayErr.Error())
+	}
+	if sexErr != nil {
+		row.addError("员工性别", values["员工性别"], "%v", sexErr.Error())
+	}
+}
+
+// 解析并校验全部工作表，返回数据行及表头缺失等文件级错误
+func parseStaffImport(db *gorm.DB, xfile *xlsx.File) ([]*staffImportRow, []model.StaffImportError, error) {
+	ctx, err := loadStaffImportContext(db)
+	if err != nil {
+		return nil, nil, err
+	}
+	var rows []*staffImportRow
+	var fileErrors []model.StaffImportError
+	for _, sheet := range xfile.Sheets {
+		if len(sheet.Rows) == 0 {
+			continue
+		}
+		var headers []string
+		present := make(map[string]bool)
+		for _, cell := range sheet.Rows[0].Cells {
+			header := strings.TrimSpace(cell.String())
+			headers = append(headers, header)
+			present[header] = true
+		}
+		var missing []string
+		for _, column := range staffImportRequired {
+			if !present[column] {
+				missing = append(missing, column)
+			}
+		}
+		if len(missing) > 0 {
+			fileErrors = append(fileErrors, model.StaffImportError{
+				Sheet:   sheet.Name,
+				Row:     1,
+				Message: fmt.Sprintf("缺少必填列: %v", strings.Join(missing, "、")),
+			})
+			continue
+		}
+		for i, r := range sheet.Rows[1:] {
+			if isEmptyRow(r) {
+				continue
+			}
+			row := &staffImportRow{sheet: sheet.Name, row: i + 2}
+			ctx.parseRow(row, headers, r.Cells)
+			rows = append(rows, row)
+		}
+	}
+	return rows, fileErrors, nil
+}
+
+func isEmptyRow(r *xlsx.Row) bool {
+	for _, cell := range r.Cells {
+		if strings.TrimSpace(cell.String()) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func newStaffImportResult(rows []*staffImportRow, fileErrors []model.StaffImportError) *model.StaffImportResult {
+	result := &model.StaffImportResult{
+		Total:    len(rows),
+		Errors:   append([]model.StaffImportError{}, fileErrors...),
+		StaffIds: []string{},
+	}
+	for _, row := range rows {
+		if len(row.errors) > 0 {
+			result.Failed++
+			result.Errors = append(result.Errors, row.errors...)
+		} else {
+			result.Success++
+		}
+	}
+	return result
+}
+
+// 只校验不导入，返回逐行逐列的错误
+func ValidateStaffImport(c *gin.Context, xfile *xlsx.File) (*model.StaffImportResult, error) {
+	db := resource.HrmsDB(c)
+	if db == nil {
+		log.Printf("ValidateStaffImport: 数据库连接为空，鉴权失败")
+		return nil, resource.ErrUnauthorized
+	}
+	rows, fileErrors, err := parseStaffImport(db, xfile)
+	if err != nil {
+		log.Printf("ValidateStaffImport err = %v", err)
+		return nil, err
+	}
+	return newStaffImportResult(rows, fileErrors), nil
+}
+
+// 导入员工，atomic 为 true 时任一行有误则全部不导入，否则只导入校验通过的行
+func CommitStaffImport(c *gin.Context, xfile *xlsx.File, atomic bool) (*model.StaffImportResult, error) {
+	db := resource.HrmsDB(c)
+	if db == nil {
+		log.Printf("CommitStaffImport: 数据库连接为空，鉴权失败")
+		return nil, resource.ErrUnauthorized
+	}
+	rows, fileErrors, err := parseStaffImport(db, xfile)
+	if err != nil {
+		log.Printf("CommitStaffImport err = %v", err)
+		return nil, err
+	}
+	result := newStaffImportResult(rows, fileErrors)
+	if atomic {
+		if len(result.Errors) > 0 {
+			result.Success = 0
+			return result, nil
+		}
+		var staffIds []string
+		err := db.Transaction(func(tx *gorm.DB) error {
+			for _, row := range rows {
+				staff, err := CreateStaff(tx, c, row.dto)
+				if err != nil {
+					row.addError("", "", "导入失败: %v", err)
+					return err
+				}
+				staffIds = append(staffIds, staff.StaffId)
+			}
+			return nil
+		})
+		if err != nil {
+			log.Printf("CommitStaffImport err = %v", err)
+			result = newStaffImportResult(rows, fileErrors)
+			result.Success = 0
+			return result, nil
+		}
+		result.Committed = true
+		result.StaffIds = staffIds
+		return result, nil
+	}
+	// 逐行导入，每行单独一个事务
+	result.Success = 0
+	for _, row := range rows {
+		if len(row.errors) > 0 {
+			continue
+		}
+		staff, err := CreateStaffWithTx(c, row.dto)
+		if err != nil {
+			row.addError("", "", "导入失败: %v", err)
+			continue
+		}
+		result.Success++
+		result.StaffIds = append(result.StaffIds, staff.StaffId)
+	}
+	successIds, successNum := result.StaffIds, result.Success
+	result = newStaffImportResult(rows, fileErrors)
+	result.Success, result.StaffIds = successNum, successIds
+	result.Committed = successNum > 0
+	return result, nil
+}
+
+// 校验并在原文件上标注结果：追加校验结果列，出错的单元格标红
+func AnnotateStaffImport(c *gin.Context, xfile *xlsx.File) (*model.StaffImportResult, error) {
+	result, err := ValidateStaffImport(c, xfile)
+	if err != nil {
+		return nil, err
+	}
+	type rowKey struct {
+		sheet string
+		row   int
+	}
+	rowErrors := make(map[rowKey][]model.StaffImportError)
+	for _, e := range result.Errors {
+		key := rowKey{e.Sheet, e.Row}
+		rowErrors[key] = append(rowErrors[key], e)
+	}
+	errorStyle := xlsx.NewStyle()
+	errorStyle.Fill = *xlsx.NewFill("solid", "FFFFC7CE", "FFFFC7CE")
+	errorStyle.ApplyFill = true
+	for _, sheet := range xfile.Sheets {
+		if len(sheet.Rows) == 0 {
+			continue
+		}
+		columns := make(map[string]int)
+		for i, cell := range sheet.Rows[0].Cells {
+			columns[strings.TrimSpace(cell.String())] = i
+		}
+		resultIndex := len(sheet.Rows[0].Cells)
+		sheet.Rows[0].AddCell().SetString(staffImportResultColumn)
+		for i, r := range sheet.Rows {
+			errs := rowErrors[rowKey{sheet.Name, i + 1}]
+			if i == 0 && len(errs) == 0 {
+				continue
+			}
+			if i > 0 && isEmptyRow(r) {
+				continue
+			}
+			for len(r.Cells) <= resultIndex {
+				r.AddCell()
+			}
+			var messages []string
+			for _, e := range errs {
+				if e.Column != "" {
+					messages = append(messages, fmt.Sprintf("%v: %v", e.Column, e.Message))
+					if index, ok := columns[e.Column]; ok {
+						r.Cells[index].SetStyle(errorStyle)
+					}
+				} else {
+					messages = append(messages, e.Message)
+				}
+			}
+			if len(messages) == 0 {
+				r.Cells[resultIndex].SetString("通过")
+				continue
+			}
+			r.Cells[resultIndex].SetString(strings.Join(messages, "；"))
+			r.Cells[resultIndex].SetStyle(errorStyle)
+		}
+	}
+	return result, nil
+}
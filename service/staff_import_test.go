@@ -0,0 +1,208 @@
+package service
+
+import (
+	"hrms/model"
+	"hrms/resource"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tealeg/xlsx"
+	"gorm.io/gorm"
+)
+
+var staffImportHeaders = []string{"员工姓名", "指定上级", "上级工号", "员工性别", "身份证号", "出生日期", "基本薪资", "职位", "部门", "手机号", "入职日期", "电子邮箱"}
+
+// 由前17位补全校验码
+func testIdentityNum(prefix string) string {
+	return prefix + string(IdentityCheckCode(prefix))
+}
+
+// 按表头顺序生成一行单元格，string、int64 及 time.Time 分别写入文本、数字及日期单元格
+func addImportRow(sheet *xlsx.Sheet, values map[string]interface{}) *xlsx.Row {
+	row := sheet.AddRow()
+	for _, header := range staffImportHeaders {
+		cell := row.AddCell()
+		switch v := values[header].(type) {
+		case string:
+			cell.SetString(v)
+		case int64:
+			cell.SetInt64(v)
+		case time.Time:
+			cell.SetDate(v)
+		}
+	}
+	return row
+}
+
+func validImportRow(identityNum string) map[string]interface{} {
+	return map[string]interface{}{
+		"员工姓名": "李四",
+		"身份证号": identityNum,
+		"基本薪资": int64(8000),
+		"职位":   "工程师",
+		"部门":   "研发部",
+		"手机号":  int64(13912345678),
+		"入职日期": "2024-03-01",
+	}
+}
+
+func newStaffImportContext() *staffImportContext {
+	return &staffImportContext{
+		deps: map[string]*model.Department{
+			"研发部": {DepId: "D01", DepName: "研发部"},
+			"旧部门": {DepId: "D02", DepName: "旧部门", ValidTo: time.Now().AddDate(0, -1, 0)},
+		},
+		rankIds:      map[string]string{"工程师": "R01"},
+		leaderNames:  map[string]string{"H00001": "张三"},
+		identityNum:  map[string]bool{testIdentityNum("11010119800101001"): true},
+		fileIdentity: make(map[string]string),
+	}
+}
+
+func TestStaffImportParseRow(t *testing.T) {
+	male := testIdentityNum("11010119900307001")
+	cases := []struct {
+		name     string
+		override map[string]interface{}
+		// 期望出错的列，为空表示校验通过
+		wantErr []string
+	}{
+		{"正常", nil, nil},
+		{"手机号为文本", map[string]interface{}{"手机号": "13912345678"}, nil},
+		{"手机号位数错误", map[string]interface{}{"手机号": int64(139123)}, []string{"手机号"}},
+		{"手机号含字母", map[string]interface{}{"手机号": "1391234567a"}, []string{"手机号"}},
+		{"入职日期为日期单元格", map[string]interface{}{"入职日期": time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)}, nil},
+		{"入职日期格式错误", map[string]interface{}{"入职日期": "2024/13/01"}, []string{"入职日期"}},
+		{"必填列为空", map[string]interface{}{"员工姓名": ""}, []string{"员工姓名"}},
+		{"部门不存在", map[string]interface{}{"部门": "市场部"}, []string{"部门"}},
+		{"部门已失效", map[string]interface{}{"部门": "旧部门"}, []string{"部门"}},
+		{"职位不存在", map[string]interface{}{"职位": "经理"}, []string{"职位"}},
+		{"基本薪资为负数", map[string]interface{}{"基本薪资": int64(-1)}, []string{"基本薪资"}},
+		{"身份证号校验码错误", map[string]interface{}{"身份证号": "110101199003070012"}, []string{"身份证号"}},
+		{"身份证号已存在", map[string]interface{}{"身份证号": testIdentityNum("11010119800101001")}, []string{"身份证号"}},
+		{"出生日期与身份证号不一致", map[string]interface{}{"出生日期": "1990-03-08"}, []string{"出生日期"}},
+		{"性别与身份证号不一致", map[string]interface{}{"员工性别": "女"}, []string{"员工性别"}},
+		{"性别错误", map[string]interface{}{"员工性别": "未知"}, []string{"员工性别"}},
+		{"上级工号", map[string]interface{}{"指定上级": "张三", "上级工号": "H00001"}, nil},
+		{"上级工号不存在", map[string]interface{}{"上级工号": "H09999"}, []string{"上级工号"}},
+		{"上级工号与指定上级不一致", map[string]interface{}{"指定上级": "王五", "上级工号": "H00001"}, []string{"上级工号"}},
+		{"电子邮箱格式错误", map[string]interface{}{"电子邮箱": "lisi"}, []string{"电子邮箱"}},
+	}
+	for _, tc := range cases {
+		values := validImportRow(male)
+		for k, v := range tc.override {
+			values[k] = v
+		}
+		sheet, _ := xlsx.NewFile().AddSheet("员工")
+		r := addImportRow(sheet, values)
+		row := &staffImportRow{sheet: "员工", row: 2}
+		newStaffImportContext().parseRow(row, staffImportHeaders, r.Cells)
+		var got []string
+		for _, e := range row.errors {
+			got = append(got, e.Column)
+		}
+		if strings.Join(got, ",") != strings.Join(tc.wantErr, ",") {
+			t.Errorf("%v: 出错的列 = %v, want %v, errors = %+v", tc.name, got, tc.wantErr, row.errors)
+		}
+		if len(tc.wantErr) == 0 {
+			dto := row.dto
+			if dto.BirthdayStr != "1990-03-07" || dto.SexStr != "男" || dto.Phone != 13912345678 || dto.EntryDateStr != "2024-03-01" {
+				t.Errorf("%v: dto = %+v", tc.name, dto)
+			}
+		}
+	}
+}
+
+func TestStaffImportDuplicateIdentityInFile(t *testing.T) {
+	ctx := newStaffImportContext()
+	sheet, _ := xlsx.NewFile().AddSheet("员工")
+	identityNum := testIdentityNum("11010119900307001")
+	var rows []*staffImportRow
+	for i := 0; i < 2; i++ {
+		r := addImportRow(sheet, validImportRow(identityNum))
+		row := &staffImportRow{sheet: "员工", row: i + 2}
+		ctx.parseRow(row, staffImportHeaders, r.Cells)
+		rows = append(rows, row)
+	}
+	if len(rows[0].errors) != 0 {
+		t.Errorf("第一行不应出错: %+v", rows[0].errors)
+	}
+	if len(rows[1].errors) != 1 || rows[1].errors[0].Column != "身份证号" || !strings.Contains(rows[1].errors[0].Message, "员工第2行") {
+		t.Errorf("重复的身份证号应指出首次出现的行: %+v", rows[1].errors)
+	}
+}
+
+// 创建员工相关测试用的数据库及请求上下文，当前用户为 T01 分公司的 root
+func newStaffTestContext(t *testing.T) (*gorm.DB, *gin.Context) {
+	t.Helper()
+	db := newTestDB(t, &model.Staff{}, &model.Authority{}, &model.StaffHistory{}, &model.IdSequence{},
+		&model.Department{}, &model.Rank{}, &model.StaffProbation{})
+	if err := db.Create(&model.Department{DepId: "D01", DepName: "研发部"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&model.Rank{RankId: "R01", RankName: "工程师"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	resource.DbMapper["hrms_T01"] = db
+	t.Cleanup(func() { delete(resource.DbMapper, "hrms_T01") })
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/", nil)
+	c.Request.AddCookie(&http.Cookie{Name: "user_cookie", Value: "sys_root_T01"})
+	return db, c
+}
+
+func TestCommitStaffImportAtomicRollback(t *testing.T) {
+	db, c := newStaffTestContext(t)
+	// 已删除员工不参与导入前的校验，但创建时仍会因身份证号重复失败
+	deleted := testIdentityNum("11010119800101001")
+	if err := db.Create(&model.Staff{StaffId: "H00001", StaffName: "张三", IdentityNum: deleted}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Delete(&model.Staff{}, "staff_id = ?", "H00001").Error; err != nil {
+		t.Fatal(err)
+	}
+	xfile := xlsx.NewFile()
+	sheet, _ := xfile.AddSheet("员工")
+	header := sheet.AddRow()
+	for _, h := range staffImportHeaders {
+		header.AddCell().SetString(h)
+	}
+	addImportRow(sheet, validImportRow(testIdentityNum("11010119900307001")))
+	addImportRow(sheet, validImportRow(deleted))
+
+	result, err := CommitStaffImport(c, xfile, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Committed || result.Success != 0 || len(result.StaffIds) != 0 {
+		t.Errorf("整体导入失败时不应提交: %+v", result)
+	}
+	if len(result.Errors) != 1 || result.Errors[0].Row != 3 || !strings.Contains(result.Errors[0].Message, "导入失败") {
+		t.Errorf("errors = %+v", result.Errors)
+	}
+	for _, m := range []interface{}{&model.Staff{}, &model.Authority{}, &model.StaffHistory{}} {
+		var count int64
+		db.Model(m).Count(&count)
+		if count != 0 {
+			t.Errorf("%T 应已回滚, count = %d", m, count)
+		}
+	}
+
+	// 非整体导入时只跳过失败的行
+	result, err = CommitStaffImport(c, xfile, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Committed || result.Success != 1 || result.Failed != 1 || len(result.StaffIds) != 1 {
+		t.Errorf("result = %+v", result)
+	}
+	var count int64
+	db.Model(&model.Staff{}).Count(&count)
+	if count != 1 {
+		t.Errorf("staff count = %d, want 1", count)
+	}
+}
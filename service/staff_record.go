@@ -0,0 +1,419 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"hrms/model"
+	"hrms/resource"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var ErrStaffRecordNotExist = errors.New("记录不存在")
+
+// 各类员工子记录的表单、存储及校验
+type staffRecordKind struct {
+	newForm   func() model.StaffRecordForm
+	newRecord func() model.StaffRecord
+	// 记录切片的指针，用于查询
+	newList func() interface{}
+	// 校验表单并转换为子记录
+	build func(staff *model.Staff, form model.StaffRecordForm) (model.StaffRecord, error)
+	// 保存后的处理，如同步首选联系人及最高学历
+	afterSave func(tx *gorm.DB, c *gin.Context, record model.StaffRecord) error
+}
+
+var staffRecordKinds = map[string]*staffRecordKind{
+	model.StaffRecordContact: {
+		newForm:   func() model.StaffRecordForm { return &model.StaffContactDTO{} },
+		newRecord: func() model.StaffRecord { return &model.StaffContact{} },
+		newList:   func() interface{} { return &[]model.StaffContact{} },
+		build:     buildStaffContact,
+		afterSave: afterSaveStaffContact,
+	},
+	model.StaffRecordFamily: {
+		newForm:   func() model.StaffRecordForm { return &model.StaffFamilyDTO{} },
+		newRecord: func() model.StaffRecord { return &model.StaffFamily{} },
+		newList:   func() interface{} { return &[]model.StaffFamily{} },
+		build:     buildStaffFamily,
+	},
+	model.StaffRecordEducation: {
+		newForm:   func() model.StaffRecordForm { return &model.StaffEducationDTO{} },
+		newRecord: func() model.StaffRecord { return &model.StaffEducation{} },
+		newList:   func() interface{} { return &[]model.StaffEducation{} },
+		build:     buildStaffEducation,
+		afterSave: afterSaveStaffEducation,
+	},
+	model.StaffRecordEmployment: {
+		newForm:   func() model.StaffRecordForm { return &model.StaffEmploymentDTO{} },
+		newRecord: func() model.StaffRecord { return &model.StaffEmployment{} },
+		newList:   func() interface{} { return &[]model.StaffEmployment{} },
+		build:     buildStaffEmployment,
+	},
+}
+
+func getStaffRecordKind(kind string) (*staffRecordKind, error) {
+	k, ok := staffRecordKinds[kind]
+	if !ok {
+		return nil, fmt.Errorf("不支持的记录类型: %v", kind)
+	}
+	return k, nil
+}
+
+// 按记录类型创建表单，用于绑定请求参数
+func NewStaffRecordForm(kind string) (model.StaffRecordForm, error) {
+	k, err := getStaffRecordKind(kind)
+	if err != nil {
+		return nil, err
+	}
+	return k.newForm(), nil
+}
+
+// 校验联系电话，允许手机号及带区号的固定电话
+func validContactPhone(phone string) bool {
+	if len(phone) < 7 || len(phone) > 20 {
+		return false
+	}
+	for i, r := range phone {
+		if !(r >= '0' && r <= '9') && r != '-' && !(r == '+' && i == 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// 校验起止日期，结束日期可为空
+func parseRecordPeriod(start, end string) (time.Time, time.Time, error) {
+	startDate, err := ParseDepDate(start)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	endDate, err := ParseDepDate(end)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if !endDate.IsZero() && !endDate.After(startDate) {
+		return time.Time{}, time.Time{}, errors.New("结束日期需晚于开始日期")
+	}
+	return startDate, endDate, nil
+}
+
+func buildStaffContact(staff *model.Staff, form model.StaffRecordForm) (model.StaffRecord, error) {
+	dto := form.(*model.StaffContactDTO)
+	phone := strings.TrimSpace(dto.Phone)
+	if !validContactPhone(phone) {
+		return nil, errors.New("联系电话格式错误")
+	}
+	return &model.StaffContact{
+		Name:      strings.TrimSpace(dto.Name),
+		Relation:  strings.TrimSpace(dto.Relation),
+		Phone:     phone,
+		Address:   strings.TrimSpace(dto.Address),
+		IsPrimary: dto.IsPrimary,
+	}, nil
+}
+
+// 每名员工至多一位首选联系人
+func afterSaveStaffContact(tx *gorm.DB, c *gin.Context, record model.StaffRecord) error {
+	contact := record.(*model.StaffContact)
+	if !contact.IsPrimary {
+		return nil
+	}
+	return tx.Model(&model.StaffContact{}).
+		Where("staff_id = ? and record_id != ? and is_primary = ?", contact.StaffId, contact.RecordId, true).
+		Update("is_primary", false).Error
+}
+
+func buildStaffFamily(staff *model.Staff, form model.StaffRecordForm) (model.StaffRecord, error) {
+	dto := form.(*model.StaffFamilyDTO)
+	if _, ok := model.FamilyRelationNames[dto.Relation]; !ok {
+		return nil, fmt.Errorf("不支持的家庭成员关系: %v", dto.Relation)
+	}
+	// 专项附加扣除的被扶养人为子女及父母
+	if dto.IsDependant && dto.Relation != model.FamilyRelationChild && dto.Relation != model.FamilyRelationParent {
+		return nil, errors.New("仅子女及父母可设为被扶养人")
+	}
+	family := &model.StaffFamily{
+		Name:        strings.TrimSpace(dto.Name),
+		Relation:    dto.Relation,
+		IdentityNum: NormalizeIdentityNum(dto.IdentityNum),
+		Phone:       strings.TrimSpace(dto.Phone),
+		IsDependant: dto.IsDependant,
+		Remark:      dto.Remark,
+	}
+	if family.Phone != "" && !validContactPhone(family.Phone) {
+		return nil, errors.New("联系电话格式错误")
+	}
+	birthdayStr := dto.BirthdayStr
+	if family.IdentityNum != "" {
+		// 性别不参与核对
+		var sexStr string
+		if err := ResolveIdentity(family.IdentityNum, &birthdayStr, &sexStr); err != nil {
+			return nil, err
+		}
+	}
+	birthday, err := ParseDepDate(birthdayStr)
+	if err != nil {
+		return nil, err
+	}
+	if birthday.After(time.Now()) {
+		return nil, errors.New("出生日期不能晚于今天")
+	}
+	family.Birthday = birthday
+	return family, nil
+}
+
+func buildStaffEducation(staff *model.Staff, form model.StaffRecordForm) (model.StaffRecord, error) {
+	dto := form.(*model.StaffEducationDTO)
+	startDate, endDate, err := parseRecordPeriod(dto.StartDate, dto.EndDate)
+	if err != nil {
+		return nil, err
+	}
+	return &model.StaffEducation{
+		School:    strings.TrimSpace(dto.School),
+		Major:     strings.TrimSpace(dto.Major),
+		EduLevel:  strings.TrimSpace(dto.EduLevel),
+		StartDate: startDate,
+		EndDate:   endDate,
+		IsHighest: dto.IsHighest,
+	}, nil
+}
+
+// 最高学历唯一，并同步到员工的毕业院校、专业及学历
+func afterSaveStaffEducation(tx *gorm.DB, c *gin.Context, record model.StaffRecord) error {
+	education := record.(*model.StaffEducation)
+	if !education.IsHighest {
+		return nil
+	}
+	if err := tx.Model(&model.StaffEducation{}).
+		Where("staff_id = ? and record_id != ? and is_highest = ?", education.StaffId, education.RecordId, true).
+		Update("is_highest", false).Error; err != nil {
+		return err
+	}
+	var old model.Staff
+	if err := tx.Where("staff_id = ?", education.StaffId).First(&old).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&model.Staff{}).Where("id = ?", old.ID).Updates(map[string]interface{}{
+		"school":    education.School,
+		"major":     education.Major,
+		"edu_level": education.EduLevel,
+	}).Error; err != nil {
+		return err
+	}
+	cur := old
+	cur.School, cur.Major, cur.EduLevel = education.School, education.Major, education.EduLevel
+	return RecordStaffHistory(tx, c, model.StaffActionEdit, &old, &cur)
+}
+
+func buildStaffEmployment(staff *model.Staff, form model.StaffRecordForm) (model.StaffRecord, error) {
+	dto := form.(*model.StaffEmploymentDTO)
+	startDate, endDate, err := parseRecordPeriod(dto.StartDate, dto.EndDate)
+	if err != nil {
+		return nil, err
+	}
+	if !staff.EntryDate.IsZero() && endDate.After(staff.EntryDate) {
+		return nil, errors.New("工作经历的结束日期不能晚于入职日期")
+	}
+	return &model.StaffEmployment{
+		Company:     strings.TrimSpace(dto.Company),
+		Position:    strings.TrimSpace(dto.Position),
+		StartDate:   startDate,
+		EndDate:     endDate,
+		LeaveReason: dto.LeaveReason,
+	}, nil
+}
+
+// 员工子记录仅管理员可维护
+func staffRecordDB(c *gin.Context) (*gorm.DB, error) {
+	db := resource.HrmsDB(c)
+	if db == nil {
+		return nil, resource.ErrUnauthorized
+	}
+	if !resource.IsAdmin(c) {
+		return nil, resource.ErrForbidden
+	}
+	return db, nil
+}
+
+func findStaffRecord(db *gorm.DB, k *staffRecordKind, recordId string) (model.StaffRecord, error) {
+	record := k.newRecord()
+	result := db.Where("record_id = ?", recordId).Limit(1).Find(record)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrStaffRecordNotExist
+	}
+	return record, nil
+}
+
+// 新增或修改员工子记录，record_id 为空时新增
+func SaveStaffRecord(c *gin.Context, kind string, form model.StaffRecordForm) (model.StaffRecord, error) {
+	db, err := staffRecordDB(c)
+	if err != nil {
+		return nil, err
+	}
+	return saveStaffRecord(db, c, kind, form)
+}
+
+func saveStaffRecord(db *gorm.DB, c *gin.Context, kind string, form model.StaffRecordForm) (model.StaffRecord, error) {
+	k, err := getStaffRecordKind(kind)
+	if err != nil {
+		return nil, err
+	}
+	var record model.StaffRecord
+	err = db.Transaction(func(tx *gorm.DB) error {
+		dto := form.RecordDTO()
+		var old model.StaffRecord
+		staffId := dto.StaffId
+		if dto.RecordId != "" {
+			if old, err = findStaffRecord(tx, k, dto.RecordId); err != nil {
+				return err
+			}
+			staffId = old.Base().StaffId
+		}
+		var staffs []model.Staff
+		if err := tx.Where("staff_id = ? and staff_id != 'root' and staff_id != 'admin'", staffId).Find(&staffs).Error; err != nil {
+			return err
+		}
+		if len(staffs) == 0 {
+			return fmt.Errorf("员工 %v 不存在", staffId)
+		}
+		if record, err = k.build(&staffs[0], form); err != nil {
+			return err
+		}
+		base := record.Base()
+		if old != nil {
+			*base = *old.Base()
+			err = tx.Save(record).Error
+		} else {
+			base.RecordId = RandomID(kind)
+			base.StaffId = staffId
+			err = tx.Create(record).Error
+		}
+		if err != nil {
+			return err
+		}
+		if k.afterSave != nil {
+			return k.afterSave(tx, c, record)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("SaveStaffRecord err = %v", err)
+		return nil, err
+	}
+	return record, nil
+}
+
+func DelStaffRecord(c *gin.Context, kind, recordId string) error {
+	db, err := staffRecordDB(c)
+	if err != nil {
+		return err
+	}
+	k, err := getStaffRecordKind(kind)
+	if err != nil {
+		return err
+	}
+	result := db.Where("record_id = ?", recordId).Delete(k.newRecord())
+	if result.Error != nil {
+		log.Printf("DelStaffRecord err = %v", result.Error)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrStaffRecordNotExist
+	}
+	return nil
+}
+
+// 查询员工的子记录，管理员及本人可查看，返回记录切片
+func GetStaffRecords(c *gin.Context, kind, staffId string) (interface{}, int64, error) {
+	db := resource.HrmsDB(c)
+	if db == nil {
+		return nil, 0, resource.ErrUnauthorized
+	}
+	if !resource.IsAdmin(c) && staffId != resource.CurrentStaffId(c) {
+		return nil, 0, resource.ErrForbidden
+	}
+	k, err := getStaffRecordKind(kind)
+	if err != nil {
+		return nil, 0, err
+	}
+	list := k.newList()
+	result := db.Where("staff_id = ?", staffId).Order("id").Find(list)
+	if result.Error != nil {
+		log.Printf("GetStaffRecords err = %v", result.Error)
+		return nil, 0, result.Error
+	}
+	RecordAccess(c, model.AccessResourceStaffRecord, kind, staffId)
+	return list, result.RowsAffected, nil
+}
+
+// 导出的子记录类型顺序
+var staffRecordExportKinds = []string{model.StaffRecordContact, model.StaffRecordFamily, model.StaffRecordEducation, model.StaffRecordEmployment}
+
+// 导出的子记录表头，首列为员工工号
+var staffRecordHeaders = map[string][]string{
+	model.StaffRecordContact:    {"工号", "姓名", "关系", "联系电话", "联系地址", "首选联系人"},
+	model.StaffRecordFamily:     {"工号", "姓名", "关系", "出生日期", "身份证号", "联系电话", "被扶养人", "备注"},
+	model.StaffRecordEducation:  {"工号", "学校", "专业", "学历", "开始日期", "结束日期", "最高学历"},
+	model.StaffRecordEmployment: {"工号", "公司", "职位", "开始日期", "结束日期", "离职原因"},
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "是"
+	}
+	return "否"
+}
+
+// 查询一批员工的子记录并转为导出行，mask 用于敏感字段脱敏
+func staffRecordRows(db *gorm.DB, kind string, staffIds []string, mask func(staffId, field, value string) string) ([][]string, error) {
+	query := db.Where("staff_id in ?", staffIds).Order("staff_id, id")
+	var rows [][]string
+	switch kind {
+	case model.StaffRecordContact:
+		var records []model.StaffContact
+		if err := query.Find(&records).Error; err != nil {
+			return nil, err
+		}
+		for _, r := range records {
+			rows = append(rows, []string{r.StaffId, r.Name, r.Relation,
+				mask(r.StaffId, model.SensitivePhone, r.Phone), r.Address, yesNo(r.IsPrimary)})
+		}
+	case model.StaffRecordFamily:
+		var records []model.StaffFamily
+		if err := query.Find(&records).Error; err != nil {
+			return nil, err
+		}
+		for _, r := range records {
+			rows = append(rows, []string{r.StaffId, r.Name, model.FamilyRelationNames[r.Relation], exportDate(r.Birthday),
+				mask(r.StaffId, model.SensitiveIdentityNum, r.IdentityNum), mask(r.StaffId, model.SensitivePhone, r.Phone),
+				yesNo(r.IsDependant), r.Remark})
+		}
+	case model.StaffRecordEducation:
+		var records []model.StaffEducation
+		if err := query.Find(&records).Error; err != nil {
+			return nil, err
+		}
+		for _, r := range records {
+			rows = append(rows, []string{r.StaffId, r.School, r.Major, r.EduLevel,
+				exportDate(r.StartDate), exportDate(r.EndDate), yesNo(r.IsHighest)})
+		}
+	case model.StaffRecordEmployment:
+		var records []model.StaffEmployment
+		if err := query.Find(&records).Error; err != nil {
+			return nil, err
+		}
+		for _, r := range records {
+			rows = append(rows, []string{r.StaffId, r.Company, r.Position,
+				exportDate(r.StartDate), exportDate(r.EndDate), r.LeaveReason})
+		}
+	}
+	return rows, nil
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"fmt"
+	"hrms/model"
+	"hrms/resource"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 允许排序的字段
+var staffSortColumns = map[string]bool{
+	"staff_id":    true,
+	"staff_name":  true,
+	"dep_id":      true,
+	"rank_id":     true,
+	"edu_level":   true,
+	"entry_date":  true,
+	"birthday":    true,
+	"base_salary": true,
+	"status":      true,
+	"created_at":  true,
+}
+
+// 解析排序参数，只允许白名单中的字段
+func parseStaffSort(sort string) ([]string, error) {
+	var orders []string
+	for _, field := range strings.Split(sort, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		direction := "asc"
+		if strings.HasPrefix(field, "-") {
+			field, direction = field[1:], "desc"
+		}
+		if !staffSortColumns[field] {
+			return nil, fmt.Errorf("不支持按 %v 排序", field)
+		}
+		orders = append(orders, field+" "+direction)
+	}
+	return orders, nil
+}
+
+// 按部门编号及部门名称过滤，默认包含下级部门
+func staffDepFilter(db *gorm.DB, query *gorm.DB, q *model.StaffSearchQuery) (*gorm.DB, error) {
+	includeSub := q.IncludeSubDeps == nil || *q.IncludeSubDeps
+	var depIdGroups [][]string
+	if q.DepId != "" {
+		depIdGroups = append(depIdGroups, []string{q.DepId})
+	}
+	if q.DepName != "" {
+		var depIds []string
+		if err := db.Model(&model.Department{}).Where("dep_name like ?", "%"+q.DepName+"%").
+			Pluck("dep_id", &depIds).Error; err != nil {
+			return nil, err
+		}
+		depIdGroups = append(depIdGroups, depIds)
+	}
+	for _, depIds := range depIdGroups {
+		if includeSub && len(depIds) > 0 {
+			subtree, err := DepSubtree(db, depIds)
+			if err != nil {
+				return nil, err
+			}
+			depIds = subtree
+		}
+		query = query.Where("dep_id in ?", depIds)
+	}
+	return query, nil
+}
+
+// 员工组合查询，返回当前页数据及满足条件的总数
+func SearchStaff(c *gin.Context, q *model.StaffSearchQuery, start int, limit int) ([]model.Staff, int64, error) {
+	var staffs []model.Staff
+	var total int64
+	db := resource.HrmsDB(c)
+	if db == nil {
+		log.Printf("SearchStaff: 数据库连接为空，鉴权失败")
+		return nil, 0, resource.ErrUnauthorized
+	}
+	query := db.Model(&model.Staff{}).Where("staff_id != 'root' and staff_id != 'admin'")
+	if q.StaffId != "" {
+		query = query.Where("staff_id = ?", q.StaffId)
+	}
+	if q.StaffName != "" {
+		query = query.Where("staff_name like ?", "%"+q.StaffName+"%")
+	}
+	query, err := staffDepFilter(db, query, q)
+	if err != nil {
+		return nil, 0, err
+	}
+	if q.RankId != "" {
+		query = query.Where("rank_id = ?", q.RankId)
+	}
+	if q.EduLevel != "" {
+		query = query.Where("edu_level = ?", q.EduLevel)
+	}
+	if q.EntryDateFrom != "" {
+		from, err := ParseDepDate(q.EntryDateFrom)
+		if err != nil {
+			return nil, 0, err
+		}
+		query = query.Where("entry_date >= ?", from)
+	}
+	if q.EntryDateTo != "" {
+		to, err := ParseDepDate(q.EntryDateTo)
+		if err != nil {
+			return nil, 0, err
+		}
+		query = query.Where("entry_date < ?", to.AddDate(0, 0, 1))
+	}
+	if q.Status != "" {
+		// 离职类状态与 Staff.HasLeft 一致，生效日期未到的不计入
+		var statuses, exitStatuses []int64
+		for _, s := range strings.Split(q.Status, ",") {
+			status, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+			if err != nil {
+				return nil, 0, fmt.Errorf("在职状态参数错误: %v", s)
+			}
+			if model.IsExitStatus(status) {
+				exitStatuses = append(exitStatuses, status)
+			} else {
+				statuses = append(statuses, status)
+			}
+		}
+		switch {
+		case len(exitStatuses) == 0:
+			query = query.Where("status in ?", statuses)
+		case len(statuses) == 0:
+			query = query.Where("status in ? and status_date <= ?", exitStatuses, time.Now())
+		default:
+			query = query.Where("status in ? or (status in ? and status_date <= ?)", statuses, exitStatuses, time.Now())
+		}
+	}
+	if q.LeaderStaffId != "" {
+		query = query.Where("leader_staff_id = ?", q.LeaderStaffId)
+	}
+	orders, err := parseStaffSort(q.Sort)
+	if err != nil {
+		return nil, 0, err
+	}
+	// 查询条件在列表及总数查询中复用
+	query = query.Session(&gorm.Session{})
+	if err := query.Count(&total).Error; err != nil {
+		log.Printf("SearchStaff err = %v", err)
+		return nil, 0, err
+	}
+	list := query
+	for _, order := range orders {
+		list = list.Order(order)
+	}
+	// 排序字段相同时按主键排序，保证分页结果稳定
+	list = list.Order("id")
+	if start != -1 && limit != -1 {
+		list = list.Offset(start).Limit(limit)
+	}
+	if err := list.Find(&staffs).Error; err != nil {
+		log.Printf("SearchStaff err = %v", err)
+		return nil, 0, err
+	}
+	return staffs, total, nil
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"hrms/model"
+	"hrms/resource"
+	"log"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 允许的状态流转，离职类状态为终态，再次入职需重新建档
+var staffStatusTransitions = map[int64][]int64{
+	model.StaffStatusProbation: {model.StaffStatusActive, model.StaffStatusSuspended, model.StaffStatusResigned, model.StaffStatusTerminated},
+	model.StaffStatusActive:    {model.StaffStatusSuspended, model.StaffStatusResigned, model.StaffStatusTerminated, model.StaffStatusRetired},
+	model.StaffStatusSuspended: {model.StaffStatusActive, model.StaffStatusResigned, model.StaffStatusTerminated, model.StaffStatusRetired},
+}
+
+func canTransferStatus(from, to int64) bool {
+	for _, status := range staffStatusTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
+func StaffStatusName(status int64) string {
+	if name, ok := model.StaffStatusNames[status]; ok {
+		return name
+	}
+	return "未知"
+}
+
+// 变更员工在职状态并记录变更日志，离职类状态生效后该员工无法登录
+func ChangeStaffStatus(c *gin.Context, dto *model.StaffStatusChangeDTO) error {
+	db := resource.HrmsDB(c)
+	if db == nil {
+		log.Printf("ChangeStaffStatus: 数据库连接为空，鉴权失败")
+		return resource.ErrUnauthorized
+	}
+	if !resource.IsAdmin(c) {
+		return resource.ErrForbidden
+	}
+	if _, ok := model.StaffStatusNames[dto.Status]; !ok {
+		return fmt.Errorf("无效的在职状态: %v", dto.Status)
+	}
+	effectiveDate, err := time.ParseInLocation("2006-01-02", dto.EffectiveDateStr, time.Local)
+	if err != nil {
+		return errors.New("生效日期格式错误，应为 YYYY-MM-DD")
+	}
+
+	var staff model.Staff
+	if err := db.Where("staff_id = ? and staff_id != 'root' and staff_id != 'admin'", dto.StaffId).First(&staff).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("员工不存在")
+		}
+		return err
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		// 有进行中的试用期考核时，需通过考核流程转正
+		probation, err := openStaffProbation(tx, staff.StaffId)
+		if err != nil {
+			return err
+		}
+		if probation != nil && dto.Status == model.StaffStatusActive {
+			return errors.New("该员工的试用期考核尚未完成，请通过试用期考核办理转正")
+		}
+		if probation != nil && model.IsExitStatus(dto.Status) {
+			if err := closeStaffProbation(tx, c, probation, dto.Reason); err != nil {
+				return err
+			}
+		}
+		return transferStaffStatus(tx, c, &staff, dto.Status, effectiveDate, dto.Reason)
+	})
+}
+
+// 在事务中变更员工在职状态，记录状态变更日志及员工变更历史
+func transferStaffStatus(tx *gorm.DB, c *gin.Context, staff *model.Staff, status int64, effectiveDate time.Time, reason string) error {
+	if !canTransferStatus(staff.Status, status) {
+		return fmt.Errorf("不允许从%v变更为%v", StaffStatusName(staff.Status), StaffStatusName(status))
+	}
+	statusLog := model.StaffStatusLog{
+		LogId:         RandomID("staff_status"),
+		StaffId:       staff.StaffId,
+		StaffName:     staff.StaffName,
+		FromStatus:    staff.Status,
+		ToStatus:      status,
+		EffectiveDate: effectiveDate,
+		Reason:        reason,
+		OperatorId:    resource.CurrentStaffId(c),
+	}
+	if err := tx.Model(&model.Staff{}).Where("id = ?", staff.ID).Updates(map[string]interface{}{
+		"status":      status,
+		"status_date": effectiveDate,
+	}).Error; err != nil {
+		log.Printf("transferStaffStatus err = %v", err)
+		return err
+	}
+	if err := tx.Create(&statusLog).Error; err != nil {
+		log.Printf("transferStaffStatus err = %v", err)
+		return err
+	}
+	var cur model.Staff
+	if err := tx.Where("id = ?", staff.ID).First(&cur).Error; err != nil {
+		return err
+	}
+	return RecordStaffHistory(tx, c, model.StaffActionStatus, staff, &cur)
+}
+
+// 查询员工状态变更记录，按生效日期倒序
+func GetStaffStatusLogByStaffId(c *gin.Context, staffId string) ([]*model.StaffStatusLog, error) {
+	var logs []*model.StaffStatusLog
+	db := resource.HrmsDB(c)
+	if db == nil {
+		log.Printf("GetStaffStatusLogByStaffId: 数据库连接为空，鉴权失败")
+		return nil, resource.ErrUnauthorized
+	}
+	if err := db.Where("staff_id = ?", staffId).Order("effective_date desc, id desc").Find(&logs).Error; err != nil {
+		log.Printf("GetStaffStatusLogByStaffId err = %v", err)
+		return nil, err
+	}
+	return logs, nil
+}